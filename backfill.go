@@ -0,0 +1,123 @@
+package main
+
+import (
+	"CurrencyMonitor/backfill"
+	"CurrencyMonitor/config"
+	"CurrencyMonitor/database"
+	"CurrencyMonitor/models"
+	"CurrencyMonitor/services"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+// runBackfill 执行backfill子命令：查找已保存的多空比数据中的缺口并从交易所补齐
+func runBackfill(args []string) error {
+	fs := flag.NewFlagSet("currency_monitor backfill", flag.ContinueOnError)
+	days := fs.Int("days", 0, "检查最近多少天的数据（默认为scheduler.retention_days）")
+	periods := fs.String("period", "", "时间粒度，逗号分隔（默认为scheduler.ratio_periods）")
+	exchangeNames := fs.String("exchange", "", "交易所，逗号分隔（默认为所有启用的交易所）")
+	symbols := fs.String("symbol", "", "交易对，逗号分隔（默认为当前跟踪的交易对）")
+	metrics := fs.String("metric", "", "指标类型，逗号分隔（默认为各交易所支持的全部指标）")
+	dryRun := fs.Bool("dry-run", false, "只列出缺口，不请求交易所")
+
+	cfg, err := config.LoadFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("未知参数: %s", strings.Join(fs.Args(), " "))
+	}
+
+	if err := database.InitDatabase(cfg.Database); err != nil {
+		return err
+	}
+
+	// Ctrl+C时中止进行中的交易所请求和数据库操作
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	exchanges, err := services.NewExchanges(cfg.Exchanges)
+	if err != nil {
+		return err
+	}
+	if names := splitList(*exchangeNames); len(names) > 0 {
+		exchanges, err = services.SelectExchanges(exchanges, names)
+		if err != nil {
+			return err
+		}
+	}
+
+	opts := backfill.Options{
+		Exchanges: exchanges,
+		Symbols:   splitList(*symbols),
+		Metrics:   splitList(*metrics),
+		Periods:   splitList(*periods),
+		DryRun:    *dryRun,
+	}
+	for _, metric := range opts.Metrics {
+		if !services.IsValidMetric(metric) {
+			return fmt.Errorf("不支持的指标类型: %s", metric)
+		}
+	}
+	if len(opts.Symbols) == 0 {
+		opts.Symbols, err = models.NewSymbolRepository(database.GetDB()).GetTrackedSymbols(ctx)
+		if err != nil || len(opts.Symbols) == 0 {
+			opts.Symbols = cfg.Universe.Symbols
+		}
+	}
+	if len(opts.Periods) == 0 {
+		opts.Periods = cfg.Scheduler.RatioPeriods
+	}
+	if *days <= 0 {
+		*days = cfg.Scheduler.RetentionDays
+	}
+	opts.Since = time.Now().AddDate(0, 0, -*days)
+
+	backfiller := backfill.NewBackfiller(models.NewLongShortRatioRepository(database.GetDB()))
+	if *dryRun {
+		gaps, err := backfiller.FindGaps(ctx, opts)
+		if err != nil {
+			return err
+		}
+		printGaps(gaps)
+		return nil
+	}
+
+	progress, err := backfiller.Run(ctx, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("补齐完成: 缺口%d个，缺少约%d条，保存%d条，超出回溯范围%d个，失败%d个\n",
+		progress.Gaps, progress.Missing, progress.Saved, progress.Skipped, len(progress.Errors))
+	return nil
+}
+
+// splitList 拆分逗号分隔的参数
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// printGaps 输出缺口列表
+func printGaps(gaps []backfill.Gap) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "交易所\t交易对\t指标\t粒度\t开始时间\t结束时间\t缺少")
+	for _, gap := range gaps {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\n", gap.Exchange, gap.Symbol, gap.Metric, gap.Period,
+			gap.Start.Format("2006-01-02 15:04"), gap.End.Format("2006-01-02 15:04"), gap.Missing)
+	}
+	w.Flush()
+	fmt.Printf("共%d个缺口\n", len(gaps))
+}
@@ -0,0 +1,386 @@
+package backfill
+
+import (
+	"CurrencyMonitor/models"
+	"CurrencyMonitor/services"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// fallbackMaxLimit 不支持时间范围查询的交易所和指标单次获取的最大数据点数量，更早的缺口按超出回溯范围跳过
+const fallbackMaxLimit = 500
+
+// ErrRunning 已有补齐任务正在执行
+var ErrRunning = errors.New("已有补齐任务正在执行")
+
+// Options 补齐范围
+type Options struct {
+	Exchanges []services.ExchangeService
+	Symbols   []string
+	Metrics   []string // 为空时检查交易所支持的全部指标
+	Periods   []string
+	Since     time.Time // 检查缺口的起始时间
+	DryRun    bool      // 只查找缺口，不请求交易所
+}
+
+// Gap 缺失数据的时间段
+type Gap struct {
+	Exchange string    `json:"exchange"`
+	Symbol   string    `json:"symbol"`
+	Metric   string    `json:"metric"`
+	Period   string    `json:"period"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Missing  int       `json:"missing"` // 缺失的数据点数量（按时间粒度估算）
+}
+
+// String 缺口的文字说明
+func (g Gap) String() string {
+	return fmt.Sprintf("%s %s %s %s %s ~ %s", g.Exchange, g.Symbol, g.Metric, g.Period,
+		g.Start.Format("2006-01-02 15:04"), g.End.Format("2006-01-02 15:04"))
+}
+
+// Progress 补齐进度
+type Progress struct {
+	Running    bool       `json:"running"`
+	DryRun     bool       `json:"dry_run"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Gaps       int        `json:"gaps"`    // 发现的缺口数量
+	Done       int        `json:"done"`    // 已处理的缺口数量
+	Missing    int        `json:"missing"` // 缺失的数据点数量
+	Saved      int        `json:"saved"`   // 从交易所获取并保存的数据点数量
+	Skipped    int        `json:"skipped"` // 超出交易所回溯范围而跳过的缺口数量
+	Current    string     `json:"current,omitempty"`
+	Errors     []string   `json:"errors,omitempty"`
+}
+
+// Backfiller 查找并补齐已保存的多空比序列中缺失的数据
+type Backfiller struct {
+	repo *models.LongShortRatioRepository
+
+	mu       sync.Mutex
+	progress Progress
+}
+
+// NewBackfiller 创建历史数据补齐器
+func NewBackfiller(repo *models.LongShortRatioRepository) *Backfiller {
+	return &Backfiller{repo: repo}
+}
+
+// Progress 获取最近一次补齐任务的进度
+func (b *Backfiller) Progress() Progress {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	progress := b.progress
+	progress.Errors = append([]string(nil), b.progress.Errors...)
+	return progress
+}
+
+// Run 查找并补齐缺口，执行期间可以通过Progress查询进度，ctx取消时停止处理剩余的缺口
+func (b *Backfiller) Run(ctx context.Context, opts Options) (Progress, error) {
+	if !b.begin(opts) {
+		return b.Progress(), ErrRunning
+	}
+	err := b.run(ctx, opts)
+	return b.Progress(), err
+}
+
+// Start 在后台执行补齐任务，已有任务在执行时返回ErrRunning。任务在ctx取消时停止，
+// 因此ctx不能是很快结束的HTTP请求的context
+func (b *Backfiller) Start(ctx context.Context, opts Options) error {
+	if !b.begin(opts) {
+		return ErrRunning
+	}
+	go func() {
+		if err := b.run(ctx, opts); err != nil {
+			log.Printf("补齐历史数据失败: %v", err)
+		}
+	}()
+	return nil
+}
+
+// begin 重置进度并标记任务开始，已有任务在执行时返回false
+func (b *Backfiller) begin(opts Options) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.progress.Running {
+		return false
+	}
+	b.progress = Progress{Running: true, DryRun: opts.DryRun, StartedAt: time.Now()}
+	return true
+}
+
+// update 修改进度
+func (b *Backfiller) update(fn func(p *Progress)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	fn(&b.progress)
+}
+
+// run 执行补齐任务
+func (b *Backfiller) run(ctx context.Context, opts Options) error {
+	defer b.update(func(p *Progress) {
+		now := time.Now()
+		p.Running = false
+		p.Current = ""
+		p.FinishedAt = &now
+	})
+
+	gaps, err := b.FindGaps(ctx, opts)
+	if err != nil {
+		b.update(func(p *Progress) { p.Errors = append(p.Errors, err.Error()) })
+		return err
+	}
+
+	missing := 0
+	for _, gap := range gaps {
+		missing += gap.Missing
+	}
+	b.update(func(p *Progress) {
+		p.Gaps = len(gaps)
+		p.Missing = missing
+	})
+	log.Printf("发现%d个缺口，缺少约%d条多空比数据", len(gaps), missing)
+	if opts.DryRun {
+		return nil
+	}
+
+	exchanges := make(map[string]services.ExchangeService, len(opts.Exchanges))
+	for _, exchange := range opts.Exchanges {
+		exchanges[exchange.Name()] = exchange
+	}
+
+	for i, gap := range gaps {
+		if err := ctx.Err(); err != nil {
+			b.update(func(p *Progress) { p.Errors = append(p.Errors, fmt.Sprintf("补齐任务已取消: %v", err)) })
+			return err
+		}
+		b.update(func(p *Progress) { p.Current = gap.String() })
+
+		saved, skipped, err := b.fill(ctx, exchanges[gap.Exchange], gap)
+		switch {
+		case err != nil:
+			log.Printf("[%d/%d] 补齐%s失败: %v", i+1, len(gaps), gap, err)
+		case skipped:
+			log.Printf("[%d/%d] %s超出交易所回溯范围，跳过", i+1, len(gaps), gap)
+		default:
+			log.Printf("[%d/%d] 补齐%s: 缺少约%d条，保存%d条", i+1, len(gaps), gap, gap.Missing, saved)
+		}
+
+		b.update(func(p *Progress) {
+			p.Done++
+			p.Saved += saved
+			if skipped {
+				p.Skipped++
+			}
+			if err != nil {
+				p.Errors = append(p.Errors, fmt.Sprintf("%s: %v", gap, err))
+			}
+		})
+	}
+	return nil
+}
+
+// FindGaps 查找[opts.Since, 当前时间]内已保存的多空比中缺失的时间段，只检查交易所支持的指标
+func (b *Backfiller) FindGaps(ctx context.Context, opts Options) ([]Gap, error) {
+	now := time.Now()
+	var gaps []Gap
+
+	for _, exchange := range opts.Exchanges {
+		for _, metric := range metrics(exchange, opts.Metrics) {
+			for _, symbol := range opts.Symbols {
+				for _, period := range opts.Periods {
+					d, ok := services.PeriodDuration(period)
+					if !ok {
+						return nil, fmt.Errorf("不支持的时间粒度: %s", period)
+					}
+
+					// 最新一个周期的数据交易所可能还没有发布，不算作缺口
+					until := now.Add(-d)
+					timestamps, err := b.repo.GetTimestamps(ctx, exchange.Name(), symbol, metric, period, opts.Since, until)
+					if err != nil {
+						return nil, fmt.Errorf("查询%s %s %s %s数据失败: %w", exchange.Name(), symbol, metric, period, err)
+					}
+
+					for _, r := range findGaps(timestamps, opts.Since, until, d) {
+						gaps = append(gaps, Gap{
+							Exchange: exchange.Name(),
+							Symbol:   symbol,
+							Metric:   metric,
+							Period:   period,
+							Start:    r[0],
+							End:      r[1],
+							Missing:  int(r[1].Sub(r[0])/d) + 1,
+						})
+					}
+				}
+			}
+		}
+	}
+
+	return gaps, nil
+}
+
+// metrics 交易所需要检查的指标：selected为空时为交易所支持的全部指标，否则为其中交易所支持的部分
+func metrics(exchange services.ExchangeService, selected []string) []string {
+	if len(selected) == 0 {
+		return services.SupportedMetrics(exchange)
+	}
+	var results []string
+	for _, metric := range selected {
+		if services.SupportsMetric(exchange, metric) {
+			results = append(results, metric)
+		}
+	}
+	return results
+}
+
+// findGaps 查找[since, until]内间隔超过时间粒度的时间段，返回每段的起止时间
+func findGaps(timestamps []time.Time, since, until time.Time, d time.Duration) [][2]time.Time {
+	if !since.Before(until) {
+		return nil
+	}
+	if len(timestamps) == 0 {
+		return [][2]time.Time{{since, until}}
+	}
+
+	var gaps [][2]time.Time
+	if first := timestamps[0]; first.Sub(since) >= d {
+		gaps = append(gaps, [2]time.Time{since, first.Add(-d)})
+	}
+	// 相邻数据点间隔超过1.5个周期才算缺口，容忍交易所时间戳的轻微偏差
+	for i := 1; i < len(timestamps); i++ {
+		if timestamps[i].Sub(timestamps[i-1]) > d*3/2 {
+			gaps = append(gaps, [2]time.Time{timestamps[i-1].Add(d), timestamps[i].Add(-d)})
+		}
+	}
+	if last := timestamps[len(timestamps)-1]; until.Sub(last) >= d {
+		gaps = append(gaps, [2]time.Time{last.Add(d), until})
+	}
+	return gaps
+}
+
+// fill 从交易所获取缺口内的数据并保存，返回保存的数量以及是否因超出回溯范围而跳过
+func (b *Backfiller) fill(ctx context.Context, exchange services.ExchangeService, gap Gap) (int, bool, error) {
+	if exchange == nil {
+		return 0, false, fmt.Errorf("交易所%s未启用", gap.Exchange)
+	}
+
+	start, end := gap.Start, gap.End
+	if earliest := time.Now().Add(-lookback(exchange, gap.Metric, gap.Period)); start.Before(earliest) {
+		if end.Before(earliest) {
+			return 0, true, nil
+		}
+		start = earliest
+	}
+
+	rangeService, ok := rangeService(exchange, gap.Metric)
+	if !ok {
+		saved, err := b.fillLatest(ctx, exchange, gap, start, end)
+		return saved, false, err
+	}
+
+	// 交易所单次返回的数据有上限，返回的数据可能只覆盖请求范围的中间一段，两端没有覆盖的部分分别继续请求
+	d, _ := services.PeriodDuration(gap.Period)
+	saved := 0
+	pending := [][2]time.Time{{start, end}}
+	for len(pending) > 0 {
+		r := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		data, err := rangeService.GetLongShortRatioRange(ctx, gap.Symbol, gap.Period, r[0], r[1])
+		if err != nil {
+			return saved, false, err
+		}
+
+		page := within(data, r[0], r[1])
+		if len(page) == 0 {
+			continue
+		}
+		if err := b.repo.UpsertMany(ctx, services.RatioModels(page)); err != nil {
+			return saved, false, fmt.Errorf("保存数据失败: %w", err)
+		}
+		saved += len(page)
+
+		first, last := bounds(page)
+		if last.Before(r[1].Add(-d)) {
+			pending = append(pending, [2]time.Time{last.Add(time.Second), r[1]})
+		}
+		if first.After(r[0].Add(d)) {
+			pending = append(pending, [2]time.Time{r[0], first.Add(-time.Second)})
+		}
+	}
+	return saved, false, nil
+}
+
+// rangeService 交易所支持按时间范围查询指标时返回对应的接口，目前只有全市场账户多空比支持
+func rangeService(exchange services.ExchangeService, metric string) (services.RatioRangeService, bool) {
+	if metric != services.MetricGlobalAccount {
+		return nil, false
+	}
+	rangeService, ok := exchange.(services.RatioRangeService)
+	return rangeService, ok
+}
+
+// lookback 交易所可以补齐的历史范围，不支持按时间范围查询时只能获取最近fallbackMaxLimit个周期的数据
+func lookback(exchange services.ExchangeService, metric, period string) time.Duration {
+	if rangeService, ok := rangeService(exchange, metric); ok {
+		return rangeService.RatioLookback(period)
+	}
+	d, _ := services.PeriodDuration(period)
+	return time.Duration(fallbackMaxLimit-1) * d
+}
+
+// fillLatest 交易所不支持按时间范围查询时获取最近的数据，补齐[start, end]内的部分
+func (b *Backfiller) fillLatest(ctx context.Context, exchange services.ExchangeService, gap Gap, start, end time.Time) (int, error) {
+	d, _ := services.PeriodDuration(gap.Period)
+	limit := int(time.Since(start)/d) + 1
+	if limit > fallbackMaxLimit {
+		limit = fallbackMaxLimit
+	}
+
+	data, err := services.GetMetricHistory(ctx, exchange, gap.Symbol, gap.Metric, gap.Period, limit)
+	if err != nil {
+		return 0, err
+	}
+	page := within(data, start, end)
+	if len(page) == 0 {
+		return 0, nil
+	}
+	if err := b.repo.UpsertMany(ctx, services.RatioModels(page)); err != nil {
+		return 0, fmt.Errorf("保存数据失败: %w", err)
+	}
+	return len(page), nil
+}
+
+// within 过滤出[start, end]内的数据
+func within(data []*services.LongShortRatioData, start, end time.Time) []*services.LongShortRatioData {
+	var results []*services.LongShortRatioData
+	for _, item := range data {
+		if !item.Timestamp.Before(start) && !item.Timestamp.After(end) {
+			results = append(results, item)
+		}
+	}
+	return results
+}
+
+// bounds 获取数据中最早和最晚的时间戳，交易所返回的数据不一定按时间排序
+func bounds(data []*services.LongShortRatioData) (time.Time, time.Time) {
+	first, last := data[0].Timestamp, data[0].Timestamp
+	for _, item := range data[1:] {
+		if item.Timestamp.Before(first) {
+			first = item.Timestamp
+		}
+		if item.Timestamp.After(last) {
+			last = item.Timestamp
+		}
+	}
+	return first, last
+}
@@ -0,0 +1,188 @@
+package backfill
+
+import (
+	"CurrencyMonitor/internal/testdb"
+	"CurrencyMonitor/models"
+	"CurrencyMonitor/services"
+	"context"
+	"testing"
+	"time"
+)
+
+// latestOnlyExchange 不支持按时间范围查询的交易所，只返回截至end的最近limit个周期的数据
+type latestOnlyExchange struct {
+	services.ExchangeService
+
+	end    time.Time
+	limits []int
+}
+
+func (e *latestOnlyExchange) Name() string {
+	return "bitget"
+}
+
+func (e *latestOnlyExchange) GetLongShortRatioHistory(ctx context.Context, symbol, period string, limit int) ([]*services.LongShortRatioData, error) {
+	e.limits = append(e.limits, limit)
+
+	d, _ := services.PeriodDuration(period)
+	var data []*services.LongShortRatioData
+	for i := limit - 1; i >= 0; i-- {
+		data = append(data, &services.LongShortRatioData{
+			Exchange:  e.Name(),
+			Symbol:    symbol,
+			Metric:    services.MetricGlobalAccount,
+			Period:    period,
+			Ratio:     1,
+			Timestamp: e.end.Add(-time.Duration(i) * d),
+		})
+	}
+	return data, nil
+}
+
+func TestFillLatestSkipsBeyondFallbackLimit(t *testing.T) {
+	repo := models.NewLongShortRatioRepository(testdb.Migrated(t))
+	ctx := context.Background()
+	d := 5 * time.Minute
+	base := time.Now().Truncate(d)
+
+	// 已保存的两条数据把检查范围分成三个缺口，第一个缺口整体早于最近fallbackMaxLimit个周期
+	for _, ts := range []time.Time{base.Add(-700 * d), base.Add(-10 * d)} {
+		if err := repo.CreateOrUpdate(ctx, &models.LongShortRatio{
+			Exchange: "bitget", Symbol: "BTCUSDT", Metric: services.MetricGlobalAccount, Period: "5m", Ratio: 1, Timestamp: ts,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exchange := &latestOnlyExchange{end: base}
+	progress, err := NewBackfiller(repo).Run(ctx, Options{
+		Exchanges: []services.ExchangeService{exchange},
+		Symbols:   []string{"BTCUSDT"},
+		Periods:   []string{"5m"},
+		Since:     base.Add(-1000 * d),
+	})
+	if err != nil {
+		t.Fatalf("补齐失败: %v", err)
+	}
+
+	if progress.Gaps != 3 || progress.Done != 3 || progress.Skipped != 1 || len(progress.Errors) != 0 {
+		t.Errorf("补齐进度错误: %+v", progress)
+	}
+	if len(exchange.limits) != 2 {
+		t.Fatalf("请求交易所%d次, 期望2次", len(exchange.limits))
+	}
+	for _, limit := range exchange.limits {
+		if limit > fallbackMaxLimit {
+			t.Errorf("请求数量 = %d, 超过 %d", limit, fallbackMaxLimit)
+		}
+	}
+
+	// 第二个缺口只补齐最近fallbackMaxLimit个周期内的部分
+	timestamps, err := repo.GetTimestamps(ctx, "bitget", "BTCUSDT", services.MetricGlobalAccount, "5m", base.Add(-1000*d), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	earliest := base.Add(-time.Duration(fallbackMaxLimit) * d)
+	for _, ts := range timestamps[1:] {
+		if ts.Before(earliest) {
+			t.Errorf("保存了超出回溯范围的数据: %v", ts)
+		}
+	}
+	if saved := len(timestamps) - 2; saved != progress.Saved || saved == 0 {
+		t.Errorf("保存数据 %d 条, 进度记录 %d 条", saved, progress.Saved)
+	}
+}
+
+// pagedExchange 支持按时间范围查询的交易所，每个周期都有数据，单次最多返回pageSize条，
+// 超出时返回范围中间的一页，两端都需要继续翻页。同时支持大户持仓多空比
+type pagedExchange struct {
+	services.ExchangeService
+
+	pageSize int
+	requests int
+}
+
+func (e *pagedExchange) Name() string {
+	return "binance"
+}
+
+func (e *pagedExchange) RatioLookback(period string) time.Duration {
+	return 30 * 24 * time.Hour
+}
+
+func (e *pagedExchange) GetLongShortRatioRange(ctx context.Context, symbol, period string, start, end time.Time) ([]*services.LongShortRatioData, error) {
+	e.requests++
+
+	d, _ := services.PeriodDuration(period)
+	var data []*services.LongShortRatioData
+	for ts := start.Truncate(d); !ts.After(end); ts = ts.Add(d) {
+		if !ts.Before(start) {
+			data = append(data, &services.LongShortRatioData{
+				Exchange: e.Name(), Symbol: symbol, Metric: services.MetricGlobalAccount, Period: period, Ratio: 1, Timestamp: ts,
+			})
+		}
+	}
+	if len(data) > e.pageSize {
+		offset := (len(data) - e.pageSize) / 2
+		data = data[offset : offset+e.pageSize]
+	}
+	return data, nil
+}
+
+func (e *pagedExchange) SupportedMetrics() []string {
+	return []string{services.MetricGlobalAccount, services.MetricTopPosition}
+}
+
+func (e *pagedExchange) GetMetricHistory(ctx context.Context, symbol, metric, period string, limit int) ([]*services.LongShortRatioData, error) {
+	d, _ := services.PeriodDuration(period)
+	end := time.Now().Truncate(d)
+	var data []*services.LongShortRatioData
+	for i := limit - 1; i >= 0; i-- {
+		data = append(data, &services.LongShortRatioData{
+			Exchange: e.Name(), Symbol: symbol, Metric: metric, Period: period, Ratio: 2, Timestamp: end.Add(-time.Duration(i) * d),
+		})
+	}
+	return data, nil
+}
+
+func TestFillPagesBothSidesOfGap(t *testing.T) {
+	repo := models.NewLongShortRatioRepository(testdb.Migrated(t))
+	ctx := context.Background()
+	d := 5 * time.Minute
+	base := time.Now().Truncate(d)
+
+	exchange := &pagedExchange{pageSize: 7}
+	opts := Options{
+		Exchanges: []services.ExchangeService{exchange},
+		Symbols:   []string{"BTCUSDT"},
+		Periods:   []string{"5m"},
+		Since:     base.Add(-100 * d),
+	}
+	backfiller := NewBackfiller(repo)
+
+	// 没有任何数据时交易所支持的每个指标各有一个缺口
+	gaps, err := backfiller.FindGaps(ctx, opts)
+	if err != nil || len(gaps) != 2 || gaps[0].Metric != services.MetricGlobalAccount || gaps[1].Metric != services.MetricTopPosition {
+		t.Fatalf("缺口错误: %v, %v", gaps, err)
+	}
+
+	progress, err := backfiller.Run(ctx, opts)
+	if err != nil || progress.Done != 2 || len(progress.Errors) != 0 {
+		t.Fatalf("补齐进度错误: %+v, %v", progress, err)
+	}
+	if exchange.requests < 100/exchange.pageSize {
+		t.Errorf("请求交易所%d次，没有分页", exchange.requests)
+	}
+
+	// 分页覆盖了整个缺口，两个指标都不再有缺口
+	if gaps, err := backfiller.FindGaps(ctx, opts); err != nil || len(gaps) != 0 {
+		t.Errorf("补齐后仍有缺口: %v, %v", gaps, err)
+	}
+
+	opts.Metrics = []string{services.MetricTopPosition, services.MetricTakerVolume}
+	opts.Since = base.Add(-1000 * d)
+	gaps, err = backfiller.FindGaps(ctx, opts)
+	if err != nil || len(gaps) != 1 || gaps[0].Metric != services.MetricTopPosition {
+		t.Errorf("只应检查交易所支持的指定指标: %v, %v", gaps, err)
+	}
+}
@@ -0,0 +1,571 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath 未指定配置文件时尝试加载的路径
+const DefaultPath = "config.yaml"
+
+// 环境变量前缀
+const envPrefix = "CM_"
+
+// Config 服务配置
+type Config struct {
+	Server    ServerConfig              `yaml:"server"`
+	Database  DatabaseConfig            `yaml:"database"`
+	Scheduler SchedulerConfig           `yaml:"scheduler"`
+	Universe  UniverseConfig            `yaml:"universe"`
+	Exchanges map[string]ExchangeConfig `yaml:"exchanges"`
+}
+
+// ServerConfig Web服务配置
+type ServerConfig struct {
+	Addr       string `yaml:"addr"`        // 监听地址
+	AdminToken string `yaml:"admin_token"` // 管理接口令牌，为空时管理接口只允许本机访问
+}
+
+// DatabaseConfig 数据库配置
+type DatabaseConfig struct {
+	Driver          string        `yaml:"driver"`            // 数据库类型 (sqlite, postgres, mysql)
+	Path            string        `yaml:"path"`              // SQLite数据库文件路径
+	DSN             string        `yaml:"dsn"`               // PostgreSQL/MySQL连接串
+	LogLevel        string        `yaml:"log_level"`         // SQL日志级别 (silent, error, warn, info)
+	MaxOpenConns    int           `yaml:"max_open_conns"`    // 最大连接数，0表示不限制
+	MaxIdleConns    int           `yaml:"max_idle_conns"`    // 最大空闲连接数
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"` // 连接最长复用时间，0表示不限制
+	AutoMigrate     bool          `yaml:"auto_migrate"`      // 启动时是否自动执行未完成的迁移
+}
+
+// SchedulerConfig 调度器配置
+type SchedulerConfig struct {
+	IngestionMode   string          `yaml:"ingestion_mode"`   // 采集模式 (poll, stream)
+	CollectSpec     string          `yaml:"collect_spec"`     // 持仓量、资金费率和K线的采集周期
+	CollectTimeout  time.Duration   `yaml:"collect_timeout"`  // 每次采集任务的最长执行时间，超时后未完成的采集项记为失败
+	RatioPeriods    []string        `yaml:"ratio_periods"`    // 多空比采集的时间粒度，每个粒度在周期结束时采集
+	LiquidationSpec string          `yaml:"liquidation_spec"` // 强平数据的轮询周期
+	UniverseSpec    string          `yaml:"universe_spec"`    // 合约目录的刷新周期
+	RollupSpec      string          `yaml:"rollup_spec"`      // 多空比汇总（1h/4h/1d）的计算周期
+	CleanupSpec     string          `yaml:"cleanup_spec"`     // 旧数据的清理周期
+	RetentionDays   int             `yaml:"retention_days"`   // 未配置保留策略的数据表的保留天数
+	Retention       RetentionConfig `yaml:"retention"`        // 按数据表和时间粒度的保留策略
+}
+
+// RetentionConfig 数据保留策略配置
+type RetentionConfig struct {
+	ArchiveDir string                     `yaml:"archive_dir"` // 归档文件目录，开启归档的策略在删除前写入gzip压缩的JSON Lines文件
+	Policies   map[string]RetentionPolicy `yaml:"policies"`    // 键为数据表名，或"表名.时间粒度"只对该粒度的数据生效
+}
+
+// RetentionPolicy 单个数据表（或时间粒度）的保留策略
+type RetentionPolicy struct {
+	Days    int  `yaml:"days"`    // 保留天数，0表示永久保留
+	Archive bool `yaml:"archive"` // 删除前是否归档
+}
+
+// RetentionTables 支持配置保留策略的数据表，值表示该表是否可以按时间粒度单独配置
+var RetentionTables = map[string]bool{
+	"long_short_ratios":        true,
+	"long_short_ratio_rollups": true,
+	"klines":                   true,
+	"open_interests":           false,
+	"funding_rates":            false,
+	"predicted_fundings":       false,
+	"liquidations":             false,
+	"api_logs":                 false,
+}
+
+// UniverseConfig 跟踪交易对的选择规则
+type UniverseConfig struct {
+	Symbols      []string `yaml:"symbols"`       // 始终跟踪的交易对
+	TopN         int      `yaml:"top_n"`         // 按持仓价值取前N个交易对，0表示不按持仓选择
+	MinExchanges int      `yaml:"min_exchanges"` // 候选交易对至少上线的交易所数量
+}
+
+// ExchangeConfig 交易所配置
+type ExchangeConfig struct {
+	Disabled bool          `yaml:"disabled"` // 是否停用
+	BaseURL  string        `yaml:"base_url"` // REST接口地址
+	WSURL    string        `yaml:"ws_url"`   // WebSocket推送地址，不支持推送的交易所为空
+	Timeout  time.Duration `yaml:"timeout"`  // HTTP请求超时时间
+
+	MaxAttempts  int           `yaml:"max_attempts"`  // 限流、5xx和超时时的最多请求次数，1表示不重试
+	RetryBackoff time.Duration `yaml:"retry_backoff"` // 第一次重试前的等待时间，之后按指数增长
+
+	BreakerThreshold int           `yaml:"breaker_threshold"` // 接口连续多少次不可用后熔断
+	BreakerCooldown  time.Duration `yaml:"breaker_cooldown"`  // 熔断后第一次探测前的等待时间，探测失败时翻倍
+}
+
+// RatioPeriods 支持采集的多空比时间粒度
+var RatioPeriods = []string{"5m", "15m", "30m", "1h", "2h", "4h", "1d"}
+
+// defaultExchanges 各交易所的默认配置
+var defaultExchanges = map[string]ExchangeConfig{
+	"binance": exchangeDefaults("https://fapi.binance.com", "wss://fstream.binance.com"),
+	"okx":     exchangeDefaults("https://www.okx.com", "wss://ws.okx.com:8443/ws/v5/public"),
+	"bybit":   exchangeDefaults("https://api.bybit.com", ""),
+	"bitget":  exchangeDefaults("https://api.bitget.com", ""),
+	"gate":    exchangeDefaults("https://api.gateio.ws", ""),
+}
+
+// exchangeDefaults 使用交易所地址和通用的请求参数创建默认配置
+func exchangeDefaults(baseURL, wsURL string) ExchangeConfig {
+	return ExchangeConfig{
+		BaseURL:          baseURL,
+		WSURL:            wsURL,
+		Timeout:          10 * time.Second,
+		MaxAttempts:      3,
+		RetryBackoff:     500 * time.Millisecond,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+	}
+}
+
+// Default 获取默认配置
+func Default() *Config {
+	cfg := &Config{
+		Server: ServerConfig{
+			Addr: ":8080",
+		},
+		Database: DatabaseConfig{
+			Driver:       "sqlite",
+			Path:         "currency_monitor.db",
+			LogLevel:     "info",
+			MaxIdleConns: 2,
+			AutoMigrate:  true,
+		},
+		Scheduler: SchedulerConfig{
+			IngestionMode:   "poll",
+			CollectSpec:     "*/15 * * * *",
+			CollectTimeout:  2 * time.Minute,
+			RatioPeriods:    []string{"5m", "15m", "1h", "4h", "1d"},
+			LiquidationSpec: "*/5 * * * *",
+			UniverseSpec:    "0 * * * *",
+			RollupSpec:      "*/15 * * * *",
+			CleanupSpec:     "0 2 * * *",
+			RetentionDays:   7,
+			Retention: RetentionConfig{
+				Policies: map[string]RetentionPolicy{
+					"long_short_ratio_rollups": {Days: 0},
+					"api_logs":                 {Days: 14},
+				},
+			},
+		},
+		Universe: UniverseConfig{
+			Symbols: []string{"BTCUSDT", "ETHUSDT"},
+		},
+		Exchanges: make(map[string]ExchangeConfig, len(defaultExchanges)),
+	}
+	for name, exchange := range defaultExchanges {
+		cfg.Exchanges[name] = exchange
+	}
+	return cfg
+}
+
+// DefaultExchange 获取交易所的默认配置
+func DefaultExchange(name string) ExchangeConfig {
+	return defaultExchanges[name]
+}
+
+// Load 按默认值、配置文件、环境变量、命令行参数的顺序加载配置并校验
+func Load(args []string) (*Config, error) {
+	return LoadFlags(flag.NewFlagSet("currency_monitor", flag.ContinueOnError), args)
+}
+
+// LoadFlags 与Load相同，配置相关的命令行参数注册到fs上。子命令可以先在fs上注册自己的参数，
+// 加载完成后通过fs.Args()获取剩余的位置参数
+func LoadFlags(fs *flag.FlagSet, args []string) (*Config, error) {
+	path := fs.String("config", "", "配置文件路径（默认读取环境变量CM_CONFIG或"+DefaultPath+"）")
+	addr := fs.String("addr", "", "Web服务监听地址")
+	dbPath := fs.String("db", "", "SQLite数据库文件路径")
+	dsn := fs.String("dsn", "", "PostgreSQL/MySQL连接串")
+	driver := fs.String("driver", "", "数据库类型 (sqlite, postgres, mysql)")
+	mode := fs.String("mode", "", "采集模式 (poll, stream)")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := Default()
+
+	configPath := *path
+	if configPath == "" {
+		configPath = os.Getenv(envPrefix + "CONFIG")
+	}
+	if configPath != "" {
+		if err := cfg.loadFile(configPath); err != nil {
+			return nil, err
+		}
+	} else if _, err := os.Stat(DefaultPath); err == nil {
+		if err := cfg.loadFile(DefaultPath); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	// 命令行参数优先级最高
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "addr":
+			cfg.Server.Addr = *addr
+		case "db":
+			cfg.Database.Path = *dbPath
+		case "dsn":
+			cfg.Database.DSN = *dsn
+		case "driver":
+			cfg.Database.Driver = *driver
+		case "mode":
+			cfg.Scheduler.IngestionMode = *mode
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile 从YAML文件加载配置，未出现的字段保持原值
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	// 交易所配置按字段合并，文件中只需写出需要修改的字段
+	exchanges := c.Exchanges
+	c.Exchanges = nil
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("解析配置文件%s失败: %w", path, err)
+	}
+
+	for name, override := range c.Exchanges {
+		exchange := exchanges[name]
+		exchange.Disabled = override.Disabled
+		if override.BaseURL != "" {
+			exchange.BaseURL = override.BaseURL
+		}
+		if override.WSURL != "" {
+			exchange.WSURL = override.WSURL
+		}
+		if override.Timeout != 0 {
+			exchange.Timeout = override.Timeout
+		}
+		if override.MaxAttempts != 0 {
+			exchange.MaxAttempts = override.MaxAttempts
+		}
+		if override.RetryBackoff != 0 {
+			exchange.RetryBackoff = override.RetryBackoff
+		}
+		if override.BreakerThreshold != 0 {
+			exchange.BreakerThreshold = override.BreakerThreshold
+		}
+		if override.BreakerCooldown != 0 {
+			exchange.BreakerCooldown = override.BreakerCooldown
+		}
+		exchanges[name] = exchange
+	}
+	c.Exchanges = exchanges
+	return nil
+}
+
+// applyEnv 使用CM_前缀的环境变量覆盖配置
+func (c *Config) applyEnv() error {
+	setString := func(key string, target *string) {
+		if v, ok := os.LookupEnv(envPrefix + key); ok {
+			*target = v
+		}
+	}
+	setList := func(key string, target *[]string) {
+		v, ok := os.LookupEnv(envPrefix + key)
+		if !ok {
+			return
+		}
+		*target = nil
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*target = append(*target, item)
+			}
+		}
+	}
+	setInt := func(key string, target *int) error {
+		v, ok := os.LookupEnv(envPrefix + key)
+		if !ok {
+			return nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("环境变量%s%s不是整数: %s", envPrefix, key, v)
+		}
+		*target = n
+		return nil
+	}
+
+	setString("SERVER_ADDR", &c.Server.Addr)
+	setString("ADMIN_TOKEN", &c.Server.AdminToken)
+	setString("DATABASE_DRIVER", &c.Database.Driver)
+	setString("DATABASE_PATH", &c.Database.Path)
+	setString("DATABASE_DSN", &c.Database.DSN)
+	setString("DATABASE_LOG_LEVEL", &c.Database.LogLevel)
+	if err := setInt("DATABASE_MAX_OPEN_CONNS", &c.Database.MaxOpenConns); err != nil {
+		return err
+	}
+	if err := setInt("DATABASE_MAX_IDLE_CONNS", &c.Database.MaxIdleConns); err != nil {
+		return err
+	}
+	if v, ok := os.LookupEnv(envPrefix + "DATABASE_CONN_MAX_LIFETIME"); ok {
+		lifetime, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("环境变量%sDATABASE_CONN_MAX_LIFETIME不是有效的时长: %s", envPrefix, v)
+		}
+		c.Database.ConnMaxLifetime = lifetime
+	}
+	if v, ok := os.LookupEnv(envPrefix + "DATABASE_AUTO_MIGRATE"); ok {
+		autoMigrate, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("环境变量%sDATABASE_AUTO_MIGRATE不是布尔值: %s", envPrefix, v)
+		}
+		c.Database.AutoMigrate = autoMigrate
+	}
+	setString("INGESTION_MODE", &c.Scheduler.IngestionMode)
+	setString("COLLECT_SPEC", &c.Scheduler.CollectSpec)
+	if v, ok := os.LookupEnv(envPrefix + "COLLECT_TIMEOUT"); ok {
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("环境变量%sCOLLECT_TIMEOUT不是有效的时长: %s", envPrefix, v)
+		}
+		c.Scheduler.CollectTimeout = timeout
+	}
+	setList("RATIO_PERIODS", &c.Scheduler.RatioPeriods)
+	setString("LIQUIDATION_SPEC", &c.Scheduler.LiquidationSpec)
+	setString("UNIVERSE_SPEC", &c.Scheduler.UniverseSpec)
+	setString("ROLLUP_SPEC", &c.Scheduler.RollupSpec)
+	setString("CLEANUP_SPEC", &c.Scheduler.CleanupSpec)
+	setString("RETENTION_ARCHIVE_DIR", &c.Scheduler.Retention.ArchiveDir)
+	if err := setInt("RETENTION_DAYS", &c.Scheduler.RetentionDays); err != nil {
+		return err
+	}
+
+	setList("UNIVERSE_SYMBOLS", &c.Universe.Symbols)
+	if err := setInt("UNIVERSE_TOP_N", &c.Universe.TopN); err != nil {
+		return err
+	}
+	if err := setInt("UNIVERSE_MIN_EXCHANGES", &c.Universe.MinExchanges); err != nil {
+		return err
+	}
+
+	// 交易所配置: CM_<交易所>_BASE_URL、CM_<交易所>_WS_URL、CM_<交易所>_TIMEOUT、CM_<交易所>_DISABLED、
+	// CM_<交易所>_MAX_ATTEMPTS、CM_<交易所>_RETRY_BACKOFF、CM_<交易所>_BREAKER_THRESHOLD、CM_<交易所>_BREAKER_COOLDOWN
+	for name, exchange := range c.Exchanges {
+		key := strings.ToUpper(name) + "_"
+		setString(key+"BASE_URL", &exchange.BaseURL)
+		setString(key+"WS_URL", &exchange.WSURL)
+		if v, ok := os.LookupEnv(envPrefix + key + "TIMEOUT"); ok {
+			timeout, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("环境变量%s%sTIMEOUT不是有效的时长: %s", envPrefix, key, v)
+			}
+			exchange.Timeout = timeout
+		}
+		if err := setInt(key+"MAX_ATTEMPTS", &exchange.MaxAttempts); err != nil {
+			return err
+		}
+		if v, ok := os.LookupEnv(envPrefix + key + "RETRY_BACKOFF"); ok {
+			backoff, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("环境变量%s%sRETRY_BACKOFF不是有效的时长: %s", envPrefix, key, v)
+			}
+			exchange.RetryBackoff = backoff
+		}
+		if err := setInt(key+"BREAKER_THRESHOLD", &exchange.BreakerThreshold); err != nil {
+			return err
+		}
+		if v, ok := os.LookupEnv(envPrefix + key + "BREAKER_COOLDOWN"); ok {
+			cooldown, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("环境变量%s%sBREAKER_COOLDOWN不是有效的时长: %s", envPrefix, key, v)
+			}
+			exchange.BreakerCooldown = cooldown
+		}
+		if v, ok := os.LookupEnv(envPrefix + key + "DISABLED"); ok {
+			disabled, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("环境变量%s%sDISABLED不是布尔值: %s", envPrefix, key, v)
+			}
+			exchange.Disabled = disabled
+		}
+		c.Exchanges[name] = exchange
+	}
+
+	return nil
+}
+
+// Validate 校验配置
+func (c *Config) Validate() error {
+	var errs []string
+
+	if c.Server.Addr == "" {
+		errs = append(errs, "server.addr不能为空")
+	}
+	switch c.Database.Driver {
+	case "sqlite":
+		if c.Database.Path == "" {
+			errs = append(errs, "database.path不能为空")
+		}
+	case "postgres", "mysql":
+		if c.Database.DSN == "" {
+			errs = append(errs, fmt.Sprintf("database.driver为%s时database.dsn不能为空", c.Database.Driver))
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("database.driver不支持: %s", c.Database.Driver))
+	}
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 || c.Database.ConnMaxLifetime < 0 {
+		errs = append(errs, "database连接池配置不能为负数")
+	}
+	switch c.Database.LogLevel {
+	case "silent", "error", "warn", "info":
+	default:
+		errs = append(errs, fmt.Sprintf("database.log_level不支持: %s", c.Database.LogLevel))
+	}
+
+	switch c.Scheduler.IngestionMode {
+	case "poll", "stream":
+	default:
+		errs = append(errs, fmt.Sprintf("scheduler.ingestion_mode不支持: %s", c.Scheduler.IngestionMode))
+	}
+	specs := map[string]string{
+		"scheduler.collect_spec":     c.Scheduler.CollectSpec,
+		"scheduler.liquidation_spec": c.Scheduler.LiquidationSpec,
+		"scheduler.universe_spec":    c.Scheduler.UniverseSpec,
+		"scheduler.rollup_spec":      c.Scheduler.RollupSpec,
+		"scheduler.cleanup_spec":     c.Scheduler.CleanupSpec,
+	}
+	for name, spec := range specs {
+		if _, err := cron.ParseStandard(spec); err != nil {
+			errs = append(errs, fmt.Sprintf("%s无效: %v", name, err))
+		}
+	}
+	if c.Scheduler.CollectTimeout <= 0 {
+		errs = append(errs, "scheduler.collect_timeout必须大于0")
+	}
+	if len(c.Scheduler.RatioPeriods) == 0 {
+		errs = append(errs, "scheduler.ratio_periods不能为空")
+	}
+	seen := make(map[string]bool)
+	for _, period := range c.Scheduler.RatioPeriods {
+		switch {
+		case !slices.Contains(RatioPeriods, period):
+			errs = append(errs, fmt.Sprintf("scheduler.ratio_periods不支持: %s，支持: %s", period, strings.Join(RatioPeriods, ", ")))
+		case seen[period]:
+			errs = append(errs, fmt.Sprintf("scheduler.ratio_periods重复: %s", period))
+		}
+		seen[period] = true
+	}
+	if c.Scheduler.RetentionDays <= 0 {
+		errs = append(errs, "scheduler.retention_days必须大于0")
+	}
+	keys := make([]string, 0, len(c.Scheduler.Retention.Policies))
+	for key := range c.Scheduler.Retention.Policies {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		policy := c.Scheduler.Retention.Policies[key]
+		table, period, hasPeriod := strings.Cut(key, ".")
+		byPeriod, ok := RetentionTables[table]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Sprintf("scheduler.retention.policies不支持的数据表: %s", table))
+		case hasPeriod && !byPeriod:
+			errs = append(errs, fmt.Sprintf("scheduler.retention.policies.%s: %s不区分时间粒度", key, table))
+		case hasPeriod && !slices.Contains(RatioPeriods, period):
+			errs = append(errs, fmt.Sprintf("scheduler.retention.policies.%s: 不支持的时间粒度%s", key, period))
+		}
+		if policy.Days < 0 {
+			errs = append(errs, fmt.Sprintf("scheduler.retention.policies.%s.days不能为负数", key))
+		}
+		if policy.Archive && c.Scheduler.Retention.ArchiveDir == "" {
+			errs = append(errs, fmt.Sprintf("scheduler.retention.policies.%s开启归档时必须设置scheduler.retention.archive_dir", key))
+		}
+	}
+
+	if len(c.Universe.Symbols) == 0 && c.Universe.TopN <= 0 {
+		errs = append(errs, "universe.symbols为空时universe.top_n必须大于0")
+	}
+	if c.Universe.TopN < 0 || c.Universe.MinExchanges < 0 {
+		errs = append(errs, "universe.top_n和universe.min_exchanges不能为负数")
+	}
+
+	enabled := 0
+	for name, exchange := range c.Exchanges {
+		if exchange.Disabled {
+			continue
+		}
+		enabled++
+		if err := validateURL(exchange.BaseURL, "http", "https"); err != nil {
+			errs = append(errs, fmt.Sprintf("exchanges.%s.base_url无效: %v", name, err))
+		}
+		if exchange.WSURL != "" {
+			if err := validateURL(exchange.WSURL, "ws", "wss"); err != nil {
+				errs = append(errs, fmt.Sprintf("exchanges.%s.ws_url无效: %v", name, err))
+			}
+		}
+		if exchange.Timeout <= 0 {
+			errs = append(errs, fmt.Sprintf("exchanges.%s.timeout必须大于0", name))
+		}
+		if exchange.MaxAttempts <= 0 {
+			errs = append(errs, fmt.Sprintf("exchanges.%s.max_attempts必须大于0", name))
+		}
+		if exchange.RetryBackoff <= 0 {
+			errs = append(errs, fmt.Sprintf("exchanges.%s.retry_backoff必须大于0", name))
+		}
+		if exchange.BreakerThreshold <= 0 {
+			errs = append(errs, fmt.Sprintf("exchanges.%s.breaker_threshold必须大于0", name))
+		}
+		if exchange.BreakerCooldown <= 0 {
+			errs = append(errs, fmt.Sprintf("exchanges.%s.breaker_cooldown必须大于0", name))
+		}
+	}
+	if enabled == 0 {
+		errs = append(errs, "至少需要启用一个交易所")
+	}
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("配置校验失败: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// validateURL 校验地址格式和协议
+func validateURL(raw string, schemes ...string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme && u.Host != "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("需要%s地址: %q", strings.Join(schemes, "/"), raw)
+}
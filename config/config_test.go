@@ -0,0 +1,144 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		env     map[string]string
+		args    []string
+		addr    string
+		symbols []string
+		mode    string
+	}{
+		{
+			name:    "默认值",
+			addr:    ":8080",
+			symbols: []string{"BTCUSDT", "ETHUSDT"},
+			mode:    "poll",
+		},
+		{
+			name:    "配置文件覆盖默认值",
+			file:    "server:\n  addr: \":9000\"\nscheduler:\n  ingestion_mode: stream\nuniverse:\n  symbols: [SOLUSDT]\n",
+			addr:    ":9000",
+			symbols: []string{"SOLUSDT"},
+			mode:    "stream",
+		},
+		{
+			name:    "环境变量覆盖配置文件",
+			file:    "server:\n  addr: \":9000\"\nscheduler:\n  ingestion_mode: stream\nuniverse:\n  symbols: [SOLUSDT]\n",
+			env:     map[string]string{"CM_SERVER_ADDR": ":9100", "CM_INGESTION_MODE": "poll", "CM_UNIVERSE_SYMBOLS": "BTCUSDT, XRPUSDT"},
+			addr:    ":9100",
+			symbols: []string{"BTCUSDT", "XRPUSDT"},
+			mode:    "poll",
+		},
+		{
+			name:    "命令行参数覆盖环境变量",
+			file:    "server:\n  addr: \":9000\"\n",
+			env:     map[string]string{"CM_SERVER_ADDR": ":9100", "CM_INGESTION_MODE": "poll"},
+			args:    []string{"-addr", ":9200", "-mode", "stream"},
+			addr:    ":9200",
+			symbols: []string{"BTCUSDT", "ETHUSDT"},
+			mode:    "stream",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CM_CONFIG", "")
+			if tt.file != "" {
+				path := filepath.Join(t.TempDir(), "config.yaml")
+				if err := os.WriteFile(path, []byte(tt.file), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				t.Setenv("CM_CONFIG", path)
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := Load(tt.args)
+			if err != nil {
+				t.Fatalf("加载配置失败: %v", err)
+			}
+			if cfg.Server.Addr != tt.addr || cfg.Scheduler.IngestionMode != tt.mode || strings.Join(cfg.Universe.Symbols, ",") != strings.Join(tt.symbols, ",") {
+				t.Errorf("配置 = %s %s %v, 期望 %s %s %v", cfg.Server.Addr, cfg.Scheduler.IngestionMode, cfg.Universe.Symbols, tt.addr, tt.mode, tt.symbols)
+			}
+		})
+	}
+}
+
+func TestLoadMergesExchangeFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("exchanges:\n  okx:\n    timeout: 3s\n  gate:\n    disabled: true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CM_CONFIG", path)
+	t.Setenv("CM_OKX_MAX_ATTEMPTS", "5")
+
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+	// 文件中未写出的字段保持默认值
+	okx := cfg.Exchanges["okx"]
+	if okx.Timeout != 3*time.Second || okx.MaxAttempts != 5 || okx.BaseURL != DefaultExchange("okx").BaseURL || okx.RetryBackoff != DefaultExchange("okx").RetryBackoff {
+		t.Errorf("okx配置错误: %+v", okx)
+	}
+	if gate := cfg.Exchanges["gate"]; !gate.Disabled || gate.BaseURL != DefaultExchange("gate").BaseURL {
+		t.Errorf("gate配置错误: %+v", gate)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		errMsg string // 为空表示校验通过
+	}{
+		{"默认配置", func(c *Config) {}, ""},
+		{"不支持的数据库类型", func(c *Config) { c.Database.Driver = "oracle" }, "database.driver不支持: oracle"},
+		{"PostgreSQL缺少连接串", func(c *Config) { c.Database.Driver = "postgres" }, "database.dsn不能为空"},
+		{"交易对为空且未设置top_n", func(c *Config) { c.Universe.Symbols = nil }, "universe.symbols为空时universe.top_n必须大于0"},
+		{"交易对为空时按持仓排名选择", func(c *Config) { c.Universe.Symbols = nil; c.Universe.TopN = 10 }, ""},
+		{"不支持的时间粒度", func(c *Config) { c.Scheduler.RatioPeriods = []string{"5m", "3m"} }, "scheduler.ratio_periods不支持: 3m"},
+		{"重复的时间粒度", func(c *Config) { c.Scheduler.RatioPeriods = []string{"5m", "5m"} }, "scheduler.ratio_periods重复: 5m"},
+		{"时间粒度为空", func(c *Config) { c.Scheduler.RatioPeriods = nil }, "scheduler.ratio_periods不能为空"},
+		{"无效的执行周期", func(c *Config) { c.Scheduler.CollectSpec = "every minute" }, "scheduler.collect_spec无效"},
+		{"不支持的采集模式", func(c *Config) { c.Scheduler.IngestionMode = "push" }, "scheduler.ingestion_mode不支持: push"},
+		{"归档未设置目录", func(c *Config) {
+			c.Scheduler.Retention.Policies["klines"] = RetentionPolicy{Days: 30, Archive: true}
+		}, "必须设置scheduler.retention.archive_dir"},
+		{"无效的推送地址", func(c *Config) {
+			okx := c.Exchanges["okx"]
+			okx.WSURL = "https://ws.okx.com"
+			c.Exchanges["okx"] = okx
+		}, "exchanges.okx.ws_url无效"},
+		{"没有启用的交易所", func(c *Config) {
+			for name, exchange := range c.Exchanges {
+				exchange.Disabled = true
+				c.Exchanges[name] = exchange
+			}
+		}, "至少需要启用一个交易所"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			switch {
+			case tt.errMsg == "" && err != nil:
+				t.Errorf("校验失败: %v", err)
+			case tt.errMsg != "" && (err == nil || !strings.Contains(err.Error(), tt.errMsg)):
+				t.Errorf("错误 = %v, 期望包含 %q", err, tt.errMsg)
+			}
+		})
+	}
+}
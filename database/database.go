@@ -1,9 +1,13 @@
 package database
 
 import (
-	"CurrencyMonitor/models"
+	"CurrencyMonitor/config"
+	"fmt"
 	"log"
+	"strings"
 
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -11,21 +15,28 @@ import (
 
 var DB *gorm.DB
 
-// InitDatabase 初始化数据库连接
-func InitDatabase() error {
+// logLevels 配置中的日志级别到GORM日志级别的映射
+var logLevels = map[string]logger.LogLevel{
+	"silent": logger.Silent,
+	"error":  logger.Error,
+	"warn":   logger.Warn,
+	"info":   logger.Info,
+}
+
+// InitDatabase 初始化数据库连接，开启自动迁移时执行未完成的版本迁移，否则要求数据库已是最新版本
+func InitDatabase(cfg config.DatabaseConfig) error {
 	var err error
 
-	// 使用SQLite数据库
-	DB, err = gorm.Open(sqlite.Open("currency_monitor.db"), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
-	})
+	DB, err = Open(cfg)
 	if err != nil {
 		return err
 	}
 
-	// 自动迁移数据库表结构
-	err = DB.AutoMigrate(&models.LongShortRatio{}, &models.APILog{})
-	if err != nil {
+	if cfg.AutoMigrate {
+		if err := Migrate(DB); err != nil {
+			return err
+		}
+	} else if err := checkVersion(DB); err != nil {
 		return err
 	}
 
@@ -33,6 +44,68 @@ func InitDatabase() error {
 	return nil
 }
 
+// checkVersion 检查数据库是否已执行全部迁移
+func checkVersion(db *gorm.DB) error {
+	current, err := prepareVersion(db)
+	if err != nil {
+		return err
+	}
+	if current < LatestVersion() {
+		return fmt.Errorf("数据库版本%d低于程序版本%d，请先执行 migrate up", current, LatestVersion())
+	}
+	return nil
+}
+
+// Open 按配置打开数据库连接并设置连接池
+func Open(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	dialector, err := newDialector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger: logger.Default.LogMode(logLevels[cfg.LogLevel]),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("连接%s数据库失败: %w", cfg.Driver, err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("获取数据库连接池失败: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	return db, nil
+}
+
+// newDialector 根据数据库类型创建GORM方言
+func newDialector(cfg config.DatabaseConfig) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case "sqlite", "":
+		return sqlite.Open(cfg.Path), nil
+	case "postgres":
+		return postgres.Open(cfg.DSN), nil
+	case "mysql":
+		return mysql.Open(mysqlDSN(cfg.DSN)), nil
+	default:
+		return nil, fmt.Errorf("不支持的数据库类型: %s", cfg.Driver)
+	}
+}
+
+// mysqlDSN 补充MySQL连接串中的必要参数，时间字段需要parseTime才能读取为time.Time
+func mysqlDSN(dsn string) string {
+	if strings.Contains(dsn, "parseTime=") {
+		return dsn
+	}
+	if strings.Contains(dsn, "?") {
+		return dsn + "&parseTime=true"
+	}
+	return dsn + "?parseTime=true"
+}
+
 // GetDB 获取数据库实例
 func GetDB() *gorm.DB {
 	return DB
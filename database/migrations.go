@@ -0,0 +1,616 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migration 数据库版本迁移，Version按顺序递增，已发布的迁移不能修改。
+// Up升级到该版本，Down回滚该版本的修改
+type migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// migrations 所有版本迁移
+var migrations = []migration{
+	{Version: 1, Name: "初始表结构", Up: migrateInitialSchema, Down: dropInitialSchema},
+	{Version: 2, Name: "多空比唯一约束", Up: addLongShortRatioUnique, Down: dropLongShortRatioUnique},
+	{Version: 3, Name: "API日志时间索引", Up: addAPILogCreatedAtIndex, Down: dropAPILogCreatedAtIndex},
+	{Version: 4, Name: "多空比时间粒度", Up: addLongShortRatioPeriod, Down: dropLongShortRatioPeriod},
+	{Version: 5, Name: "多空比汇总表", Up: createRatioRollups, Down: dropRatioRollups},
+	{Version: 6, Name: "API日志错误类型", Up: addAPILogErrorType, Down: dropAPILogErrorType},
+	{Version: 7, Name: "行情数据唯一约束", Up: addMarketDataUnique, Down: dropMarketDataUnique},
+}
+
+// SchemaVersion 已执行的迁移记录
+type SchemaVersion struct {
+	Version   int       `gorm:"primarykey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName 迁移记录表名
+func (SchemaVersion) TableName() string {
+	return "schema_version"
+}
+
+// MigrationState 迁移执行状态
+type MigrationState struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// LatestVersion 程序支持的最新数据库版本
+func LatestVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// Migrate 按版本顺序执行所有尚未执行的迁移
+func Migrate(db *gorm.DB) error {
+	_, err := MigrateUp(db, 0)
+	return err
+}
+
+// MigrateUp 按版本顺序执行尚未执行的迁移，steps为0时执行全部，返回执行后的版本
+func MigrateUp(db *gorm.DB, steps int) (int, error) {
+	current, err := prepareVersion(db)
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		if steps > 0 && applied == steps {
+			break
+		}
+
+		log.Printf("执行数据库迁移 %03d: %s", m.Version, m.Name)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaVersion{
+				Version:   m.Version,
+				Name:      m.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return current, fmt.Errorf("执行数据库迁移%03d失败: %w", m.Version, err)
+		}
+		current = m.Version
+		applied++
+	}
+
+	return current, nil
+}
+
+// MigrateDown 按版本倒序回滚最近执行的steps个迁移，返回回滚后的版本
+func MigrateDown(db *gorm.DB, steps int) (int, error) {
+	current, err := prepareVersion(db)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+		m := migrations[i]
+		if m.Version > current {
+			continue
+		}
+
+		log.Printf("回滚数据库迁移 %03d: %s", m.Version, m.Name)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaVersion{}, m.Version).Error
+		})
+		if err != nil {
+			return current, fmt.Errorf("回滚数据库迁移%03d失败: %w", m.Version, err)
+		}
+		current = m.Version - 1
+		steps--
+	}
+
+	return current, nil
+}
+
+// MigrationStatus 返回所有迁移的执行状态
+func MigrationStatus(db *gorm.DB) ([]MigrationState, error) {
+	if _, err := prepareVersion(db); err != nil {
+		return nil, err
+	}
+
+	var versions []SchemaVersion
+	if err := db.Find(&versions).Error; err != nil {
+		return nil, fmt.Errorf("查询迁移记录失败: %w", err)
+	}
+	applied := make(map[int]SchemaVersion, len(versions))
+	for _, v := range versions {
+		applied[v.Version] = v
+	}
+
+	states := make([]MigrationState, 0, len(migrations))
+	for _, m := range migrations {
+		state := MigrationState{Version: m.Version, Name: m.Name}
+		if v, ok := applied[m.Version]; ok {
+			state.Applied = true
+			state.AppliedAt = v.AppliedAt
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// prepareVersion 创建迁移记录表并返回当前版本，数据库版本高于程序支持的版本时返回错误
+func prepareVersion(db *gorm.DB) (int, error) {
+	if err := db.AutoMigrate(&SchemaVersion{}); err != nil {
+		return 0, fmt.Errorf("创建迁移记录表失败: %w", err)
+	}
+
+	current, err := currentVersion(db)
+	if err != nil {
+		return 0, err
+	}
+	if current > LatestVersion() {
+		return 0, fmt.Errorf("数据库版本%d高于程序支持的版本%d，请升级程序", current, LatestVersion())
+	}
+	return current, nil
+}
+
+// currentVersion 获取已执行的最新迁移版本，没有执行过迁移时为0
+func currentVersion(db *gorm.DB) (int, error) {
+	var version int
+	err := db.Model(&SchemaVersion{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	if err != nil {
+		return 0, fmt.Errorf("查询数据库版本失败: %w", err)
+	}
+	return version, nil
+}
+
+// migrateInitialSchema 001 创建初始表结构。使用版本1时的表结构快照而不是models中的当前模型，
+// 保证之后模型变化时新旧数据库执行同样的迁移；已由AutoMigrate创建的旧数据库只会补充缺少的列和索引。
+func migrateInitialSchema(tx *gorm.DB) error {
+	type longShortRatio struct {
+		ID        uint `gorm:"primarykey"`
+		CreatedAt time.Time
+		UpdatedAt time.Time
+		Exchange  string    `gorm:"index;not null"`
+		Symbol    string    `gorm:"index;not null"`
+		Metric    string    `gorm:"index;not null;default:global_account"`
+		Ratio     float64   `gorm:"not null"`
+		Timestamp time.Time `gorm:"index;not null"`
+	}
+	type apiLog struct {
+		ID           uint `gorm:"primarykey"`
+		CreatedAt    time.Time
+		Exchange     string `gorm:"index;not null"`
+		Symbol       string `gorm:"not null"`
+		Period       string `gorm:"not null"`
+		Limit        int    `gorm:"not null"`
+		URL          string `gorm:"not null"`
+		StatusCode   int    `gorm:"not null"`
+		ResponseTime int64  `gorm:"not null"`
+		DataCount    int    `gorm:"not null"`
+		ErrorMsg     string
+		Success      bool `gorm:"not null"`
+	}
+	type openInterest struct {
+		ID           uint `gorm:"primarykey"`
+		CreatedAt    time.Time
+		UpdatedAt    time.Time
+		Exchange     string    `gorm:"index;not null"`
+		Symbol       string    `gorm:"index;not null"`
+		OpenInterest float64   `gorm:"not null"`
+		Notional     float64   `gorm:"not null"`
+		Timestamp    time.Time `gorm:"index;not null"`
+	}
+	type fundingRate struct {
+		ID          uint `gorm:"primarykey"`
+		CreatedAt   time.Time
+		UpdatedAt   time.Time
+		Exchange    string    `gorm:"index;not null"`
+		Symbol      string    `gorm:"index;not null"`
+		Rate        float64   `gorm:"not null"`
+		FundingTime time.Time `gorm:"index;not null"`
+	}
+	type predictedFunding struct {
+		ID              uint `gorm:"primarykey"`
+		CreatedAt       time.Time
+		UpdatedAt       time.Time
+		Exchange        string    `gorm:"index;not null"`
+		Symbol          string    `gorm:"index;not null"`
+		Rate            float64   `gorm:"not null"`
+		NextFundingTime time.Time `gorm:"index;not null"`
+		Timestamp       time.Time `gorm:"not null"`
+	}
+	type liquidation struct {
+		ID        uint `gorm:"primarykey"`
+		CreatedAt time.Time
+		Exchange  string    `gorm:"index;not null"`
+		Symbol    string    `gorm:"index;not null"`
+		Side      string    `gorm:"not null"`
+		Price     float64   `gorm:"not null"`
+		Quantity  float64   `gorm:"not null"`
+		Notional  float64   `gorm:"not null"`
+		Timestamp time.Time `gorm:"index;not null"`
+	}
+	type kline struct {
+		ID        uint `gorm:"primarykey"`
+		CreatedAt time.Time
+		UpdatedAt time.Time
+		Exchange  string    `gorm:"index;not null"`
+		Symbol    string    `gorm:"index;not null"`
+		Period    string    `gorm:"not null"`
+		OpenTime  time.Time `gorm:"index;not null"`
+		Open      float64   `gorm:"not null"`
+		High      float64   `gorm:"not null"`
+		Low       float64   `gorm:"not null"`
+		Close     float64   `gorm:"not null"`
+		Volume    float64   `gorm:"not null"`
+	}
+	type symbol struct {
+		ID              uint `gorm:"primarykey"`
+		CreatedAt       time.Time
+		UpdatedAt       time.Time
+		Exchange        string `gorm:"index;not null"`
+		Symbol          string `gorm:"index;not null"`
+		ExchangeSymbol  string `gorm:"not null"`
+		BaseAsset       string
+		QuoteAsset      string
+		OpenInterestUSD float64
+		Listed          bool `gorm:"not null;default:true"`
+		Tracked         bool `gorm:"index;not null;default:false"`
+	}
+
+	tables := []struct {
+		name  string
+		model interface{}
+	}{
+		{"long_short_ratios", &longShortRatio{}},
+		{"api_logs", &apiLog{}},
+		{"open_interests", &openInterest{}},
+		{"funding_rates", &fundingRate{}},
+		{"predicted_fundings", &predictedFunding{}},
+		{"liquidations", &liquidation{}},
+		{"klines", &kline{}},
+		{"symbols", &symbol{}},
+	}
+	for _, table := range tables {
+		if err := tx.Table(table.name).AutoMigrate(table.model); err != nil {
+			return fmt.Errorf("创建表%s失败: %w", table.name, err)
+		}
+	}
+	return nil
+}
+
+// initialTables 版本1创建的表
+var initialTables = []string{
+	"long_short_ratios", "api_logs", "open_interests", "funding_rates",
+	"predicted_fundings", "liquidations", "klines", "symbols",
+}
+
+// dropInitialSchema 001回滚，删除版本1创建的所有数据表
+func dropInitialSchema(tx *gorm.DB) error {
+	for i := len(initialTables) - 1; i >= 0; i-- {
+		if err := tx.Migrator().DropTable(initialTables[i]); err != nil {
+			return fmt.Errorf("删除表%s失败: %w", initialTables[i], err)
+		}
+	}
+	return nil
+}
+
+// longShortRatioUnique 多空比唯一约束：同一交易所、交易对、指标和时间戳只保留一条记录
+type longShortRatioUnique struct {
+	Exchange  string    `gorm:"uniqueIndex:idx_long_short_ratios_unique"`
+	Symbol    string    `gorm:"uniqueIndex:idx_long_short_ratios_unique"`
+	Metric    string    `gorm:"uniqueIndex:idx_long_short_ratios_unique"`
+	Timestamp time.Time `gorm:"uniqueIndex:idx_long_short_ratios_unique"`
+}
+
+// addLongShortRatioUnique 002 删除重复的多空比记录（保留最后写入的一条）后添加唯一约束。
+// 删除的重复记录在回滚时不会恢复
+func addLongShortRatioUnique(tx *gorm.DB) error {
+	// 子查询包一层派生表，MySQL不允许在DELETE的子查询中直接读取同一张表
+	err := tx.Exec(`DELETE FROM long_short_ratios WHERE id NOT IN (
+		SELECT id FROM (
+			SELECT MAX(id) AS id FROM long_short_ratios GROUP BY exchange, symbol, metric, timestamp
+		) AS keep_ids
+	)`).Error
+	if err != nil {
+		return fmt.Errorf("删除重复的多空比记录失败: %w", err)
+	}
+
+	migrator := tx.Table("long_short_ratios").Migrator()
+	if err := migrator.CreateIndex(&longShortRatioUnique{}, "idx_long_short_ratios_unique"); err != nil {
+		return fmt.Errorf("创建多空比唯一索引失败: %w", err)
+	}
+	return nil
+}
+
+// dropLongShortRatioUnique 002回滚，删除多空比唯一约束
+func dropLongShortRatioUnique(tx *gorm.DB) error {
+	migrator := tx.Table("long_short_ratios").Migrator()
+	if err := migrator.DropIndex(&longShortRatioUnique{}, "idx_long_short_ratios_unique"); err != nil {
+		return fmt.Errorf("删除多空比唯一索引失败: %w", err)
+	}
+	return nil
+}
+
+// apiLogCreatedAt API日志按时间查询和清理使用的索引
+type apiLogCreatedAt struct {
+	CreatedAt time.Time `gorm:"index:idx_api_logs_created_at"`
+}
+
+// addAPILogCreatedAtIndex 003 为API日志的created_at添加索引，日志列表、统计和清理都按时间过滤
+func addAPILogCreatedAtIndex(tx *gorm.DB) error {
+	migrator := tx.Table("api_logs").Migrator()
+	if err := migrator.CreateIndex(&apiLogCreatedAt{}, "idx_api_logs_created_at"); err != nil {
+		return fmt.Errorf("创建API日志时间索引失败: %w", err)
+	}
+	return nil
+}
+
+// dropAPILogCreatedAtIndex 003回滚，删除API日志时间索引
+func dropAPILogCreatedAtIndex(tx *gorm.DB) error {
+	migrator := tx.Table("api_logs").Migrator()
+	if err := migrator.DropIndex(&apiLogCreatedAt{}, "idx_api_logs_created_at"); err != nil {
+		return fmt.Errorf("删除API日志时间索引失败: %w", err)
+	}
+	return nil
+}
+
+// longShortRatioPeriod 多空比时间粒度列及包含时间粒度的唯一约束
+type longShortRatioPeriod struct {
+	Exchange  string    `gorm:"uniqueIndex:idx_long_short_ratios_unique"`
+	Symbol    string    `gorm:"uniqueIndex:idx_long_short_ratios_unique"`
+	Metric    string    `gorm:"uniqueIndex:idx_long_short_ratios_unique"`
+	Period    string    `gorm:"uniqueIndex:idx_long_short_ratios_unique;not null;default:5m"`
+	Timestamp time.Time `gorm:"uniqueIndex:idx_long_short_ratios_unique"`
+}
+
+// addLongShortRatioPeriod 004 添加时间粒度列，已有数据都是5m粒度，唯一约束改为包含时间粒度
+func addLongShortRatioPeriod(tx *gorm.DB) error {
+	migrator := tx.Table("long_short_ratios").Migrator()
+	if err := migrator.AddColumn(&longShortRatioPeriod{}, "Period"); err != nil {
+		return fmt.Errorf("添加多空比时间粒度列失败: %w", err)
+	}
+	if err := migrator.DropIndex(&longShortRatioUnique{}, "idx_long_short_ratios_unique"); err != nil {
+		return fmt.Errorf("删除多空比唯一索引失败: %w", err)
+	}
+	if err := migrator.CreateIndex(&longShortRatioPeriod{}, "idx_long_short_ratios_unique"); err != nil {
+		return fmt.Errorf("创建多空比唯一索引失败: %w", err)
+	}
+	return nil
+}
+
+// dropLongShortRatioPeriod 004回滚，删除时间粒度列并恢复不含时间粒度的唯一约束。
+// 同一时间戳存在多个时间粒度的数据时只保留最后写入的一条
+func dropLongShortRatioPeriod(tx *gorm.DB) error {
+	migrator := tx.Table("long_short_ratios").Migrator()
+	if err := migrator.DropIndex(&longShortRatioPeriod{}, "idx_long_short_ratios_unique"); err != nil {
+		return fmt.Errorf("删除多空比唯一索引失败: %w", err)
+	}
+	if err := migrator.DropColumn(&longShortRatioPeriod{}, "Period"); err != nil {
+		return fmt.Errorf("删除多空比时间粒度列失败: %w", err)
+	}
+	if err := restoreLongShortRatioIndexes(tx); err != nil {
+		return err
+	}
+	return addLongShortRatioUnique(tx)
+}
+
+// longShortRatioIndexes 多空比的单列索引
+type longShortRatioIndexes struct {
+	Exchange  string    `gorm:"index:idx_long_short_ratios_exchange"`
+	Symbol    string    `gorm:"index:idx_long_short_ratios_symbol"`
+	Metric    string    `gorm:"index:idx_long_short_ratios_metric"`
+	Timestamp time.Time `gorm:"index:idx_long_short_ratios_timestamp"`
+}
+
+// restoreLongShortRatioIndexes 补充缺少的多空比单列索引，SQLite删除列时会重建表并丢失原有索引
+func restoreLongShortRatioIndexes(tx *gorm.DB) error {
+	migrator := tx.Table("long_short_ratios").Migrator()
+	for _, name := range []string{
+		"idx_long_short_ratios_exchange", "idx_long_short_ratios_symbol",
+		"idx_long_short_ratios_metric", "idx_long_short_ratios_timestamp",
+	} {
+		if migrator.HasIndex(&longShortRatioIndexes{}, name) {
+			continue
+		}
+		if err := migrator.CreateIndex(&longShortRatioIndexes{}, name); err != nil {
+			return fmt.Errorf("创建索引%s失败: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// ratioRollup 版本5的多空比汇总表结构快照。MySQL中只带uniqueIndex的字符串列会建成longtext，无法建立索引，需要指定长度
+type ratioRollup struct {
+	ID          uint `gorm:"primarykey"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Exchange    string    `gorm:"uniqueIndex:idx_long_short_ratio_rollups_unique;size:32;not null"`
+	Symbol      string    `gorm:"uniqueIndex:idx_long_short_ratio_rollups_unique;size:64;not null"`
+	Metric      string    `gorm:"uniqueIndex:idx_long_short_ratio_rollups_unique;size:32;not null"`
+	Resolution  string    `gorm:"uniqueIndex:idx_long_short_ratio_rollups_unique;size:16;not null"`
+	BucketStart time.Time `gorm:"uniqueIndex:idx_long_short_ratio_rollups_unique;not null"`
+	Open        float64   `gorm:"not null"`
+	High        float64   `gorm:"not null"`
+	Low         float64   `gorm:"not null"`
+	Close       float64   `gorm:"not null"`
+	Mean        float64   `gorm:"not null"`
+	Count       int       `gorm:"not null"`
+}
+
+// longShortRatioUpdatedAt 汇总任务按updated_at查找新写入或更新的多空比
+type longShortRatioUpdatedAt struct {
+	UpdatedAt time.Time `gorm:"index:idx_long_short_ratios_updated_at"`
+}
+
+// createRatioRollups 005 创建多空比汇总表，并为多空比的updated_at添加索引用于增量汇总
+func createRatioRollups(tx *gorm.DB) error {
+	if err := tx.Table("long_short_ratio_rollups").AutoMigrate(&ratioRollup{}); err != nil {
+		return fmt.Errorf("创建表long_short_ratio_rollups失败: %w", err)
+	}
+	migrator := tx.Table("long_short_ratios").Migrator()
+	if err := migrator.CreateIndex(&longShortRatioUpdatedAt{}, "idx_long_short_ratios_updated_at"); err != nil {
+		return fmt.Errorf("创建多空比更新时间索引失败: %w", err)
+	}
+	return nil
+}
+
+// dropRatioRollups 005回滚，删除多空比汇总表和更新时间索引
+func dropRatioRollups(tx *gorm.DB) error {
+	migrator := tx.Table("long_short_ratios").Migrator()
+	if err := migrator.DropIndex(&longShortRatioUpdatedAt{}, "idx_long_short_ratios_updated_at"); err != nil {
+		return fmt.Errorf("删除多空比更新时间索引失败: %w", err)
+	}
+	if err := tx.Migrator().DropTable("long_short_ratio_rollups"); err != nil {
+		return fmt.Errorf("删除表long_short_ratio_rollups失败: %w", err)
+	}
+	return nil
+}
+
+// apiLogErrorType API日志的错误类型列
+type apiLogErrorType struct {
+	ErrorType string
+}
+
+// addAPILogErrorType 006 为API日志添加错误类型列，已有的失败记录错误类型为空
+func addAPILogErrorType(tx *gorm.DB) error {
+	migrator := tx.Table("api_logs").Migrator()
+	if err := migrator.AddColumn(&apiLogErrorType{}, "ErrorType"); err != nil {
+		return fmt.Errorf("添加API日志错误类型列失败: %w", err)
+	}
+	return nil
+}
+
+// apiLogIndexes API日志的索引
+type apiLogIndexes struct {
+	Exchange  string    `gorm:"index:idx_api_logs_exchange"`
+	CreatedAt time.Time `gorm:"index:idx_api_logs_created_at"`
+}
+
+// dropAPILogErrorType 006回滚，删除API日志错误类型列。SQLite删除列时会重建表，需要补充丢失的索引
+func dropAPILogErrorType(tx *gorm.DB) error {
+	migrator := tx.Table("api_logs").Migrator()
+	if err := migrator.DropColumn(&apiLogErrorType{}, "ErrorType"); err != nil {
+		return fmt.Errorf("删除API日志错误类型列失败: %w", err)
+	}
+	for _, name := range []string{"idx_api_logs_exchange", "idx_api_logs_created_at"} {
+		if migrator.HasIndex(&apiLogIndexes{}, name) {
+			continue
+		}
+		if err := migrator.CreateIndex(&apiLogIndexes{}, name); err != nil {
+			return fmt.Errorf("创建索引%s失败: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// openInterestUnique 持仓量唯一约束
+type openInterestUnique struct {
+	Exchange  string    `gorm:"uniqueIndex:idx_open_interests_unique"`
+	Symbol    string    `gorm:"uniqueIndex:idx_open_interests_unique"`
+	Timestamp time.Time `gorm:"uniqueIndex:idx_open_interests_unique"`
+}
+
+// fundingRateUnique 已结算资金费率唯一约束
+type fundingRateUnique struct {
+	Exchange    string    `gorm:"uniqueIndex:idx_funding_rates_unique"`
+	Symbol      string    `gorm:"uniqueIndex:idx_funding_rates_unique"`
+	FundingTime time.Time `gorm:"uniqueIndex:idx_funding_rates_unique"`
+}
+
+// predictedFundingUnique 预测资金费率唯一约束：每个结算周期一条
+type predictedFundingUnique struct {
+	Exchange        string    `gorm:"uniqueIndex:idx_predicted_fundings_unique"`
+	Symbol          string    `gorm:"uniqueIndex:idx_predicted_fundings_unique"`
+	NextFundingTime time.Time `gorm:"uniqueIndex:idx_predicted_fundings_unique"`
+}
+
+// liquidationUnique 强平事件唯一约束，REST轮询和推送会重复返回同一事件
+type liquidationUnique struct {
+	Exchange  string    `gorm:"uniqueIndex:idx_liquidations_unique"`
+	Symbol    string    `gorm:"uniqueIndex:idx_liquidations_unique"`
+	Side      string    `gorm:"uniqueIndex:idx_liquidations_unique;size:16;not null"`
+	Timestamp time.Time `gorm:"uniqueIndex:idx_liquidations_unique"`
+	Price     float64   `gorm:"uniqueIndex:idx_liquidations_unique"`
+	Quantity  float64   `gorm:"uniqueIndex:idx_liquidations_unique"`
+}
+
+// klineUnique K线唯一约束：同一交易所、交易对、时间粒度和开盘时间只保留一条记录
+type klineUnique struct {
+	Exchange string    `gorm:"uniqueIndex:idx_klines_unique"`
+	Symbol   string    `gorm:"uniqueIndex:idx_klines_unique"`
+	Period   string    `gorm:"uniqueIndex:idx_klines_unique;size:16;not null"`
+	OpenTime time.Time `gorm:"uniqueIndex:idx_klines_unique"`
+}
+
+// marketDataUniques 版本7添加的唯一约束
+var marketDataUniques = []struct {
+	table   string
+	index   string
+	columns []string
+	model   interface{}
+	resize  []string // MySQL中需要从longtext改为varchar才能建立索引的列
+}{
+	{"open_interests", "idx_open_interests_unique", []string{"exchange", "symbol", "timestamp"}, &openInterestUnique{}, nil},
+	{"funding_rates", "idx_funding_rates_unique", []string{"exchange", "symbol", "funding_time"}, &fundingRateUnique{}, nil},
+	{"predicted_fundings", "idx_predicted_fundings_unique", []string{"exchange", "symbol", "next_funding_time"}, &predictedFundingUnique{}, nil},
+	{"liquidations", "idx_liquidations_unique", []string{"exchange", "symbol", "side", "timestamp", "price", "quantity"}, &liquidationUnique{}, []string{"Side"}},
+	{"klines", "idx_klines_unique", []string{"exchange", "symbol", "period", "open_time"}, &klineUnique{}, []string{"Period"}},
+}
+
+// addMarketDataUnique 007 为持仓量、资金费率、强平和K线表添加唯一约束，推送和轮询并发写入时依赖唯一约束去重。
+// 添加前删除重复记录（保留最后写入的一条），删除的记录在回滚时不会恢复
+func addMarketDataUnique(tx *gorm.DB) error {
+	for _, u := range marketDataUniques {
+		columns := strings.Join(u.columns, ", ")
+		err := tx.Exec(fmt.Sprintf(`DELETE FROM %[1]s WHERE id NOT IN (
+			SELECT id FROM (
+				SELECT MAX(id) AS id FROM %[1]s GROUP BY %[2]s
+			) AS keep_ids
+		)`, u.table, columns)).Error
+		if err != nil {
+			return fmt.Errorf("删除%s的重复记录失败: %w", u.table, err)
+		}
+
+		migrator := tx.Table(u.table).Migrator()
+		if tx.Dialector.Name() == "mysql" {
+			for _, field := range u.resize {
+				if err := migrator.AlterColumn(u.model, field); err != nil {
+					return fmt.Errorf("修改%s.%s列类型失败: %w", u.table, field, err)
+				}
+			}
+		}
+		if err := migrator.CreateIndex(u.model, u.index); err != nil {
+			return fmt.Errorf("创建%s唯一索引失败: %w", u.table, err)
+		}
+	}
+	return nil
+}
+
+// dropMarketDataUnique 007回滚，删除持仓量、资金费率、强平和K线表的唯一约束
+func dropMarketDataUnique(tx *gorm.DB) error {
+	for i := len(marketDataUniques) - 1; i >= 0; i-- {
+		u := marketDataUniques[i]
+		if err := tx.Table(u.table).Migrator().DropIndex(u.model, u.index); err != nil {
+			return fmt.Errorf("删除%s唯一索引失败: %w", u.table, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package database_test
+
+import (
+	"CurrencyMonitor/database"
+	"CurrencyMonitor/internal/testdb"
+	"testing"
+	"time"
+)
+
+func TestMigrateUpDown(t *testing.T) {
+	db := testdb.Open(t)
+
+	if version, err := database.MigrateUp(db, 0); err != nil || version != database.LatestVersion() {
+		t.Fatalf("MigrateUp = %d, %v", version, err)
+	}
+	if version, err := database.MigrateDown(db, database.LatestVersion()); err != nil || version != 0 {
+		t.Fatalf("MigrateDown = %d, %v", version, err)
+	}
+	if version, err := database.MigrateUp(db, 0); err != nil || version != database.LatestVersion() {
+		t.Fatalf("回滚后重新执行MigrateUp = %d, %v", version, err)
+	}
+}
+
+func TestLongShortRatioUniqueRemovesDuplicates(t *testing.T) {
+	db := testdb.Open(t)
+	if _, err := database.MigrateUp(db, 1); err != nil {
+		t.Fatalf("MigrateUp失败: %v", err)
+	}
+
+	ts := time.Unix(1700000000, 0).UTC()
+	row := func(ratio float64) map[string]interface{} {
+		return map[string]interface{}{"exchange": "binance", "symbol": "BTCUSDT", "metric": "global_account", "timestamp": ts, "ratio": ratio}
+	}
+	for _, ratio := range []float64{1.1, 1.2, 1.3} {
+		if err := db.Table("long_short_ratios").Create(row(ratio)).Error; err != nil {
+			t.Fatalf("写入多空比失败: %v", err)
+		}
+	}
+
+	if _, err := database.MigrateUp(db, 1); err != nil {
+		t.Fatalf("执行迁移002失败: %v", err)
+	}
+
+	// 重复记录只保留最后写入的一条
+	var ratios []float64
+	if err := db.Table("long_short_ratios").Pluck("ratio", &ratios).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(ratios) != 1 || ratios[0] != 1.3 {
+		t.Errorf("去重后的多空比 = %v, 期望 [1.3]", ratios)
+	}
+	if err := db.Table("long_short_ratios").Create(row(1.4)).Error; err == nil {
+		t.Error("多空比唯一索引没有生效")
+	}
+}
+
+func TestMarketDataUniqueRemovesDuplicates(t *testing.T) {
+	db := testdb.Open(t)
+	if _, err := database.MigrateUp(db, 6); err != nil {
+		t.Fatalf("MigrateUp失败: %v", err)
+	}
+
+	ts := time.Unix(1700000000, 0).UTC()
+	inserts := []struct {
+		table string
+		row   map[string]interface{}
+	}{
+		{"open_interests", map[string]interface{}{"exchange": "binance", "symbol": "BTCUSDT", "timestamp": ts, "open_interest": 1, "notional": 1}},
+		{"funding_rates", map[string]interface{}{"exchange": "binance", "symbol": "BTCUSDT", "funding_time": ts, "rate": 0.0001}},
+		{"predicted_fundings", map[string]interface{}{"exchange": "binance", "symbol": "BTCUSDT", "next_funding_time": ts, "timestamp": ts, "rate": 0.0001}},
+		{"liquidations", map[string]interface{}{"exchange": "binance", "symbol": "BTCUSDT", "side": "long", "timestamp": ts, "price": 100, "quantity": 1, "notional": 100}},
+		{"klines", map[string]interface{}{"exchange": "binance", "symbol": "BTCUSDT", "period": "5m", "open_time": ts, "open": 1, "high": 1, "low": 1, "close": 1, "volume": 1}},
+	}
+	for _, insert := range inserts {
+		for i := 0; i < 2; i++ {
+			if err := db.Table(insert.table).Create(insert.row).Error; err != nil {
+				t.Fatalf("写入%s失败: %v", insert.table, err)
+			}
+		}
+	}
+
+	if _, err := database.MigrateUp(db, 0); err != nil {
+		t.Fatalf("执行迁移007失败: %v", err)
+	}
+
+	for _, insert := range inserts {
+		var count int64
+		if err := db.Table(insert.table).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Errorf("%s保留%d条记录，期望1条", insert.table, count)
+		}
+
+		// 唯一索引生效后重复写入失败
+		if err := db.Table(insert.table).Create(insert.row).Error; err == nil {
+			t.Errorf("%s唯一索引没有生效", insert.table)
+		}
+	}
+}
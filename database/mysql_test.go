@@ -0,0 +1,45 @@
+package database
+
+import (
+	"CurrencyMonitor/models"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm/schema"
+)
+
+// TestMySQLIndexedColumnsHaveSize MySQL不能为longtext列建立索引，迁移建表使用的结构和模型中带索引的字符串列都必须指定长度
+func TestMySQLIndexedColumnsHaveSize(t *testing.T) {
+	dialector := mysql.Dialector{Config: &mysql.Config{}}
+	cache := &sync.Map{}
+
+	for _, model := range []interface{}{
+		&ratioRollup{},
+		&models.LongShortRatio{},
+		&models.LongShortRatioRollup{},
+		&models.APILog{},
+		&models.OpenInterest{},
+		&models.FundingRate{},
+		&models.PredictedFunding{},
+		&models.Liquidation{},
+		&models.Kline{},
+		&models.Symbol{},
+	} {
+		s, err := schema.Parse(model, cache, schema.NamingStrategy{})
+		if err != nil {
+			t.Fatalf("解析%T失败: %v", model, err)
+		}
+		for _, index := range s.ParseIndexes() {
+			for _, option := range index.Fields {
+				if option.Field.DataType != schema.String {
+					continue
+				}
+				if dataType := dialector.DataTypeOf(option.Field); strings.HasSuffix(dataType, "text") {
+					t.Errorf("%s.%s 在索引%s中，MySQL列类型为%s", s.Name, option.Field.Name, index.Name, dataType)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"CurrencyMonitor/config"
+	"CurrencyMonitor/database"
+	"CurrencyMonitor/export"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// runExport 执行export子命令：将已保存的数据按交易所/交易对/日期分区导出为CSV或Parquet文件
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("currency_monitor export", flag.ContinueOnError)
+	dataset := fs.String("dataset", "long_short_ratios", "导出的数据集，支持: "+strings.Join(export.Datasets(), ", "))
+	formats := fs.String("format", "parquet", "导出格式，逗号分隔，支持: "+strings.Join(export.Formats, ", "))
+	out := fs.String("out", "export", "导出目录")
+	start := fs.String("start", "", "开始时间（包含），格式为2006-01-02或RFC3339（默认为最近days天）")
+	end := fs.String("end", "", "结束时间（不包含），格式同start（默认为当前时间）")
+	days := fs.Int("days", 7, "未指定start时导出最近多少天的数据")
+	exchangeNames := fs.String("exchange", "", "交易所，逗号分隔（默认为全部）")
+	symbols := fs.String("symbol", "", "交易对，逗号分隔（默认为全部）")
+	noPartition := fs.Bool("no-partition", false, "每种格式只写入一个文件，不按交易所/交易对/日期分区")
+
+	cfg, err := config.LoadFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("未知参数: %s", strings.Join(fs.Args(), " "))
+	}
+
+	filter := export.Filter{
+		Exchanges: splitList(*exchangeNames),
+		Symbols:   splitList(*symbols),
+		End:       time.Now(),
+	}
+	if *end != "" {
+		if filter.End, err = export.ParseTime(*end); err != nil {
+			return err
+		}
+	}
+	filter.Start = filter.End.AddDate(0, 0, -*days)
+	if *start != "" {
+		if filter.Start, err = export.ParseTime(*start); err != nil {
+			return err
+		}
+	}
+
+	if err := database.InitDatabase(cfg.Database); err != nil {
+		return err
+	}
+
+	// Ctrl+C时中止进行中的交易所请求和数据库操作
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	summary, err := export.Export(ctx, database.GetDB(), export.Options{
+		Dataset:   *dataset,
+		Formats:   splitList(*formats),
+		Filter:    filter,
+		Partition: !*noPartition,
+	}, export.DirSink(*out))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("导出完成: %s ~ %s，%d个文件，共%d行，目录%s\n",
+		filter.Start.Format(time.RFC3339), filter.End.Format(time.RFC3339), len(summary.Files), summary.Rows, *out)
+	return nil
+}
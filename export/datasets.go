@@ -0,0 +1,288 @@
+package export
+
+import (
+	"CurrencyMonitor/models"
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"gorm.io/gorm"
+)
+
+// Row 导出的一行数据
+type Row interface {
+	partition() (exchange, symbol string, timestamp time.Time) // 分区使用的交易所、交易对和时间
+	record() []string                                          // CSV的一行
+}
+
+// dataset 可导出的数据集。新增数据表时实现对应的Row并在datasets中注册
+type dataset struct {
+	header []string        // CSV表头，与Row.record的顺序一致
+	schema *parquet.Schema // Parquet结构，由Row的结构体生成
+	// each 按交易所、交易对、时间顺序逐行读取过滤后的数据
+	each func(ctx context.Context, db *gorm.DB, filter Filter, fn func(Row) error) error
+}
+
+// datasets 可导出的数据集，键为数据表名
+var datasets = map[string]dataset{
+	"long_short_ratios": {
+		header: []string{"exchange", "symbol", "metric", "period", "ratio", "timestamp"},
+		schema: parquet.SchemaOf(ratioRow{}),
+		each:   eachRatio,
+	},
+	"open_interests": {
+		header: []string{"exchange", "symbol", "open_interest", "notional", "timestamp"},
+		schema: parquet.SchemaOf(openInterestRow{}),
+		each:   eachOpenInterest,
+	},
+	"funding_rates": {
+		header: []string{"exchange", "symbol", "rate", "funding_time"},
+		schema: parquet.SchemaOf(fundingRateRow{}),
+		each:   eachFundingRate,
+	},
+	"predicted_fundings": {
+		header: []string{"exchange", "symbol", "rate", "next_funding_time", "timestamp"},
+		schema: parquet.SchemaOf(predictedFundingRow{}),
+		each:   eachPredictedFunding,
+	},
+	"klines": {
+		header: []string{"exchange", "symbol", "period", "open_time", "open", "high", "low", "close", "volume"},
+		schema: parquet.SchemaOf(klineRow{}),
+		each:   eachKline,
+	},
+	"liquidations": {
+		header: []string{"exchange", "symbol", "side", "price", "quantity", "notional", "timestamp"},
+		schema: parquet.SchemaOf(liquidationRow{}),
+		each:   eachLiquidation,
+	},
+}
+
+// Datasets 可导出的数据集名称
+func Datasets() []string {
+	names := make([]string, 0, len(datasets))
+	for name := range datasets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatFloat CSV中的数值使用最短的无损十进制表示
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// formatTime CSV中的时间使用UTC的RFC3339格式
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// ratioRow 导出的多空比数据
+type ratioRow struct {
+	Exchange  string    `parquet:"exchange,dict"`
+	Symbol    string    `parquet:"symbol,dict"`
+	Metric    string    `parquet:"metric,dict"`
+	Period    string    `parquet:"period,dict"`
+	Ratio     float64   `parquet:"ratio"`
+	Timestamp time.Time `parquet:"timestamp,timestamp"`
+}
+
+func (r ratioRow) partition() (string, string, time.Time) {
+	return r.Exchange, r.Symbol, r.Timestamp
+}
+
+func (r ratioRow) record() []string {
+	return []string{
+		r.Exchange, r.Symbol, r.Metric, r.Period,
+		formatFloat(r.Ratio),
+		formatTime(r.Timestamp),
+	}
+}
+
+// eachRatio 逐行读取多空比数据
+func eachRatio(ctx context.Context, db *gorm.DB, filter Filter, fn func(Row) error) error {
+	repo := models.NewLongShortRatioRepository(db)
+	return repo.ForEach(ctx, filter.Exchanges, filter.Symbols, filter.Start, filter.End, func(ratio *models.LongShortRatio) error {
+		return fn(ratioRow{
+			Exchange:  ratio.Exchange,
+			Symbol:    ratio.Symbol,
+			Metric:    ratio.Metric,
+			Period:    ratio.Period,
+			Ratio:     ratio.Ratio,
+			Timestamp: ratio.Timestamp.UTC(),
+		})
+	})
+}
+
+// openInterestRow 导出的持仓量数据
+type openInterestRow struct {
+	Exchange     string    `parquet:"exchange,dict"`
+	Symbol       string    `parquet:"symbol,dict"`
+	OpenInterest float64   `parquet:"open_interest"`
+	Notional     float64   `parquet:"notional"`
+	Timestamp    time.Time `parquet:"timestamp,timestamp"`
+}
+
+func (r openInterestRow) partition() (string, string, time.Time) {
+	return r.Exchange, r.Symbol, r.Timestamp
+}
+
+func (r openInterestRow) record() []string {
+	return []string{r.Exchange, r.Symbol, formatFloat(r.OpenInterest), formatFloat(r.Notional), formatTime(r.Timestamp)}
+}
+
+// eachOpenInterest 逐行读取持仓量数据
+func eachOpenInterest(ctx context.Context, db *gorm.DB, filter Filter, fn func(Row) error) error {
+	repo := models.NewOpenInterestRepository(db)
+	return repo.ForEach(ctx, filter.Exchanges, filter.Symbols, filter.Start, filter.End, func(oi *models.OpenInterest) error {
+		return fn(openInterestRow{
+			Exchange:     oi.Exchange,
+			Symbol:       oi.Symbol,
+			OpenInterest: oi.OpenInterest,
+			Notional:     oi.Notional,
+			Timestamp:    oi.Timestamp.UTC(),
+		})
+	})
+}
+
+// fundingRateRow 导出的已结算资金费率，按结算时间分区
+type fundingRateRow struct {
+	Exchange    string    `parquet:"exchange,dict"`
+	Symbol      string    `parquet:"symbol,dict"`
+	Rate        float64   `parquet:"rate"`
+	FundingTime time.Time `parquet:"funding_time,timestamp"`
+}
+
+func (r fundingRateRow) partition() (string, string, time.Time) {
+	return r.Exchange, r.Symbol, r.FundingTime
+}
+
+func (r fundingRateRow) record() []string {
+	return []string{r.Exchange, r.Symbol, formatFloat(r.Rate), formatTime(r.FundingTime)}
+}
+
+// eachFundingRate 逐行读取已结算资金费率
+func eachFundingRate(ctx context.Context, db *gorm.DB, filter Filter, fn func(Row) error) error {
+	repo := models.NewFundingRateRepository(db)
+	return repo.ForEach(ctx, filter.Exchanges, filter.Symbols, filter.Start, filter.End, func(rate *models.FundingRate) error {
+		return fn(fundingRateRow{
+			Exchange:    rate.Exchange,
+			Symbol:      rate.Symbol,
+			Rate:        rate.Rate,
+			FundingTime: rate.FundingTime.UTC(),
+		})
+	})
+}
+
+// predictedFundingRow 导出的预测资金费率，按下一次结算时间分区
+type predictedFundingRow struct {
+	Exchange        string    `parquet:"exchange,dict"`
+	Symbol          string    `parquet:"symbol,dict"`
+	Rate            float64   `parquet:"rate"`
+	NextFundingTime time.Time `parquet:"next_funding_time,timestamp"`
+	Timestamp       time.Time `parquet:"timestamp,timestamp"`
+}
+
+func (r predictedFundingRow) partition() (string, string, time.Time) {
+	return r.Exchange, r.Symbol, r.NextFundingTime
+}
+
+func (r predictedFundingRow) record() []string {
+	return []string{r.Exchange, r.Symbol, formatFloat(r.Rate), formatTime(r.NextFundingTime), formatTime(r.Timestamp)}
+}
+
+// eachPredictedFunding 逐行读取预测资金费率
+func eachPredictedFunding(ctx context.Context, db *gorm.DB, filter Filter, fn func(Row) error) error {
+	repo := models.NewFundingRateRepository(db)
+	return repo.ForEachPredicted(ctx, filter.Exchanges, filter.Symbols, filter.Start, filter.End, func(predicted *models.PredictedFunding) error {
+		return fn(predictedFundingRow{
+			Exchange:        predicted.Exchange,
+			Symbol:          predicted.Symbol,
+			Rate:            predicted.Rate,
+			NextFundingTime: predicted.NextFundingTime.UTC(),
+			Timestamp:       predicted.Timestamp.UTC(),
+		})
+	})
+}
+
+// klineRow 导出的K线数据，按开盘时间分区
+type klineRow struct {
+	Exchange string    `parquet:"exchange,dict"`
+	Symbol   string    `parquet:"symbol,dict"`
+	Period   string    `parquet:"period,dict"`
+	OpenTime time.Time `parquet:"open_time,timestamp"`
+	Open     float64   `parquet:"open"`
+	High     float64   `parquet:"high"`
+	Low      float64   `parquet:"low"`
+	Close    float64   `parquet:"close"`
+	Volume   float64   `parquet:"volume"`
+}
+
+func (r klineRow) partition() (string, string, time.Time) {
+	return r.Exchange, r.Symbol, r.OpenTime
+}
+
+func (r klineRow) record() []string {
+	return []string{
+		r.Exchange, r.Symbol, r.Period, formatTime(r.OpenTime),
+		formatFloat(r.Open), formatFloat(r.High), formatFloat(r.Low), formatFloat(r.Close), formatFloat(r.Volume),
+	}
+}
+
+// eachKline 逐行读取K线数据
+func eachKline(ctx context.Context, db *gorm.DB, filter Filter, fn func(Row) error) error {
+	repo := models.NewKlineRepository(db)
+	return repo.ForEach(ctx, filter.Exchanges, filter.Symbols, filter.Start, filter.End, func(kline *models.Kline) error {
+		return fn(klineRow{
+			Exchange: kline.Exchange,
+			Symbol:   kline.Symbol,
+			Period:   kline.Period,
+			OpenTime: kline.OpenTime.UTC(),
+			Open:     kline.Open,
+			High:     kline.High,
+			Low:      kline.Low,
+			Close:    kline.Close,
+			Volume:   kline.Volume,
+		})
+	})
+}
+
+// liquidationRow 导出的强平记录
+type liquidationRow struct {
+	Exchange  string    `parquet:"exchange,dict"`
+	Symbol    string    `parquet:"symbol,dict"`
+	Side      string    `parquet:"side,dict"`
+	Price     float64   `parquet:"price"`
+	Quantity  float64   `parquet:"quantity"`
+	Notional  float64   `parquet:"notional"`
+	Timestamp time.Time `parquet:"timestamp,timestamp"`
+}
+
+func (r liquidationRow) partition() (string, string, time.Time) {
+	return r.Exchange, r.Symbol, r.Timestamp
+}
+
+func (r liquidationRow) record() []string {
+	return []string{
+		r.Exchange, r.Symbol, r.Side,
+		formatFloat(r.Price), formatFloat(r.Quantity), formatFloat(r.Notional), formatTime(r.Timestamp),
+	}
+}
+
+// eachLiquidation 逐行读取强平记录
+func eachLiquidation(ctx context.Context, db *gorm.DB, filter Filter, fn func(Row) error) error {
+	repo := models.NewLiquidationRepository(db)
+	return repo.ForEach(ctx, filter.Exchanges, filter.Symbols, filter.Start, filter.End, func(liq *models.Liquidation) error {
+		return fn(liquidationRow{
+			Exchange:  liq.Exchange,
+			Symbol:    liq.Symbol,
+			Side:      liq.Side,
+			Price:     liq.Price,
+			Quantity:  liq.Quantity,
+			Notional:  liq.Notional,
+			Timestamp: liq.Timestamp.UTC(),
+		})
+	})
+}
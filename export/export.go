@@ -0,0 +1,289 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"gorm.io/gorm"
+)
+
+// 导出格式
+const (
+	FormatCSV     = "csv"
+	FormatParquet = "parquet"
+)
+
+// Formats 支持的导出格式
+var Formats = []string{FormatCSV, FormatParquet}
+
+// Filter 导出数据的过滤条件
+type Filter struct {
+	Exchanges []string  // 为空时导出所有交易所
+	Symbols   []string  // 为空时导出所有交易对
+	Start     time.Time // 开始时间（包含）
+	End       time.Time // 结束时间（不包含）
+}
+
+// Options 导出选项
+type Options struct {
+	Dataset   string
+	Formats   []string
+	Filter    Filter
+	Partition bool // 按交易所/交易对/日期（UTC）分区写入多个文件，否则每种格式写入一个文件
+}
+
+// File 导出的文件
+type File struct {
+	Path string `json:"path"`
+	Rows int    `json:"rows"`
+}
+
+// Summary 导出结果
+type Summary struct {
+	Files []File `json:"files"`
+	Rows  int    `json:"rows"` // 导出的总行数，多种格式时按每种格式分别计数
+}
+
+// Sink 导出文件的写入目标，path为使用/分隔的相对路径
+type Sink interface {
+	Create(path string) (io.WriteCloser, error)
+}
+
+// DirSink 将导出文件写入本地目录，已存在的文件会被覆盖
+type DirSink string
+
+// Create 创建导出文件及其所在目录
+func (d DirSink) Create(name string) (io.WriteCloser, error) {
+	fullPath := filepath.Join(string(d), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+		return nil, fmt.Errorf("创建导出目录失败: %w", err)
+	}
+	file, err := os.Create(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("创建导出文件失败: %w", err)
+	}
+	return file, nil
+}
+
+// ZipSink 将导出文件依次写入zip压缩包，用于HTTP流式下载，写完后需要调用Close
+type ZipSink struct {
+	w *zip.Writer
+}
+
+// NewZipSink 创建写入w的zip压缩包
+func NewZipSink(w io.Writer) *ZipSink {
+	return &ZipSink{w: zip.NewWriter(w)}
+}
+
+// Create 在压缩包中添加文件，添加下一个文件时上一个文件自动结束
+func (z *ZipSink) Create(name string) (io.WriteCloser, error) {
+	w, err := z.w.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建压缩包文件失败: %w", err)
+	}
+	return nopCloser{w}, nil
+}
+
+// Close 写入压缩包目录，完成压缩包
+func (z *ZipSink) Close() error {
+	return z.w.Close()
+}
+
+// StreamSink 将唯一的导出文件直接写入w，用于不分区的单一格式导出
+type StreamSink struct {
+	W io.Writer
+}
+
+// Create 返回w，w由调用方关闭
+func (s StreamSink) Create(string) (io.WriteCloser, error) {
+	return nopCloser{s.W}, nil
+}
+
+// nopCloser 关闭时不做任何操作的写入器
+type nopCloser struct {
+	io.Writer
+}
+
+// Close 不做任何操作
+func (nopCloser) Close() error {
+	return nil
+}
+
+// Validate 校验导出选项，流式导出时在写出响应头之前调用
+func Validate(opts Options) error {
+	if _, ok := datasets[opts.Dataset]; !ok {
+		return fmt.Errorf("不支持的数据集: %s，支持: %v", opts.Dataset, Datasets())
+	}
+	if len(opts.Formats) == 0 {
+		return fmt.Errorf("未指定导出格式，支持: %v", Formats)
+	}
+	for _, format := range opts.Formats {
+		if !slices.Contains(Formats, format) {
+			return fmt.Errorf("不支持的导出格式: %s，支持: %v", format, Formats)
+		}
+	}
+	if !opts.Filter.Start.Before(opts.Filter.End) {
+		return fmt.Errorf("开始时间必须早于结束时间")
+	}
+	return nil
+}
+
+// Export 按过滤条件导出数据集，每种格式单独读取一遍数据，保证同一时间只有一个文件在写入。
+// ctx取消时停止读取数据并返回错误
+func Export(ctx context.Context, db *gorm.DB, opts Options, sink Sink) (Summary, error) {
+	if err := Validate(opts); err != nil {
+		return Summary{}, err
+	}
+
+	var summary Summary
+	for _, format := range opts.Formats {
+		files, err := exportFormat(ctx, db, datasets[opts.Dataset], opts, format, sink)
+		for _, file := range files {
+			summary.Files = append(summary.Files, file)
+			summary.Rows += file.Rows
+		}
+		if err != nil {
+			return summary, err
+		}
+	}
+	return summary, nil
+}
+
+// exportFormat 按一种格式导出数据集，数据按交易所、交易对、时间顺序读取，分区切换时关闭上一个文件
+func exportFormat(ctx context.Context, db *gorm.DB, ds dataset, opts Options, format string, sink Sink) ([]File, error) {
+	var (
+		files   []File
+		current partWriter
+	)
+	closeCurrent := func() error {
+		if current == nil {
+			return nil
+		}
+		err := current.close()
+		current = nil
+		if err != nil {
+			return fmt.Errorf("写入%s失败: %w", files[len(files)-1].Path, err)
+		}
+		return nil
+	}
+
+	err := ds.each(ctx, db, opts.Filter, func(row Row) error {
+		name := path.Join(format, opts.Dataset+"."+format)
+		if opts.Partition {
+			exchange, symbol, timestamp := row.partition()
+			name = path.Join(format, opts.Dataset,
+				"exchange="+exchange, "symbol="+symbol, "date="+timestamp.UTC().Format("2006-01-02"),
+				"data."+format)
+		}
+
+		if current == nil || files[len(files)-1].Path != name {
+			if err := closeCurrent(); err != nil {
+				return err
+			}
+			out, err := sink.Create(name)
+			if err != nil {
+				return err
+			}
+			current = newPartWriter(format, ds, out)
+			files = append(files, File{Path: name})
+		}
+
+		if err := current.write(row); err != nil {
+			return fmt.Errorf("写入%s失败: %w", name, err)
+		}
+		files[len(files)-1].Rows++
+		return nil
+	})
+	if err != nil {
+		closeCurrent()
+		return files, err
+	}
+	return files, closeCurrent()
+}
+
+// partWriter 单个导出文件的写入器
+type partWriter interface {
+	write(row Row) error
+	close() error
+}
+
+// newPartWriter 按格式创建导出文件的写入器
+func newPartWriter(format string, ds dataset, out io.WriteCloser) partWriter {
+	if format == FormatParquet {
+		return &parquetWriter{
+			out: out,
+			w:   parquet.NewWriter(out, ds.schema, parquet.Compression(&parquet.Snappy)),
+		}
+	}
+
+	return &csvWriter{out: out, w: csv.NewWriter(out), header: ds.header}
+}
+
+// csvWriter CSV文件写入器，写入第一行数据前先写表头
+type csvWriter struct {
+	out    io.WriteCloser
+	w      *csv.Writer
+	header []string
+}
+
+func (c *csvWriter) write(row Row) error {
+	if c.header != nil {
+		if err := c.w.Write(c.header); err != nil {
+			return err
+		}
+		c.header = nil
+	}
+	return c.w.Write(row.record())
+}
+
+func (c *csvWriter) close() error {
+	c.w.Flush()
+	if err := c.w.Error(); err != nil {
+		c.out.Close()
+		return err
+	}
+	return c.out.Close()
+}
+
+// parquetWriter Parquet文件写入器，使用snappy压缩
+type parquetWriter struct {
+	out io.WriteCloser
+	w   *parquet.Writer
+}
+
+func (p *parquetWriter) write(row Row) error {
+	return p.w.Write(row)
+}
+
+func (p *parquetWriter) close() error {
+	if err := p.w.Close(); err != nil {
+		p.out.Close()
+		return err
+	}
+	return p.out.Close()
+}
+
+// ParseTime 解析导出的时间范围参数，支持日期（按UTC零点）和RFC3339时间
+func ParseTime(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无效的时间: %s，格式为2006-01-02或RFC3339", value)
+	}
+	return t, nil
+}
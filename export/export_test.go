@@ -0,0 +1,216 @@
+package export
+
+import (
+	"CurrencyMonitor/internal/testdb"
+	"CurrencyMonitor/models"
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"gorm.io/gorm"
+)
+
+// readCSV 读取导出的CSV文件，返回表头和数据行
+func readCSV(t *testing.T, path string) ([]string, [][]string) {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil || len(records) == 0 {
+		t.Fatalf("读取%s失败: %v", path, err)
+	}
+	return records[0], records[1:]
+}
+
+// readParquet 按数据集的行类型读取导出的Parquet文件，返回每行对应的CSV记录
+func readParquet[T Row](path string) ([][]string, error) {
+	rows, err := parquet.ReadFile[T](path)
+	if err != nil {
+		return nil, err
+	}
+	records := make([][]string, len(rows))
+	for i, row := range rows {
+		records[i] = row.record()
+	}
+	return records, nil
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	db := testdb.Migrated(t)
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ts := start.Add(90 * time.Minute)
+	// 时间范围之外和被交易所过滤掉的记录不会被导出
+	before, after := start.Add(-time.Minute), start.Add(24*time.Hour)
+
+	tests := []struct {
+		dataset string
+		insert  func(db *gorm.DB, exchange string, at time.Time) error
+		read    func(path string) ([][]string, error)
+		want    [][]string
+	}{
+		{
+			dataset: "long_short_ratios",
+			insert: func(db *gorm.DB, exchange string, at time.Time) error {
+				return models.NewLongShortRatioRepository(db).CreateOrUpdate(ctx, &models.LongShortRatio{
+					Exchange: exchange, Symbol: "BTCUSDT", Metric: "global_account", Period: "5m", Ratio: 1.25, Timestamp: at,
+				})
+			},
+			read: readParquet[ratioRow],
+			want: [][]string{{"binance", "BTCUSDT", "global_account", "5m", "1.25", "2024-01-01T01:30:00Z"}},
+		},
+		{
+			dataset: "open_interests",
+			insert: func(db *gorm.DB, exchange string, at time.Time) error {
+				return models.NewOpenInterestRepository(db).CreateOrUpdate(ctx, &models.OpenInterest{
+					Exchange: exchange, Symbol: "BTCUSDT", OpenInterest: 1234.5, Notional: 52000000, Timestamp: at,
+				})
+			},
+			read: readParquet[openInterestRow],
+			want: [][]string{{"binance", "BTCUSDT", "1234.5", "52000000", "2024-01-01T01:30:00Z"}},
+		},
+		{
+			dataset: "funding_rates",
+			insert: func(db *gorm.DB, exchange string, at time.Time) error {
+				return models.NewFundingRateRepository(db).CreateOrUpdate(ctx, &models.FundingRate{
+					Exchange: exchange, Symbol: "BTCUSDT", Rate: 0.0001, FundingTime: at,
+				})
+			},
+			read: readParquet[fundingRateRow],
+			want: [][]string{{"binance", "BTCUSDT", "0.0001", "2024-01-01T01:30:00Z"}},
+		},
+		{
+			dataset: "predicted_fundings",
+			insert: func(db *gorm.DB, exchange string, at time.Time) error {
+				return models.NewFundingRateRepository(db).SavePredicted(ctx, &models.PredictedFunding{
+					Exchange: exchange, Symbol: "BTCUSDT", Rate: -0.00025, NextFundingTime: at, Timestamp: at.Add(-time.Hour),
+				})
+			},
+			read: readParquet[predictedFundingRow],
+			want: [][]string{{"binance", "BTCUSDT", "-0.00025", "2024-01-01T01:30:00Z", "2024-01-01T00:30:00Z"}},
+		},
+		{
+			dataset: "klines",
+			insert: func(db *gorm.DB, exchange string, at time.Time) error {
+				return models.NewKlineRepository(db).CreateOrUpdate(ctx, &models.Kline{
+					Exchange: exchange, Symbol: "BTCUSDT", Period: "5m", OpenTime: at, Open: 100, High: 110.5, Low: 99, Close: 105, Volume: 12.75,
+				})
+			},
+			read: readParquet[klineRow],
+			want: [][]string{{"binance", "BTCUSDT", "5m", "2024-01-01T01:30:00Z", "100", "110.5", "99", "105", "12.75"}},
+		},
+		{
+			dataset: "liquidations",
+			insert: func(db *gorm.DB, exchange string, at time.Time) error {
+				_, err := models.NewLiquidationRepository(db).CreateIfNotExists(ctx, &models.Liquidation{
+					Exchange: exchange, Symbol: "BTCUSDT", Side: "long", Price: 42000, Quantity: 0.5, Notional: 21000, Timestamp: at,
+				})
+				return err
+			},
+			read: readParquet[liquidationRow],
+			want: [][]string{{"binance", "BTCUSDT", "long", "42000", "0.5", "21000", "2024-01-01T01:30:00Z"}},
+		},
+	}
+
+	if got := Datasets(); len(got) != len(tests) {
+		t.Errorf("Datasets() = %v, 期望%d个数据集", got, len(tests))
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dataset, func(t *testing.T) {
+			for _, row := range []struct {
+				exchange string
+				at       time.Time
+			}{
+				{"binance", ts}, {"binance", before}, {"binance", after}, {"okx", ts},
+			} {
+				if err := tt.insert(db, row.exchange, row.at); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			dir := t.TempDir()
+			summary, err := Export(ctx, db, Options{
+				Dataset: tt.dataset,
+				Formats: Formats,
+				Filter:  Filter{Exchanges: []string{"binance"}, Start: start, End: after},
+			}, DirSink(dir))
+			if err != nil {
+				t.Fatalf("导出失败: %v", err)
+			}
+			if len(summary.Files) != 2 || summary.Rows != 2*len(tt.want) {
+				t.Fatalf("导出结果错误: %+v", summary)
+			}
+
+			header, records := readCSV(t, filepath.Join(dir, FormatCSV, tt.dataset+".csv"))
+			if !reflect.DeepEqual(header, datasets[tt.dataset].header) {
+				t.Errorf("CSV表头 = %v, 期望 %v", header, datasets[tt.dataset].header)
+			}
+			if !reflect.DeepEqual(records, tt.want) {
+				t.Errorf("CSV数据 = %v, 期望 %v", records, tt.want)
+			}
+
+			records, err = tt.read(filepath.Join(dir, FormatParquet, tt.dataset+".parquet"))
+			if err != nil {
+				t.Fatalf("读取Parquet失败: %v", err)
+			}
+			if !reflect.DeepEqual(records, tt.want) {
+				t.Errorf("Parquet数据 = %v, 期望 %v", records, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportPartitions(t *testing.T) {
+	db := testdb.Migrated(t)
+	ctx := context.Background()
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	klines := models.NewKlineRepository(db)
+	for _, kline := range []models.Kline{
+		{Exchange: "okx", Symbol: "ETHUSDT", Period: "1h", OpenTime: day.Add(23 * time.Hour), Close: 2},
+		{Exchange: "binance", Symbol: "BTCUSDT", Period: "1h", OpenTime: day, Close: 1},
+		{Exchange: "binance", Symbol: "BTCUSDT", Period: "1h", OpenTime: day.Add(24 * time.Hour), Close: 3},
+		{Exchange: "binance", Symbol: "BTCUSDT", Period: "5m", OpenTime: day, Close: 4},
+	} {
+		if err := klines.CreateOrUpdate(ctx, &kline); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir := t.TempDir()
+	summary, err := Export(ctx, db, Options{
+		Dataset:   "klines",
+		Formats:   []string{FormatParquet},
+		Filter:    Filter{Start: day, End: day.Add(48 * time.Hour)},
+		Partition: true,
+	}, DirSink(dir))
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+
+	// 按交易所、交易对和开盘时间的UTC日期分区，同一天不同粒度的K线写入同一个文件
+	want := []File{
+		{Path: "parquet/klines/exchange=binance/symbol=BTCUSDT/date=2024-01-01/data.parquet", Rows: 2},
+		{Path: "parquet/klines/exchange=binance/symbol=BTCUSDT/date=2024-01-02/data.parquet", Rows: 1},
+		{Path: "parquet/klines/exchange=okx/symbol=ETHUSDT/date=2024-01-01/data.parquet", Rows: 1},
+	}
+	if !reflect.DeepEqual(summary.Files, want) {
+		t.Fatalf("分区文件 = %+v, 期望 %+v", summary.Files, want)
+	}
+	for _, file := range want {
+		rows, err := parquet.ReadFile[klineRow](filepath.Join(dir, filepath.FromSlash(file.Path)))
+		if err != nil || len(rows) != file.Rows {
+			t.Errorf("读取%s = %d行, %v, 期望 %d行", file.Path, len(rows), err, file.Rows)
+		}
+	}
+}
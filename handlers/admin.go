@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler 管理接口处理器
+type AdminHandler struct {
+	token  string
+	reload func() ([]string, error)
+	status func() map[string]interface{}
+}
+
+// NewAdminHandler 创建新的管理接口处理器，reload重新加载配置并返回变更说明，status返回调度器状态
+func NewAdminHandler(token string, reload func() ([]string, error), status func() map[string]interface{}) *AdminHandler {
+	return &AdminHandler{
+		token:  token,
+		reload: reload,
+		status: status,
+	}
+}
+
+// RequireToken 校验管理接口令牌。未配置令牌时只允许本机访问，
+// 按连接的来源地址判断，不使用可以伪造的X-Forwarded-For
+func (h *AdminHandler) RequireToken(c *gin.Context) {
+	if h.token == "" {
+		if !isLoopback(c.Request.RemoteAddr) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"message": "未配置管理接口令牌，只允许本机访问",
+			})
+			return
+		}
+		c.Next()
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(c.GetHeader("X-Admin-Token")), []byte(h.token)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "管理接口令牌无效",
+		})
+		return
+	}
+	c.Next()
+}
+
+// isLoopback 判断连接来源地址是否为本机
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Reload 重新加载配置
+func (h *AdminHandler) Reload(c *gin.Context) {
+	changes, err := h.reload()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "重新加载配置失败: " + err.Error(),
+		})
+		return
+	}
+
+	if changes == nil {
+		changes = []string{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"changed": len(changes) > 0,
+			"changes": changes,
+		},
+	})
+}
+
+// GetStatus 获取调度器状态
+func (h *AdminHandler) GetStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.status(),
+	})
+}
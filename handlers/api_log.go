@@ -25,6 +25,7 @@ func NewAPILogHandler() *APILogHandler {
 
 // GetRecentLogs 获取最近的API日志
 func (h *APILogHandler) GetRecentLogs(c *gin.Context) {
+	ctx := c.Request.Context()
 	limitStr := c.DefaultQuery("limit", "100")
 	exchange := c.Query("exchange")
 
@@ -35,9 +36,9 @@ func (h *APILogHandler) GetRecentLogs(c *gin.Context) {
 
 	var logs []models.APILog
 	if exchange != "" {
-		logs, err = h.repo.GetByExchange(exchange, limit)
+		logs, err = h.repo.GetByExchange(ctx, exchange, limit)
 	} else {
-		logs, err = h.repo.GetRecent(limit)
+		logs, err = h.repo.GetRecent(ctx, limit)
 	}
 
 	if err != nil {
@@ -64,7 +65,7 @@ func (h *APILogHandler) GetStatistics(c *gin.Context) {
 	}
 
 	since := time.Now().Add(-time.Duration(hours) * time.Hour)
-	stats, err := h.repo.GetStatistics(since)
+	stats, err := h.repo.GetStatistics(c.Request.Context(), since)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
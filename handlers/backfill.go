@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"CurrencyMonitor/backfill"
+	"CurrencyMonitor/config"
+	"CurrencyMonitor/database"
+	"CurrencyMonitor/models"
+	"CurrencyMonitor/services"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BackfillHandler 历史数据补齐处理器
+type BackfillHandler struct {
+	ctx        context.Context // 后台补齐任务使用的context，不随请求结束取消
+	backfiller *backfill.Backfiller
+	collector  func() *services.DataCollectionService
+	config     func() config.SchedulerConfig
+}
+
+// NewBackfillHandler 创建新的历史数据补齐处理器，ctx取消时中止正在执行的补齐任务，
+// config返回当前的调度配置，用于确定默认的时间粒度和检查天数
+func NewBackfillHandler(ctx context.Context, collector func() *services.DataCollectionService, config func() config.SchedulerConfig) *BackfillHandler {
+	return &BackfillHandler{
+		ctx:        ctx,
+		backfiller: backfill.NewBackfiller(models.NewLongShortRatioRepository(database.GetDB())),
+		collector:  collector,
+		config:     config,
+	}
+}
+
+// Start 在后台开始补齐任务
+func (h *BackfillHandler) Start(c *gin.Context) {
+	cfg := h.config()
+	collector := h.collector()
+
+	opts := backfill.Options{
+		Exchanges: collector.Exchanges(),
+		Symbols:   splitQuery(c.Query("symbol")),
+		Metrics:   splitQuery(c.Query("metric")),
+		Periods:   splitQuery(c.Query("period")),
+		DryRun:    c.Query("dry_run") == "true",
+	}
+	if names := splitQuery(c.Query("exchange")); len(names) > 0 {
+		exchanges, err := services.SelectExchanges(opts.Exchanges, names)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+		opts.Exchanges = exchanges
+	}
+	if len(opts.Symbols) == 0 {
+		opts.Symbols = collector.Symbols()
+	}
+	if len(opts.Periods) == 0 {
+		opts.Periods = cfg.RatioPeriods
+	}
+	for _, metric := range opts.Metrics {
+		if !services.IsValidMetric(metric) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "不支持的指标类型: " + metric,
+			})
+			return
+		}
+	}
+	for _, period := range opts.Periods {
+		if _, ok := services.PeriodDuration(period); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "不支持的时间粒度: " + period,
+			})
+			return
+		}
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(cfg.RetentionDays)))
+	if err != nil || days <= 0 {
+		days = cfg.RetentionDays
+	}
+	opts.Since = time.Now().AddDate(0, 0, -days)
+
+	if err := h.backfiller.Start(h.ctx, opts); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, backfill.ErrRunning) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "补齐任务已开始",
+		"data":    h.backfiller.Progress(),
+	})
+}
+
+// GetProgress 获取最近一次补齐任务的进度
+func (h *BackfillHandler) GetProgress(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.backfiller.Progress(),
+	})
+}
+
+// splitQuery 拆分逗号分隔的查询参数
+func splitQuery(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"CurrencyMonitor/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExchangeStatusHandler 交易所接口状态处理器
+type ExchangeStatusHandler struct{}
+
+// NewExchangeStatusHandler 创建新的交易所接口状态处理器
+func NewExchangeStatusHandler() *ExchangeStatusHandler {
+	return &ExchangeStatusHandler{}
+}
+
+// GetStatus 获取各交易所接口的熔断器和限流器状态，state参数只返回指定状态的熔断器
+func (h *ExchangeStatusHandler) GetStatus(c *gin.Context) {
+	state := c.Query("state")
+	switch state {
+	case "", services.BreakerClosed, services.BreakerOpen, services.BreakerHalfOpen:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "不支持的熔断器状态: " + state,
+		})
+		return
+	}
+
+	breakers := make([]map[string]interface{}, 0)
+	for _, breaker := range services.CircuitBreakerStatus() {
+		if state == "" || breaker["state"] == state {
+			breakers = append(breakers, breaker)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"circuit_breakers": breakers,
+			"rate_limits":      services.RateLimitStatus(),
+		},
+	})
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"CurrencyMonitor/database"
+	"CurrencyMonitor/export"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportHandler 数据导出处理器
+type ExportHandler struct{}
+
+// NewExportHandler 创建新的数据导出处理器
+func NewExportHandler() *ExportHandler {
+	return &ExportHandler{}
+}
+
+// Export 流式导出已保存的数据。默认按交易所/交易对/日期分区打包为zip，
+// partition=false且只有一种格式时直接返回单个CSV或Parquet文件
+func (h *ExportHandler) Export(c *gin.Context) {
+	ctx := c.Request.Context()
+	opts := export.Options{
+		Dataset:   c.DefaultQuery("dataset", "long_short_ratios"),
+		Formats:   splitQuery(c.DefaultQuery("format", export.FormatCSV)),
+		Partition: c.DefaultQuery("partition", "true") != "false",
+		Filter: export.Filter{
+			Exchanges: splitQuery(c.Query("exchange")),
+			Symbols:   splitQuery(c.Query("symbol")),
+			End:       time.Now(),
+		},
+	}
+
+	var err error
+	if end := c.Query("end"); end != "" {
+		opts.Filter.End, err = export.ParseTime(end)
+	}
+	if err == nil {
+		opts.Filter.Start, err = exportStart(c, opts.Filter.End)
+	}
+	if err == nil {
+		err = export.Validate(opts)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// 开始写出数据后无法再返回错误响应，出错时只记录日志并中断响应
+	filename := fmt.Sprintf("%s_%s_%s", opts.Dataset,
+		opts.Filter.Start.UTC().Format("20060102T150405"), opts.Filter.End.UTC().Format("20060102T150405"))
+	if !opts.Partition && len(opts.Formats) == 1 {
+		format := opts.Formats[0]
+		contentType := "text/csv; charset=utf-8"
+		if format == export.FormatParquet {
+			contentType = "application/vnd.apache.parquet"
+		}
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.%s", filename, format))
+		if _, err := export.Export(ctx, database.GetDB(), opts, export.StreamSink{W: c.Writer}); err != nil {
+			log.Printf("导出数据失败: %v", err)
+		}
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.zip", filename))
+	sink := export.NewZipSink(c.Writer)
+	if _, err := export.Export(ctx, database.GetDB(), opts, sink); err != nil {
+		log.Printf("导出数据失败: %v", err)
+		return
+	}
+	if err := sink.Close(); err != nil {
+		log.Printf("导出数据失败: %v", err)
+	}
+}
+
+// exportStart 解析开始时间，未指定start时取结束时间之前days天（默认7天）
+func exportStart(c *gin.Context, end time.Time) (time.Time, error) {
+	if start := c.Query("start"); start != "" {
+		return export.ParseTime(start)
+	}
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil || days <= 0 {
+		days = 7
+	}
+	return end.AddDate(0, 0, -days), nil
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"CurrencyMonitor/database"
+	"CurrencyMonitor/models"
+	"CurrencyMonitor/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FundingHandler 资金费率处理器
+type FundingHandler struct {
+	repo      *models.FundingRateRepository
+	ratioRepo *models.LongShortRatioRepository
+	symbols   *symbolSource
+}
+
+// NewFundingHandler 创建新的资金费率处理器
+func NewFundingHandler(symbols []string) *FundingHandler {
+	return &FundingHandler{
+		repo:      models.NewFundingRateRepository(database.GetDB()),
+		ratioRepo: models.NewLongShortRatioRepository(database.GetDB()),
+		symbols:   newSymbolSource(symbols),
+	}
+}
+
+// GetHistory 获取已结算资金费率历史
+func (h *FundingHandler) GetHistory(c *gin.Context) {
+	exchange := c.Query("exchange")
+	symbol := c.Query("symbol")
+	daysStr := c.DefaultQuery("days", "7")
+
+	if exchange == "" || symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "exchange和symbol参数是必需的",
+		})
+		return
+	}
+
+	days, err := strconv.Atoi(daysStr)
+	if err != nil || days <= 0 {
+		days = 7
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	rates, err := h.repo.GetRecentData(c.Request.Context(), exchange, symbol, since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取资金费率失败",
+		})
+		return
+	}
+
+	var results []gin.H
+	for _, rate := range rates {
+		results = append(results, gin.H{
+			"rate":         rate.Rate,
+			"funding_time": rate.FundingTime,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    results,
+	})
+}
+
+// GetNext 获取各交易所的预测资金费率及结算倒计时
+func (h *FundingHandler) GetNext(c *gin.Context) {
+	ctx := c.Request.Context()
+	symbols := h.symbols.tracked(ctx)
+	if symbol := c.Query("symbol"); symbol != "" {
+		symbols = []string{symbol}
+	}
+
+	now := time.Now()
+	var results []gin.H
+	for _, symbol := range symbols {
+		for _, exchange := range services.ExchangeNames() {
+			predicted, err := h.repo.GetLatestPredicted(ctx, exchange, symbol)
+			if err != nil {
+				continue
+			}
+
+			countdown := int64(predicted.NextFundingTime.Sub(now).Seconds())
+			if countdown < 0 {
+				countdown = 0
+			}
+
+			results = append(results, gin.H{
+				"exchange":          predicted.Exchange,
+				"symbol":            predicted.Symbol,
+				"rate":              predicted.Rate,
+				"next_funding_time": predicted.NextFundingTime,
+				"countdown_seconds": countdown,
+				"timestamp":         predicted.Timestamp,
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    results,
+	})
+}
+
+// GetComparison 获取与多空比按时间戳对齐的资金费率数据
+func (h *FundingHandler) GetComparison(c *gin.Context) {
+	ctx := c.Request.Context()
+	symbol := c.Query("symbol")
+	daysStr := c.DefaultQuery("days", "7")
+	metric, ok := parseMetric(c)
+	if !ok {
+		return
+	}
+	period, ok := parsePeriod(c)
+	if !ok {
+		return
+	}
+
+	if symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "symbol参数是必需的",
+		})
+		return
+	}
+
+	days, err := strconv.Atoi(daysStr)
+	if err != nil || days <= 0 {
+		days = 7
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+
+	var result = gin.H{
+		"symbol": symbol,
+		"metric": metric,
+		"period": period,
+		"data":   gin.H{},
+	}
+
+	for _, exchange := range services.ExchangeNames() {
+		ratios, err := h.ratioRepo.GetRecentData(ctx, exchange, symbol, metric, period, since)
+		if err != nil {
+			continue
+		}
+
+		// 多取一个结算周期，保证第一个多空比数据点也有对应的费率
+		rates, err := h.repo.GetRecentData(ctx, exchange, symbol, since.Add(-24*time.Hour))
+		if err != nil {
+			continue
+		}
+
+		result["data"].(gin.H)[exchange] = alignFundingRates(ratios, rates)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// alignFundingRates 将资金费率对齐到多空比时间戳，每个点取该时间之前最近一次结算的费率
+func alignFundingRates(ratios []models.LongShortRatio, rates []models.FundingRate) []gin.H {
+	var points []gin.H
+	j := -1
+	for _, ratio := range ratios {
+		for j+1 < len(rates) && !rates[j+1].FundingTime.After(ratio.Timestamp) {
+			j++
+		}
+
+		point := gin.H{
+			"ratio":        ratio.Ratio,
+			"timestamp":    ratio.Timestamp,
+			"funding_rate": nil,
+		}
+		if j >= 0 {
+			point["funding_rate"] = rates[j].Rate
+			point["funding_time"] = rates[j].FundingTime
+		}
+		points = append(points, point)
+	}
+	return points
+}
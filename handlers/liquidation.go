@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"CurrencyMonitor/database"
+	"CurrencyMonitor/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// liquidationBuckets 支持的强平聚合时间桶
+var liquidationBuckets = map[string]time.Duration{
+	"5m": 5 * time.Minute,
+	"1h": time.Hour,
+	"4h": 4 * time.Hour,
+}
+
+// LiquidationHandler 强平数据处理器
+type LiquidationHandler struct {
+	repo *models.LiquidationRepository
+}
+
+// NewLiquidationHandler 创建新的强平数据处理器
+func NewLiquidationHandler() *LiquidationHandler {
+	return &LiquidationHandler{
+		repo: models.NewLiquidationRepository(database.GetDB()),
+	}
+}
+
+// GetRecent 获取最近的强平事件
+func (h *LiquidationHandler) GetRecent(c *gin.Context) {
+	exchange := c.Query("exchange")
+	symbol := c.Query("symbol")
+	limitStr := c.DefaultQuery("limit", "100")
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	liqs, err := h.repo.GetRecent(c.Request.Context(), exchange, symbol, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取强平数据失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    liqs,
+	})
+}
+
+// GetAggregate 获取按时间桶聚合的多空强平名义价值
+func (h *LiquidationHandler) GetAggregate(c *gin.Context) {
+	symbol := c.Query("symbol")
+	exchange := c.Query("exchange")
+	bucketStr := c.DefaultQuery("bucket", "1h")
+	hoursStr := c.DefaultQuery("hours", "24")
+
+	if symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "symbol参数是必需的",
+		})
+		return
+	}
+
+	bucket, ok := liquidationBuckets[bucketStr]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "不支持的聚合粒度，支持: 5m, 1h, 4h",
+		})
+		return
+	}
+
+	hours, err := strconv.Atoi(hoursStr)
+	if err != nil || hours <= 0 {
+		hours = 24
+	}
+
+	since := time.Now().Add(-time.Duration(hours) * time.Hour).Truncate(bucket)
+	buckets, err := h.repo.Aggregate(c.Request.Context(), exchange, symbol, bucket, since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "聚合强平数据失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"symbol":   symbol,
+			"exchange": exchange,
+			"bucket":   bucketStr,
+			"buckets":  buckets,
+		},
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"CurrencyMonitor/database"
 	"CurrencyMonitor/models"
 	"CurrencyMonitor/services"
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -14,41 +16,159 @@ import (
 
 // LongShortRatioHandler 多空比处理器
 type LongShortRatioHandler struct {
-	repo              *models.LongShortRatioRepository
-	dataCollectionSvc *services.DataCollectionService
+	repo        *models.LongShortRatioRepository
+	rollupRepo  *models.RollupRepository
+	fundingRepo *models.FundingRateRepository
+	klineRepo   *models.KlineRepository
+	symbols     *symbolSource
+	collector   func() *services.DataCollectionService
 }
 
 // NewLongShortRatioHandler 创建新的多空比处理器
-func NewLongShortRatioHandler() *LongShortRatioHandler {
+func NewLongShortRatioHandler(collector func() *services.DataCollectionService, symbols []string) *LongShortRatioHandler {
 	repo := models.NewLongShortRatioRepository(database.GetDB())
-	dataCollectionSvc := services.NewDataCollectionService([]string{"BTCUSDT", "ETHUSDT"})
 
 	return &LongShortRatioHandler{
-		repo:              repo,
-		dataCollectionSvc: dataCollectionSvc,
+		repo:        repo,
+		rollupRepo:  models.NewRollupRepository(database.GetDB()),
+		fundingRepo: models.NewFundingRateRepository(database.GetDB()),
+		klineRepo:   models.NewKlineRepository(database.GetDB()),
+		symbols:     newSymbolSource(symbols),
+		collector:   collector,
 	}
 }
 
+// parseMetric 解析并校验metric参数，默认为全市场账户多空比
+func parseMetric(c *gin.Context) (string, bool) {
+	metric := c.DefaultQuery("metric", services.MetricGlobalAccount)
+	if !services.IsValidMetric(metric) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "不支持的指标类型，支持: global_account, top_account, top_position, taker_volume",
+		})
+		return "", false
+	}
+	return metric, true
+}
+
+// parsePeriod 解析并校验period参数，默认为5分钟
+func parsePeriod(c *gin.Context) (string, bool) {
+	period := c.DefaultQuery("period", models.DefaultRatioPeriod)
+	if _, ok := services.PeriodDuration(period); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "不支持的时间粒度，支持: 5m, 15m, 30m, 1h, 2h, 4h, 1d",
+		})
+		return "", false
+	}
+	return period, true
+}
+
+// parseMaxPoints 解析max_points参数，未指定时为0，表示按请求的时间粒度返回全部数据
+func parseMaxPoints(c *gin.Context) (int, bool) {
+	maxPoints, err := strconv.Atoi(c.DefaultQuery("max_points", "0"))
+	if err != nil || maxPoints < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "max_points必须是非负整数",
+		})
+		return 0, false
+	}
+	return maxPoints, true
+}
+
+// chooseResolution 选择数据点数量不超过maxPoints的最细粒度：请求的时间粒度满足时直接读取原始数据，
+// 否则依次尝试更粗的汇总粒度，都不满足时使用最粗的汇总粒度。返回的bool表示是否读取汇总表
+func chooseResolution(period string, window time.Duration, maxPoints int) (string, bool) {
+	d, _ := services.PeriodDuration(period)
+	if maxPoints <= 0 || int(window/d) <= maxPoints {
+		return period, false
+	}
+
+	resolution, rollup := period, false
+	for _, candidate := range models.RollupResolutions {
+		rd, _ := services.PeriodDuration(candidate)
+		if rd <= d {
+			continue
+		}
+		resolution, rollup = candidate, true
+		if int(window/rd) <= maxPoints {
+			break
+		}
+	}
+	return resolution, rollup
+}
+
+// ratioSeries 读取since之后的多空比序列，按maxPoints选择原始数据或汇总数据，返回使用的粒度和数据点
+func (h *LongShortRatioHandler) ratioSeries(ctx context.Context, exchange, symbol, metric, period string, since time.Time, maxPoints int) (string, []gin.H, error) {
+	resolution, rollup := chooseResolution(period, time.Since(since), maxPoints)
+
+	var points []gin.H
+	if !rollup {
+		ratios, err := h.repo.GetRecentData(ctx, exchange, symbol, metric, period, since)
+		if err != nil {
+			return resolution, nil, err
+		}
+		for _, ratio := range ratios {
+			points = append(points, gin.H{
+				"ratio":     ratio.Ratio,
+				"timestamp": ratio.Timestamp,
+			})
+		}
+		return resolution, points, nil
+	}
+
+	rollups, err := h.rollupRepo.GetRecentData(ctx, exchange, symbol, metric, resolution, since)
+	if err != nil {
+		return resolution, nil, err
+	}
+	// ratio取区间收盘值，与原始数据的含义保持一致
+	for _, rollup := range rollups {
+		points = append(points, gin.H{
+			"ratio":     rollup.Close,
+			"open":      rollup.Open,
+			"high":      rollup.High,
+			"low":       rollup.Low,
+			"close":     rollup.Close,
+			"mean":      rollup.Mean,
+			"count":     rollup.Count,
+			"timestamp": rollup.BucketStart,
+		})
+	}
+	return resolution, points, nil
+}
+
 // GetCurrentRatios 获取当前多空比数据
 func (h *LongShortRatioHandler) GetCurrentRatios(c *gin.Context) {
+	ctx := c.Request.Context()
 	exchange := c.Query("exchange")
 	symbol := c.Query("symbol")
+	metric, ok := parseMetric(c)
+	if !ok {
+		return
+	}
+	period, ok := parsePeriod(c)
+	if !ok {
+		return
+	}
 
 	if exchange == "" || symbol == "" {
 		// 获取所有交易所和交易对的最新数据
-		exchanges := []string{"binance", "okx"}
-		symbols := []string{"BTCUSDT", "ETHUSDT"}
+		exchanges := services.ExchangeNames()
+		symbols := h.symbols.tracked(ctx)
 
 		var results []gin.H
 		for _, ex := range exchanges {
 			for _, sym := range symbols {
-				ratio, err := h.repo.GetLatest(ex, sym)
+				ratio, err := h.repo.GetLatest(ctx, ex, sym, metric, period)
 				if err != nil {
 					continue
 				}
 				results = append(results, gin.H{
 					"exchange":  ratio.Exchange,
 					"symbol":    ratio.Symbol,
+					"metric":    ratio.Metric,
+					"period":    ratio.Period,
 					"ratio":     ratio.Ratio,
 					"timestamp": ratio.Timestamp,
 				})
@@ -63,7 +183,7 @@ func (h *LongShortRatioHandler) GetCurrentRatios(c *gin.Context) {
 	}
 
 	// 获取指定交易所和交易对的最新数据
-	ratio, err := h.repo.GetLatest(exchange, symbol)
+	ratio, err := h.repo.GetLatest(ctx, exchange, symbol, metric, period)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"success": false,
@@ -77,6 +197,8 @@ func (h *LongShortRatioHandler) GetCurrentRatios(c *gin.Context) {
 		"data": gin.H{
 			"exchange":  ratio.Exchange,
 			"symbol":    ratio.Symbol,
+			"metric":    ratio.Metric,
+			"period":    ratio.Period,
 			"ratio":     ratio.Ratio,
 			"timestamp": ratio.Timestamp,
 		},
@@ -88,6 +210,18 @@ func (h *LongShortRatioHandler) GetHistoricalData(c *gin.Context) {
 	exchange := c.Query("exchange")
 	symbol := c.Query("symbol")
 	daysStr := c.DefaultQuery("days", "7")
+	metric, ok := parseMetric(c)
+	if !ok {
+		return
+	}
+	period, ok := parsePeriod(c)
+	if !ok {
+		return
+	}
+	maxPoints, ok := parseMaxPoints(c)
+	if !ok {
+		return
+	}
 
 	if exchange == "" || symbol == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -103,7 +237,7 @@ func (h *LongShortRatioHandler) GetHistoricalData(c *gin.Context) {
 	}
 
 	since := time.Now().AddDate(0, 0, -days)
-	ratios, err := h.repo.GetRecentData(exchange, symbol, since)
+	resolution, results, err := h.ratioSeries(c.Request.Context(), exchange, symbol, metric, period, since, maxPoints)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -112,45 +246,35 @@ func (h *LongShortRatioHandler) GetHistoricalData(c *gin.Context) {
 		return
 	}
 
-	var results []gin.H
-	for _, ratio := range ratios {
-		results = append(results, gin.H{
-			"ratio":     ratio.Ratio,
-			"timestamp": ratio.Timestamp,
-		})
-	}
-
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    results,
+		"success":    true,
+		"resolution": resolution,
+		"data":       results,
 	})
 }
 
 // RefreshData 刷新多空比数据
 func (h *LongShortRatioHandler) RefreshData(c *gin.Context) {
-	// 收集最新数据
-	data, err := h.dataCollectionSvc.CollectAllData()
-	if err != nil {
+	ctx := c.Request.Context()
+	// 收集跟踪交易对的最新数据，部分交易对失败时仍保存成功的数据，客户端断开时取消未完成的请求
+	data, err := h.collector().CollectAllData(ctx)
+	var failed services.CollectErrors
+	if err != nil && !errors.As(err, &failed) {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"message": "收集数据失败",
+			"message": "收集数据失败: " + err.Error(),
 		})
 		return
 	}
 
-	// 保存到数据库
-	var savedCount int
-	for _, item := range data {
-		ratio := &models.LongShortRatio{
-			Exchange:  item.Exchange,
-			Symbol:    item.Symbol,
-			Ratio:     item.Ratio,
-			Timestamp: item.Timestamp,
-		}
-
-		if err := h.repo.CreateOrUpdate(ratio); err == nil {
-			savedCount++
-		}
+	// 在一个事务内批量保存到数据库
+	ratios := services.RatioModels(data)
+	if err := h.repo.UpsertMany(ctx, ratios); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "保存数据失败: " + err.Error(),
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -158,34 +282,56 @@ func (h *LongShortRatioHandler) RefreshData(c *gin.Context) {
 		"message": "数据刷新完成",
 		"data": gin.H{
 			"collected": len(data),
-			"saved":     savedCount,
+			"saved":     len(ratios),
+			"failed":    len(failed),
+			"errors":    collectErrorMessages(failed),
 		},
 	})
 }
 
+// collectErrorMessages 将采集失败项转换为错误信息列表
+func collectErrorMessages(failed services.CollectErrors) []string {
+	messages := make([]string, 0, len(failed))
+	for _, item := range failed {
+		messages = append(messages, item.Error())
+	}
+	return messages
+}
+
 // GetDashboardData 获取仪表板数据
 func (h *LongShortRatioHandler) GetDashboardData(c *gin.Context) {
-	exchanges := []string{"binance", "okx"}
-	symbols := []string{"BTCUSDT", "ETHUSDT"}
+	ctx := c.Request.Context()
+	metric, ok := parseMetric(c)
+	if !ok {
+		return
+	}
+	period, ok := parsePeriod(c)
+	if !ok {
+		return
+	}
+	exchanges := services.ExchangeNames()
+	symbols := h.symbols.tracked(ctx)
 
 	var dashboardData []gin.H
 
 	for _, symbol := range symbols {
 		symbolData := gin.H{
 			"symbol": symbol,
+			"metric": metric,
+			"period": period,
 			"data":   []gin.H{},
 		}
 
 		for _, exchange := range exchanges {
 			// 获取最新数据
-			latest, err := h.repo.GetLatest(exchange, symbol)
+			latest, err := h.repo.GetLatest(ctx, exchange, symbol, metric, period)
 			if err != nil {
 				continue
 			}
 
 			// 获取24小时前的数据进行对比
 			since24h := time.Now().Add(-24 * time.Hour)
-			historical, err := h.repo.GetRecentData(exchange, symbol, since24h)
+			historical, err := h.repo.GetRecentData(ctx, exchange, symbol, metric, period, since24h)
 			if err != nil {
 				continue
 			}
@@ -202,6 +348,12 @@ func (h *LongShortRatioHandler) GetDashboardData(c *gin.Context) {
 				"timestamp": latest.Timestamp,
 			}
 
+			// 附带预测资金费率
+			if predicted, err := h.fundingRepo.GetLatestPredicted(ctx, exchange, symbol); err == nil {
+				exchangeData["funding_rate"] = predicted.Rate
+				exchangeData["next_funding_time"] = predicted.NextFundingTime
+			}
+
 			symbolData["data"] = append(symbolData["data"].([]gin.H), exchangeData)
 		}
 
@@ -218,6 +370,18 @@ func (h *LongShortRatioHandler) GetDashboardData(c *gin.Context) {
 func (h *LongShortRatioHandler) GetComparisonData(c *gin.Context) {
 	symbol := c.Query("symbol")
 	daysStr := c.DefaultQuery("days", "7")
+	metric, ok := parseMetric(c)
+	if !ok {
+		return
+	}
+	period, ok := parsePeriod(c)
+	if !ok {
+		return
+	}
+	maxPoints, ok := parseMaxPoints(c)
+	if !ok {
+		return
+	}
 
 	if symbol == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -233,27 +397,22 @@ func (h *LongShortRatioHandler) GetComparisonData(c *gin.Context) {
 	}
 
 	since := time.Now().AddDate(0, 0, -days)
-	exchanges := []string{"binance", "okx"}
+	exchanges := services.ExchangeNames()
 
 	var result = gin.H{
 		"symbol": symbol,
+		"metric": metric,
+		"period": period,
 		"data":   gin.H{},
 	}
 
 	for _, exchange := range exchanges {
-		ratios, err := h.repo.GetRecentData(exchange, symbol, since)
+		resolution, exchangeData, err := h.ratioSeries(c.Request.Context(), exchange, symbol, metric, period, since, maxPoints)
 		if err != nil {
 			continue
 		}
 
-		var exchangeData []gin.H
-		for _, ratio := range ratios {
-			exchangeData = append(exchangeData, gin.H{
-				"ratio":     ratio.Ratio,
-				"timestamp": ratio.Timestamp,
-			})
-		}
-
+		result["resolution"] = resolution
 		result["data"].(gin.H)[exchange] = exchangeData
 	}
 
@@ -263,10 +422,22 @@ func (h *LongShortRatioHandler) GetComparisonData(c *gin.Context) {
 	})
 }
 
-// GetChartData 获取图表数据（支持时间粒度）
+// chartPoints 图表返回的数据点数量
+const chartPoints = 30
+
+// GetChartData 获取图表数据（支持时间粒度）。数据从数据库读取，只有数据存在缺口时才向交易所请求补齐
 func (h *LongShortRatioHandler) GetChartData(c *gin.Context) {
+	ctx := c.Request.Context()
 	symbol := c.Query("symbol")
-	period := c.DefaultQuery("period", "5m")
+	includePrice := c.Query("include") == "price"
+	metric, ok := parseMetric(c)
+	if !ok {
+		return
+	}
+	period, ok := parsePeriod(c)
+	if !ok {
+		return
+	}
 	if symbol == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -275,74 +446,181 @@ func (h *LongShortRatioHandler) GetChartData(c *gin.Context) {
 		return
 	}
 
-	// 固定limit为30个点
-	limit := 30
+	result := gin.H{
+		"symbol": symbol,
+		"period": period,
+		"metric": metric,
+	}
+
+	for _, exchange := range h.collector().Exchanges() {
+		// 客户端断开后不再请求剩余的交易所
+		if ctx.Err() != nil {
+			return
+		}
+		result[exchange.Name()] = gin.H{}
+		if !services.SupportsMetric(exchange, metric) {
+			continue
+		}
+
+		ratios, err := h.chartRatios(ctx, exchange, symbol, metric, period)
+		if err != nil {
+			fmt.Printf("获取%s数据失败: %v\n", exchange.Name(), err)
+			continue
+		}
+		if len(ratios) == 0 {
+			continue
+		}
+
+		points := make([]gin.H, 0, len(ratios))
+		for _, ratio := range ratios {
+			points = append(points, gin.H{
+				"ratio":     ratio.Ratio,
+				"timestamp": ratio.Timestamp,
+			})
+		}
 
-	// 验证时间粒度参数
-	validPeriods := map[string]bool{
-		"5m":  true,
-		"15m": true,
-		"30m": true,
-		"1h":  true,
-		"2h":  true,
-		"4h":  true,
-		"1d":  true,
+		if includePrice {
+			h.attachPrices(ctx, exchange, symbol, period, ratios, points)
+		}
+		result[exchange.Name()] = points
 	}
-	if !validPeriods[period] {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "不支持的时间粒度，支持: 5m, 15m, 30m, 1h, 2h, 4h, 1d",
-		})
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// chartRatios 从数据库读取最近chartPoints个周期的多空比，存在缺口时从交易所获取并保存后重新读取
+func (h *LongShortRatioHandler) chartRatios(ctx context.Context, exchange services.ExchangeService, symbol, metric, period string) ([]models.LongShortRatio, error) {
+	duration, _ := services.PeriodDuration(period)
+	since := time.Now().Add(-chartPoints * duration)
+
+	ratios, err := h.repo.GetRecentData(ctx, exchange.Name(), symbol, metric, period, since)
+	if err != nil {
+		return nil, err
+	}
+
+	if hasGaps(ratios, since, duration) {
+		data, err := services.GetMetricHistory(ctx, exchange, symbol, metric, period, chartPoints)
+		if err != nil {
+			// 交易所请求失败时仍返回数据库中已有的数据
+			fmt.Printf("补齐%s %s %s数据失败: %v\n", exchange.Name(), symbol, period, err)
+		} else {
+			if err := h.repo.UpsertMany(ctx, services.RatioModels(data)); err != nil {
+				return nil, err
+			}
+			if ratios, err = h.repo.GetRecentData(ctx, exchange.Name(), symbol, metric, period, since); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(ratios) > chartPoints {
+		ratios = ratios[len(ratios)-chartPoints:]
+	}
+	return ratios, nil
+}
+
+// hasGaps 判断从since开始按duration间隔的数据是否有缺口。最新一个周期的数据交易所可能还没有发布，不算作缺口
+func hasGaps(ratios []models.LongShortRatio, since time.Time, duration time.Duration) bool {
+	if len(ratios) == 0 {
+		return true
+	}
+	if ratios[0].Timestamp.Sub(since) > duration {
+		return true
+	}
+	for i := 1; i < len(ratios); i++ {
+		if ratios[i].Timestamp.Sub(ratios[i-1].Timestamp) > duration {
+			return true
+		}
+	}
+	return time.Since(ratios[len(ratios)-1].Timestamp) > 2*duration
+}
+
+// attachPrices 按多空比时间戳对齐K线，写入每个数据点的price字段。K线从数据库读取，
+// 只有数据点缺少对应的K线时才从交易所获取缺失部分并保存
+func (h *LongShortRatioHandler) attachPrices(ctx context.Context, exchange services.ExchangeService, symbol, period string, ratios []models.LongShortRatio, points []gin.H) {
+	duration, ok := services.PeriodDuration(period)
+	if !ok || len(ratios) == 0 {
 		return
 	}
 
-	// 获取Binance数据
-	binanceService := services.NewBinanceService()
-	binanceData, err := binanceService.GetLongShortRatioHistory(symbol, period, limit)
+	// 多空比时间戳所在的K线
+	start := ratios[0].Timestamp.Truncate(duration)
+	end := ratios[len(ratios)-1].Timestamp.Truncate(duration)
+	stored, err := h.klineRepo.GetRange(ctx, exchange.Name(), symbol, period, start, end)
 	if err != nil {
-		fmt.Printf("获取Binance数据失败: %v\n", err)
+		fmt.Printf("读取%s K线失败: %v\n", exchange.Name(), err)
+	}
+	byOpenTime := make(map[int64]models.Kline, len(stored))
+	for _, kline := range stored {
+		byOpenTime[kline.OpenTime.Unix()] = kline
 	}
 
-	// 获取OKX数据
-	okxService := services.NewOKXService()
-	okxData, err := okxService.GetLongShortRatioHistory(symbol, period, limit)
-	if err != nil {
-		fmt.Printf("获取OKX数据失败: %v\n", err)
+	if missing, ok := earliestMissingKline(ratios, byOpenTime, duration); ok {
+		h.fetchKlines(ctx, exchange, symbol, period, missing, byOpenTime)
 	}
 
-	result := gin.H{
-		"symbol":  symbol,
-		"period":  period,
-		"binance": gin.H{},
-		"okx":     gin.H{},
-	}
-
-	// 处理Binance数据
-	if binanceData != nil {
-		var binancePoints []gin.H
-		for _, item := range binanceData {
-			binancePoints = append(binancePoints, gin.H{
-				"ratio":     item.Ratio,
-				"timestamp": item.Timestamp,
-			})
+	for i, ratio := range ratios {
+		points[i]["price"] = nil
+		if kline, ok := byOpenTime[ratio.Timestamp.Truncate(duration).Unix()]; ok {
+			points[i]["price"] = gin.H{
+				"open":   kline.Open,
+				"high":   kline.High,
+				"low":    kline.Low,
+				"close":  kline.Close,
+				"volume": kline.Volume,
+			}
 		}
-		result["binance"] = binancePoints
 	}
+}
 
-	// 处理OKX数据
-	if okxData != nil {
-		var okxPoints []gin.H
-		for _, item := range okxData {
-			okxPoints = append(okxPoints, gin.H{
-				"ratio":     item.Ratio,
-				"timestamp": item.Timestamp,
-			})
+// earliestMissingKline 获取数据库中缺少K线的最早一个多空比数据点所在K线的开盘时间
+func earliestMissingKline(ratios []models.LongShortRatio, byOpenTime map[int64]models.Kline, duration time.Duration) (time.Time, bool) {
+	for _, ratio := range ratios {
+		openTime := ratio.Timestamp.Truncate(duration)
+		if _, ok := byOpenTime[openTime.Unix()]; !ok {
+			return openTime, true
 		}
-		result["okx"] = okxPoints
 	}
+	return time.Time{}, false
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    result,
-	})
+// fetchKlines 从交易所获取since之后的K线，保存到数据库并加入byOpenTime
+func (h *LongShortRatioHandler) fetchKlines(ctx context.Context, exchange services.ExchangeService, symbol, period string, since time.Time, byOpenTime map[int64]models.Kline) {
+	klineService, ok := exchange.(services.KlineService)
+	if !ok {
+		return
+	}
+	duration, _ := services.PeriodDuration(period)
+
+	// 交易所接口只能获取最近的K线，按缺失的最早K线计算数量，多取一根保证最新的数据点也能对齐
+	limit := int(time.Since(since)/duration) + 2
+	if limit > chartPoints+1 {
+		limit = chartPoints + 1
+	}
+	klines, err := klineService.GetKlines(ctx, symbol, period, limit)
+	if err != nil {
+		fmt.Printf("获取%s K线失败: %v\n", exchange.Name(), err)
+		return
+	}
+
+	for _, item := range klines {
+		kline := models.Kline{
+			Exchange: item.Exchange,
+			Symbol:   item.Symbol,
+			Period:   item.Period,
+			OpenTime: item.OpenTime,
+			Open:     item.Open,
+			High:     item.High,
+			Low:      item.Low,
+			Close:    item.Close,
+			Volume:   item.Volume,
+		}
+		if err := h.klineRepo.CreateOrUpdate(ctx, &kline); err != nil {
+			fmt.Printf("保存%s K线失败: %v\n", exchange.Name(), err)
+		}
+		byOpenTime[kline.OpenTime.Unix()] = kline
+	}
 }
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChooseResolution(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		name       string
+		period     string
+		window     time.Duration
+		maxPoints  int
+		resolution string
+		rollup     bool
+	}{
+		{"未限制数据点", "5m", 30 * day, 0, "5m", false},
+		{"原始数据满足", "5m", day, 288, "5m", false},
+		{"使用最细的满足条件的汇总粒度", "5m", 7 * day, 500, "1h", true},
+		{"跳过不满足的汇总粒度", "5m", 30 * day, 200, "4h", true},
+		{"都不满足时使用最粗的汇总粒度", "5m", 365 * day, 100, "1d", true},
+		{"跳过不比请求粒度粗的汇总粒度", "4h", 30 * day, 100, "1d", true},
+		{"没有更粗的汇总粒度", "1d", 365 * day, 10, "1d", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolution, rollup := chooseResolution(tt.period, tt.window, tt.maxPoints)
+			if resolution != tt.resolution || rollup != tt.rollup {
+				t.Errorf("chooseResolution(%s, %v, %d) = %s %v, 期望 %s %v", tt.period, tt.window, tt.maxPoints, resolution, rollup, tt.resolution, tt.rollup)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"CurrencyMonitor/database"
+	"CurrencyMonitor/models"
+	"CurrencyMonitor/services"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenInterestHandler 持仓量处理器
+type OpenInterestHandler struct {
+	repo      *models.OpenInterestRepository
+	symbols   *symbolSource
+	collector func() *services.DataCollectionService
+}
+
+// NewOpenInterestHandler 创建新的持仓量处理器
+func NewOpenInterestHandler(collector func() *services.DataCollectionService, symbols []string) *OpenInterestHandler {
+	repo := models.NewOpenInterestRepository(database.GetDB())
+
+	return &OpenInterestHandler{
+		repo:      repo,
+		symbols:   newSymbolSource(symbols),
+		collector: collector,
+	}
+}
+
+// GetCurrent 获取当前持仓量数据
+func (h *OpenInterestHandler) GetCurrent(c *gin.Context) {
+	ctx := c.Request.Context()
+	exchange := c.Query("exchange")
+	symbol := c.Query("symbol")
+
+	if exchange == "" || symbol == "" {
+		// 获取所有交易所和交易对的最新数据
+		var results []gin.H
+		for _, ex := range services.ExchangeNames() {
+			for _, sym := range h.symbols.tracked(ctx) {
+				oi, err := h.repo.GetLatest(ctx, ex, sym)
+				if err != nil {
+					continue
+				}
+				results = append(results, gin.H{
+					"exchange":      oi.Exchange,
+					"symbol":        oi.Symbol,
+					"open_interest": oi.OpenInterest,
+					"notional":      oi.Notional,
+					"timestamp":     oi.Timestamp,
+				})
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    results,
+		})
+		return
+	}
+
+	// 获取指定交易所和交易对的最新数据
+	oi, err := h.repo.GetLatest(ctx, exchange, symbol)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "未找到数据",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"exchange":      oi.Exchange,
+			"symbol":        oi.Symbol,
+			"open_interest": oi.OpenInterest,
+			"notional":      oi.Notional,
+			"timestamp":     oi.Timestamp,
+		},
+	})
+}
+
+// GetHistorical 获取历史持仓量数据
+func (h *OpenInterestHandler) GetHistorical(c *gin.Context) {
+	exchange := c.Query("exchange")
+	symbol := c.Query("symbol")
+	daysStr := c.DefaultQuery("days", "7")
+
+	if exchange == "" || symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "exchange和symbol参数是必需的",
+		})
+		return
+	}
+
+	days, err := strconv.Atoi(daysStr)
+	if err != nil || days <= 0 {
+		days = 7
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	items, err := h.repo.GetRecentData(c.Request.Context(), exchange, symbol, since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取历史数据失败",
+		})
+		return
+	}
+
+	var results []gin.H
+	for _, item := range items {
+		results = append(results, gin.H{
+			"open_interest": item.OpenInterest,
+			"notional":      item.Notional,
+			"timestamp":     item.Timestamp,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    results,
+	})
+}
+
+// GetChartData 获取持仓量图表数据（支持时间粒度）
+func (h *OpenInterestHandler) GetChartData(c *gin.Context) {
+	symbol := c.Query("symbol")
+	period := c.DefaultQuery("period", "5m")
+	if symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "symbol参数是必需的",
+		})
+		return
+	}
+
+	// 固定limit为30个点
+	limit := 30
+
+	validPeriods := map[string]bool{
+		"5m":  true,
+		"15m": true,
+		"30m": true,
+		"1h":  true,
+		"2h":  true,
+		"4h":  true,
+		"1d":  true,
+	}
+	if !validPeriods[period] {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "不支持的时间粒度，支持: 5m, 15m, 30m, 1h, 2h, 4h, 1d",
+		})
+		return
+	}
+
+	result := gin.H{
+		"symbol": symbol,
+		"period": period,
+	}
+
+	ctx := c.Request.Context()
+	for _, exchange := range h.collector().Exchanges() {
+		// 客户端断开后不再请求剩余的交易所
+		if ctx.Err() != nil {
+			return
+		}
+		oiService, ok := exchange.(services.OpenInterestService)
+		if !ok {
+			continue
+		}
+		result[exchange.Name()] = gin.H{}
+
+		data, err := oiService.GetOpenInterestHistory(ctx, symbol, period, limit)
+		if err != nil {
+			fmt.Printf("获取%s持仓量失败: %v\n", exchange.Name(), err)
+			continue
+		}
+
+		var points []gin.H
+		for _, item := range data {
+			points = append(points, gin.H{
+				"open_interest": item.OpenInterest,
+				"notional":      item.Notional,
+				"timestamp":     item.Timestamp,
+			})
+		}
+		result[exchange.Name()] = points
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"CurrencyMonitor/retention"
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RetentionHandler 数据保留策略处理器
+type RetentionHandler struct {
+	cleanup func(ctx context.Context) retention.Report
+	last    func() *retention.Report
+}
+
+// NewRetentionHandler 创建新的数据保留策略处理器，cleanup立即按保留策略清理并返回报告，last返回最近一次清理的报告
+func NewRetentionHandler(cleanup func(ctx context.Context) retention.Report, last func() *retention.Report) *RetentionHandler {
+	return &RetentionHandler{
+		cleanup: cleanup,
+		last:    last,
+	}
+}
+
+// Cleanup 立即按保留策略清理旧数据
+func (h *RetentionHandler) Cleanup(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.cleanup(c.Request.Context()),
+	})
+}
+
+// GetReport 获取最近一次清理的报告
+func (h *RetentionHandler) GetReport(c *gin.Context) {
+	report := h.last()
+	if report == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "还没有执行过数据清理",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"CurrencyMonitor/database"
+	"CurrencyMonitor/models"
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// symbolSource 跟踪交易对来源
+type symbolSource struct {
+	repo     *models.SymbolRepository
+	fallback []string
+}
+
+// newSymbolSource 创建跟踪交易对来源，fallback为配置中始终跟踪的交易对
+func newSymbolSource(fallback []string) *symbolSource {
+	return &symbolSource{
+		repo:     models.NewSymbolRepository(database.GetDB()),
+		fallback: fallback,
+	}
+}
+
+// tracked 获取当前跟踪的交易对，合约目录尚未刷新时使用配置的交易对
+func (s *symbolSource) tracked(ctx context.Context) []string {
+	symbols, err := s.repo.GetTrackedSymbols(ctx)
+	if err != nil || len(symbols) == 0 {
+		return s.fallback
+	}
+	return symbols
+}
+
+// SymbolHandler 合约目录处理器
+type SymbolHandler struct {
+	repo    *models.SymbolRepository
+	symbols *symbolSource
+}
+
+// NewSymbolHandler 创建新的合约目录处理器
+func NewSymbolHandler(symbols []string) *SymbolHandler {
+	return &SymbolHandler{
+		repo:    models.NewSymbolRepository(database.GetDB()),
+		symbols: newSymbolSource(symbols),
+	}
+}
+
+// GetCatalog 获取合约目录
+func (h *SymbolHandler) GetCatalog(c *gin.Context) {
+	exchange := c.Query("exchange")
+	trackedOnly := c.Query("tracked") == "true"
+
+	items, err := h.repo.GetAll(c.Request.Context(), exchange, trackedOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取合约目录失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    items,
+	})
+}
+
+// GetTracked 获取当前跟踪的交易对
+func (h *SymbolHandler) GetTracked(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.symbols.tracked(c.Request.Context()),
+	})
+}
+
+// GetMapping 获取统一格式交易对在各交易所的原生合约代码
+func (h *SymbolHandler) GetMapping(c *gin.Context) {
+	symbol := strings.ToUpper(c.Param("symbol"))
+
+	items, err := h.repo.GetBySymbol(c.Request.Context(), symbol)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取合约信息失败",
+		})
+		return
+	}
+	if len(items) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "未找到交易对",
+		})
+		return
+	}
+
+	mapping := gin.H{}
+	for _, item := range items {
+		mapping[item.Exchange] = gin.H{
+			"exchange_symbol":   item.ExchangeSymbol,
+			"listed":            item.Listed,
+			"open_interest_usd": item.OpenInterestUSD,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"symbol":    symbol,
+			"tracked":   items[0].Tracked,
+			"exchanges": mapping,
+		},
+	})
+}
@@ -0,0 +1,79 @@
+// Package testdb 为各包的测试提供SQLite测试数据库
+package testdb
+
+import (
+	"CurrencyMonitor/config"
+	"CurrencyMonitor/database"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// Open 打开以测试名命名的空内存SQLite数据库，测试结束时关闭
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
+	return open(t, config.DatabaseConfig{
+		Driver:       "sqlite",
+		Path:         fmt.Sprintf("file:%s?mode=memory&cache=shared", name),
+		LogLevel:     "silent",
+		MaxOpenConns: 1,
+		MaxIdleConns: 1,
+	})
+}
+
+// Migrated 打开执行过全部迁移的内存SQLite数据库
+func Migrated(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	db := Open(t)
+	migrate(t, db)
+	return db
+}
+
+// File 打开执行过全部迁移、允许conns个连接同时访问的SQLite数据库文件，用于测试并发写入。
+// 内存数据库只能使用一个连接，写入会被串行化，无法体现并发写入的竞争。
+// 不保留空闲连接，每次访问都重新打开连接，即使只有一个CPU，goroutine也会在打开连接时交错执行
+func File(t testing.TB, conns int) *gorm.DB {
+	t.Helper()
+
+	// 写事务开始时就获取写锁，等待锁最多5秒，避免并发写入直接返回database is locked
+	path := filepath.Join(t.TempDir(), "test.db") + "?_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate"
+	db := open(t, config.DatabaseConfig{
+		Driver:       "sqlite",
+		Path:         path,
+		LogLevel:     "silent",
+		MaxOpenConns: conns,
+	})
+	migrate(t, db)
+	return db
+}
+
+// open 按配置打开数据库，测试结束时关闭
+func open(t testing.TB, cfg config.DatabaseConfig) *gorm.DB {
+	t.Helper()
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// migrate 执行全部迁移
+func migrate(t testing.TB, db *gorm.DB) {
+	t.Helper()
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("迁移测试数据库失败: %v", err)
+	}
+}
@@ -1,48 +1,155 @@
 package main
 
 import (
+	"CurrencyMonitor/config"
 	"CurrencyMonitor/database"
 	"CurrencyMonitor/routes"
 	"CurrencyMonitor/scheduler"
+	"CurrencyMonitor/services"
+	"context"
+	"errors"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 )
 
+// shutdownTimeout 关闭服务时等待进行中的请求返回的最长时间
+const shutdownTimeout = 10 * time.Second
+
 func main() {
+	// 子命令
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			if err := runMigrate(os.Args[2:]); err != nil {
+				log.Fatalf("数据库迁移失败: %v", err)
+			}
+			return
+		case "backfill":
+			if err := runBackfill(os.Args[2:]); err != nil {
+				log.Fatalf("补齐历史数据失败: %v", err)
+			}
+			return
+		case "export":
+			if err := runExport(os.Args[2:]); err != nil {
+				log.Fatalf("导出数据失败: %v", err)
+			}
+			return
+		}
+	}
+
+	// 加载配置：默认值 < 配置文件 < 环境变量 < 命令行参数
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+
 	// 初始化数据库
-	if err := database.InitDatabase(); err != nil {
+	if err := database.InitDatabase(cfg.Database); err != nil {
 		log.Fatalf("数据库初始化失败: %v", err)
 	}
 
+	// 创建启用的交易所服务
+	exchanges, err := services.NewExchanges(cfg.Exchanges)
+	if err != nil {
+		log.Fatalf("创建交易所服务失败: %v", err)
+	}
+
 	// 创建并启动数据调度器
-	dataScheduler := scheduler.NewDataScheduler()
+	dataScheduler := scheduler.NewDataScheduler(cfg, exchanges)
 	if err := dataScheduler.Start(); err != nil {
 		log.Fatalf("启动数据调度器失败: %v", err)
 	}
 
+	// 重新加载配置：使用启动时相同的命令行参数重新读取配置文件和环境变量，与上一次加载的配置比较
+	var reloadMu sync.Mutex
+	applied := cfg
+	reload := func() ([]string, error) {
+		reloadMu.Lock()
+		defer reloadMu.Unlock()
+
+		newCfg, err := config.Load(os.Args[1:])
+		if err != nil {
+			return nil, err
+		}
+		changes, err := dataScheduler.Reload(newCfg)
+		if err != nil {
+			return nil, err
+		}
+
+		// 监听地址和数据库在运行中无法切换
+		if newCfg.Server != applied.Server {
+			changes = append(changes, "server配置变更需要重启后生效")
+		}
+		if newCfg.Database != applied.Database {
+			changes = append(changes, "database配置变更需要重启后生效")
+		}
+		applied = newCfg
+		return changes, nil
+	}
+
 	// 设置路由
-	r := routes.SetupRoutes()
+	if cfg.Server.AdminToken == "" {
+		log.Println("未配置管理接口令牌，管理接口只允许本机访问")
+	}
+	r := routes.SetupRoutes(cfg, dataScheduler, reload)
+
+	// 请求的context派生自serverCtx，关闭服务时取消，进行中的交易所请求和数据库操作随之中止
+	serverCtx, cancelRequests := context.WithCancel(context.Background())
+	server := &http.Server{
+		Addr:        cfg.Server.Addr,
+		Handler:     r,
+		BaseContext: func(net.Listener) context.Context { return serverCtx },
+	}
 
-	// 优雅关闭处理
+	// 优雅关闭处理，SIGHUP重新加载配置
 	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
 
+	stopped := make(chan struct{})
 	go func() {
-		<-c
-		log.Println("收到关闭信号，正在停止服务...")
-		dataScheduler.Stop()
-		os.Exit(0)
+		defer close(stopped)
+		for sig := range c {
+			if sig == syscall.SIGHUP {
+				log.Println("收到SIGHUP，重新加载配置...")
+				if _, err := reload(); err != nil {
+					log.Printf("重新加载配置失败: %v", err)
+				}
+				continue
+			}
+
+			log.Println("收到关闭信号，正在停止服务...")
+			cancelRequests()
+			dataScheduler.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			if err := server.Shutdown(ctx); err != nil {
+				log.Printf("关闭Web服务器失败: %v", err)
+			}
+			cancel()
+			return
+		}
 	}()
 
 	// 启动Web服务器
+	baseURL := "http://localhost" + cfg.Server.Addr
+	if !strings.HasPrefix(cfg.Server.Addr, ":") {
+		baseURL = "http://" + cfg.Server.Addr
+	}
 	log.Println("CurrencyMonitor 启动成功！")
-	log.Println("访问地址: http://localhost:8080")
-	log.Println("仪表板: http://localhost:8080/dashboard")
-	log.Println("API文档: http://localhost:8080/api/v1/long-short/")
+	log.Println("访问地址: " + baseURL)
+	log.Println("仪表板: " + baseURL + "/dashboard")
+	log.Println("API文档: " + baseURL + "/api/v1/long-short/")
 
-	if err := r.Run(":8080"); err != nil {
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf("启动Web服务器失败: %v", err)
 	}
+	<-stopped
+	log.Println("服务已停止")
 }
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"CurrencyMonitor/config"
+	"CurrencyMonitor/database"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+)
+
+// migrateUsage migrate子命令用法
+const migrateUsage = `用法: currency_monitor migrate [配置参数] <命令>
+
+命令:
+  up [N]     执行尚未执行的迁移，指定N时只执行N个
+  down [N]   回滚最近执行的N个迁移，默认1个
+  status     查看迁移执行状态
+
+配置参数与启动服务时相同，例如 -config、-driver、-db、-dsn`
+
+// runMigrate 执行migrate子命令
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("currency_monitor migrate", flag.ContinueOnError)
+	cfg, err := config.LoadFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	rest := fs.Args()
+	if len(rest) == 0 || len(rest) > 2 {
+		return fmt.Errorf("%s", migrateUsage)
+	}
+
+	command := rest[0]
+	steps := 0
+	if command == "down" {
+		steps = 1
+	}
+	if len(rest) == 2 {
+		if command == "status" {
+			return fmt.Errorf("%s", migrateUsage)
+		}
+		steps, err = strconv.Atoi(rest[1])
+		if err != nil || steps <= 0 {
+			return fmt.Errorf("迁移数量必须是正整数: %s", rest[1])
+		}
+	}
+
+	db, err := database.Open(cfg.Database)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		version, err := database.MigrateUp(db, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("数据库当前版本: %d\n", version)
+	case "down":
+		version, err := database.MigrateDown(db, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("数据库当前版本: %d\n", version)
+	case "status":
+		states, err := database.MigrationStatus(db)
+		if err != nil {
+			return err
+		}
+		printMigrationStatus(states)
+	default:
+		return fmt.Errorf("%s", migrateUsage)
+	}
+	return nil
+}
+
+// printMigrationStatus 输出迁移执行状态表
+func printMigrationStatus(states []database.MigrationState) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "版本\t名称\t状态\t执行时间")
+	for _, state := range states {
+		status, appliedAt := "未执行", "-"
+		if state.Applied {
+			status = "已执行"
+			appliedAt = state.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%03d\t%s\t%s\t%s\n", state.Version, state.Name, status, appliedAt)
+	}
+	w.Flush()
+}
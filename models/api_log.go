@@ -1,6 +1,7 @@
 package models
 
 import (
+	"context"
 	"time"
 
 	"gorm.io/gorm"
@@ -9,7 +10,7 @@ import (
 // APILog API请求日志模型
 type APILog struct {
 	ID        uint      `json:"id" gorm:"primarykey"`
-	CreatedAt time.Time `json:"created_at"`
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_api_logs_created_at"`
 
 	Exchange     string `json:"exchange" gorm:"index;not null"` // 交易所名称 (binance, okx)
 	Symbol       string `json:"symbol" gorm:"not null"`         // 交易对
@@ -20,6 +21,7 @@ type APILog struct {
 	ResponseTime int64  `json:"response_time" gorm:"not null"`  // 响应时间(毫秒)
 	DataCount    int    `json:"data_count" gorm:"not null"`     // 返回数据条数
 	ErrorMsg     string `json:"error_msg"`                      // 错误信息
+	ErrorType    string `json:"error_type"`                     // 错误类型 (rate_limited, unavailable, bad_symbol, decode)
 	Success      bool   `json:"success" gorm:"not null"`        // 是否成功
 }
 
@@ -34,23 +36,23 @@ func NewAPILogRepository(db *gorm.DB) *APILogRepository {
 }
 
 // Create 创建新的API日志记录
-func (r *APILogRepository) Create(log *APILog) error {
-	return r.db.Create(log).Error
+func (r *APILogRepository) Create(ctx context.Context, log *APILog) error {
+	return r.db.WithContext(ctx).Create(log).Error
 }
 
 // GetRecent 获取最近的日志记录
-func (r *APILogRepository) GetRecent(limit int) ([]APILog, error) {
+func (r *APILogRepository) GetRecent(ctx context.Context, limit int) ([]APILog, error) {
 	var logs []APILog
-	err := r.db.Order("created_at DESC").
+	err := r.db.WithContext(ctx).Order("created_at DESC").
 		Limit(limit).
 		Find(&logs).Error
 	return logs, err
 }
 
 // GetByExchange 根据交易所获取日志
-func (r *APILogRepository) GetByExchange(exchange string, limit int) ([]APILog, error) {
+func (r *APILogRepository) GetByExchange(ctx context.Context, exchange string, limit int) ([]APILog, error) {
 	var logs []APILog
-	err := r.db.Where("exchange = ?", exchange).
+	err := r.db.WithContext(ctx).Where("exchange = ?", exchange).
 		Order("created_at DESC").
 		Limit(limit).
 		Find(&logs).Error
@@ -58,29 +60,48 @@ func (r *APILogRepository) GetByExchange(exchange string, limit int) ([]APILog,
 }
 
 // GetStatistics 获取统计信息
-func (r *APILogRepository) GetStatistics(since time.Time) (map[string]interface{}, error) {
+func (r *APILogRepository) GetStatistics(ctx context.Context, since time.Time) (map[string]interface{}, error) {
 	var totalRequests int64
 	var successRequests int64
 	var avgResponseTime float64
 
 	// 总请求数
-	err := r.db.Model(&APILog{}).Where("created_at >= ?", since).Count(&totalRequests).Error
+	err := r.db.WithContext(ctx).Model(&APILog{}).Where("created_at >= ?", since).Count(&totalRequests).Error
 	if err != nil {
 		return nil, err
 	}
 
 	// 成功请求数
-	err = r.db.Model(&APILog{}).Where("created_at >= ? AND success = ?", since, true).Count(&successRequests).Error
+	err = r.db.WithContext(ctx).Model(&APILog{}).Where("created_at >= ? AND success = ?", since, true).Count(&successRequests).Error
 	if err != nil {
 		return nil, err
 	}
 
-	// 平均响应时间
-	err = r.db.Model(&APILog{}).Where("created_at >= ?", since).Select("AVG(response_time)").Scan(&avgResponseTime).Error
+	// 平均响应时间，没有记录时AVG返回NULL
+	err = r.db.WithContext(ctx).Model(&APILog{}).Where("created_at >= ?", since).Select("COALESCE(AVG(response_time), 0)").Scan(&avgResponseTime).Error
 	if err != nil {
 		return nil, err
 	}
 
+	// 按错误类型统计失败请求，未分类的错误类型为空
+	var errorTypes []struct {
+		ErrorType string
+		Count     int64
+	}
+	err = r.db.WithContext(ctx).Model(&APILog{}).Where("created_at >= ? AND success = ?", since, false).
+		Select("error_type, COUNT(*) AS count").Group("error_type").Scan(&errorTypes).Error
+	if err != nil {
+		return nil, err
+	}
+	errorsByType := make(map[string]int64, len(errorTypes))
+	for _, item := range errorTypes {
+		name := item.ErrorType
+		if name == "" {
+			name = "other"
+		}
+		errorsByType[name] += item.Count
+	}
+
 	successRate := float64(0)
 	if totalRequests > 0 {
 		successRate = float64(successRequests) / float64(totalRequests) * 100
@@ -91,10 +112,11 @@ func (r *APILogRepository) GetStatistics(since time.Time) (map[string]interface{
 		"success_requests":  successRequests,
 		"success_rate":      successRate,
 		"avg_response_time": avgResponseTime,
+		"errors_by_type":    errorsByType,
 	}, nil
 }
 
 // DeleteOldLogs 删除旧日志
-func (r *APILogRepository) DeleteOldLogs(before time.Time) error {
-	return r.db.Where("created_at < ?", before).Delete(&APILog{}).Error
+func (r *APILogRepository) DeleteOldLogs(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("created_at < ?", before).Delete(&APILog{}).Error
 }
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FundingRate 已结算资金费率数据模型
+type FundingRate struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Exchange    string    `json:"exchange" gorm:"index;uniqueIndex:idx_funding_rates_unique;not null"`     // 交易所名称 (binance, okx)
+	Symbol      string    `json:"symbol" gorm:"index;uniqueIndex:idx_funding_rates_unique;not null"`       // 交易对 (BTCUSDT, ETHUSDT)
+	Rate        float64   `json:"rate" gorm:"not null"`                                                    // 结算费率
+	FundingTime time.Time `json:"funding_time" gorm:"index;uniqueIndex:idx_funding_rates_unique;not null"` // 结算时间
+}
+
+// PredictedFunding 预测资金费率数据模型（每个结算周期一条，随收集更新）
+type PredictedFunding struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Exchange        string    `json:"exchange" gorm:"index;uniqueIndex:idx_predicted_fundings_unique;not null"`          // 交易所名称 (binance, okx)
+	Symbol          string    `json:"symbol" gorm:"index;uniqueIndex:idx_predicted_fundings_unique;not null"`            // 交易对 (BTCUSDT, ETHUSDT)
+	Rate            float64   `json:"rate" gorm:"not null"`                                                              // 预测费率
+	NextFundingTime time.Time `json:"next_funding_time" gorm:"index;uniqueIndex:idx_predicted_fundings_unique;not null"` // 下一次结算时间
+	Timestamp       time.Time `json:"timestamp" gorm:"not null"`                                                         // 数据时间戳
+}
+
+// FundingRateRepository 资金费率数据仓库
+type FundingRateRepository struct {
+	db *gorm.DB
+}
+
+// NewFundingRateRepository 创建新的资金费率数据仓库
+func NewFundingRateRepository(db *gorm.DB) *FundingRateRepository {
+	return &FundingRateRepository{db: db}
+}
+
+// fundingRateUpsert 唯一键冲突时更新结算费率
+var fundingRateUpsert = clause.OnConflict{
+	Columns:   []clause.Column{{Name: "exchange"}, {Name: "symbol"}, {Name: "funding_time"}},
+	DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_at"}),
+}
+
+// predictedFundingUpsert 唯一键冲突时更新预测费率和数据时间戳
+var predictedFundingUpsert = clause.OnConflict{
+	Columns:   []clause.Column{{Name: "exchange"}, {Name: "symbol"}, {Name: "next_funding_time"}},
+	DoUpdates: clause.AssignmentColumns([]string{"rate", "timestamp", "updated_at"}),
+}
+
+// CreateOrUpdate 创建或更新已结算资金费率记录，依赖唯一索引在一条语句内完成
+func (r *FundingRateRepository) CreateOrUpdate(ctx context.Context, rate *FundingRate) error {
+	return r.db.WithContext(ctx).Clauses(fundingRateUpsert).Create(rate).Error
+}
+
+// GetRecentData 获取最近指定时间范围内的已结算资金费率
+func (r *FundingRateRepository) GetRecentData(ctx context.Context, exchange, symbol string, since time.Time) ([]FundingRate, error) {
+	var rates []FundingRate
+	err := r.db.WithContext(ctx).Where("exchange = ? AND symbol = ? AND funding_time >= ?", exchange, symbol, since).
+		Order("funding_time ASC").
+		Find(&rates).Error
+	return rates, err
+}
+
+// GetLatest 获取最近一次已结算资金费率
+func (r *FundingRateRepository) GetLatest(ctx context.Context, exchange, symbol string) (*FundingRate, error) {
+	var rate FundingRate
+	err := r.db.WithContext(ctx).Where("exchange = ? AND symbol = ?", exchange, symbol).
+		Order("funding_time DESC").
+		First(&rate).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// SavePredicted 保存预测资金费率，同一结算周期内覆盖更新。推送每秒更新预测费率，依赖唯一索引在一条语句内完成
+func (r *FundingRateRepository) SavePredicted(ctx context.Context, predicted *PredictedFunding) error {
+	return r.db.WithContext(ctx).Clauses(predictedFundingUpsert).Create(predicted).Error
+}
+
+// GetLatestPredicted 获取最新的预测资金费率
+func (r *FundingRateRepository) GetLatestPredicted(ctx context.Context, exchange, symbol string) (*PredictedFunding, error) {
+	var predicted PredictedFunding
+	err := r.db.WithContext(ctx).Where("exchange = ? AND symbol = ?", exchange, symbol).
+		Order("next_funding_time DESC").
+		First(&predicted).Error
+	if err != nil {
+		return nil, err
+	}
+	return &predicted, nil
+}
+
+// ForEach 按交易所、交易对、结算时间顺序逐条读取结算时间在[start, end)内的资金费率，exchanges或symbols为空时不过滤
+func (r *FundingRateRepository) ForEach(ctx context.Context, exchanges, symbols []string, start, end time.Time, fn func(*FundingRate) error) error {
+	return forEach(ctx, r.db, "funding_time", "exchange, symbol, funding_time", exchanges, symbols, start, end, fn)
+}
+
+// ForEachPredicted 按交易所、交易对、下一次结算时间顺序逐条读取结算时间在[start, end)内的预测资金费率，exchanges或symbols为空时不过滤
+func (r *FundingRateRepository) ForEachPredicted(ctx context.Context, exchanges, symbols []string, start, end time.Time, fn func(*PredictedFunding) error) error {
+	return forEach(ctx, r.db, "next_funding_time", "exchange, symbol, next_funding_time", exchanges, symbols, start, end, fn)
+}
+
+// DeleteOldData 删除指定时间之前的旧数据
+func (r *FundingRateRepository) DeleteOldData(ctx context.Context, before time.Time) error {
+	if err := r.db.WithContext(ctx).Where("funding_time < ?", before).Delete(&FundingRate{}).Error; err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Where("next_funding_time < ?", before).Delete(&PredictedFunding{}).Error
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Kline K线数据模型
+type Kline struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Exchange string    `json:"exchange" gorm:"index;uniqueIndex:idx_klines_unique;not null"`  // 交易所名称 (binance, okx)
+	Symbol   string    `json:"symbol" gorm:"index;uniqueIndex:idx_klines_unique;not null"`    // 交易对 (BTCUSDT, ETHUSDT)
+	Period   string    `json:"period" gorm:"uniqueIndex:idx_klines_unique;size:16;not null"`  // 时间粒度 (5m, 1h, ...)
+	OpenTime time.Time `json:"open_time" gorm:"index;uniqueIndex:idx_klines_unique;not null"` // 开盘时间
+	Open     float64   `json:"open" gorm:"not null"`                                          // 开盘价
+	High     float64   `json:"high" gorm:"not null"`                                          // 最高价
+	Low      float64   `json:"low" gorm:"not null"`                                           // 最低价
+	Close    float64   `json:"close" gorm:"not null"`                                         // 收盘价
+	Volume   float64   `json:"volume" gorm:"not null"`                                        // 成交量
+}
+
+// KlineRepository K线数据仓库
+type KlineRepository struct {
+	db *gorm.DB
+}
+
+// NewKlineRepository 创建新的K线数据仓库
+func NewKlineRepository(db *gorm.DB) *KlineRepository {
+	return &KlineRepository{db: db}
+}
+
+// klineUpsert 唯一键冲突时更新价格和成交量
+var klineUpsert = clause.OnConflict{
+	Columns:   []clause.Column{{Name: "exchange"}, {Name: "symbol"}, {Name: "period"}, {Name: "open_time"}},
+	DoUpdates: clause.AssignmentColumns([]string{"open", "high", "low", "close", "volume", "updated_at"}),
+}
+
+// CreateOrUpdate 创建或更新K线记录（未收盘的K线会被后续数据覆盖），依赖唯一索引在一条语句内完成
+func (r *KlineRepository) CreateOrUpdate(ctx context.Context, kline *Kline) error {
+	return r.db.WithContext(ctx).Clauses(klineUpsert).Create(kline).Error
+}
+
+// GetRange 获取指定时间范围内的K线
+func (r *KlineRepository) GetRange(ctx context.Context, exchange, symbol, period string, start, end time.Time) ([]Kline, error) {
+	var klines []Kline
+	err := r.db.WithContext(ctx).Where("exchange = ? AND symbol = ? AND period = ? AND open_time >= ? AND open_time <= ?",
+		exchange, symbol, period, start, end).
+		Order("open_time ASC").
+		Find(&klines).Error
+	return klines, err
+}
+
+// ForEach 按交易所、交易对、开盘时间顺序逐条读取开盘时间在[start, end)内的K线，exchanges或symbols为空时不过滤
+func (r *KlineRepository) ForEach(ctx context.Context, exchanges, symbols []string, start, end time.Time, fn func(*Kline) error) error {
+	return forEach(ctx, r.db, "open_time", "exchange, symbol, open_time, period", exchanges, symbols, start, end, fn)
+}
+
+// DeleteOldData 删除指定时间之前的旧数据
+func (r *KlineRepository) DeleteOldData(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("open_time < ?", before).Delete(&Kline{}).Error
+}
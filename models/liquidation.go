@@ -0,0 +1,118 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Liquidation 强平事件数据模型
+type Liquidation struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Exchange  string    `json:"exchange" gorm:"index;uniqueIndex:idx_liquidations_unique;not null"`  // 交易所名称 (binance, okx)
+	Symbol    string    `json:"symbol" gorm:"index;uniqueIndex:idx_liquidations_unique;not null"`    // 交易对 (BTCUSDT, ETHUSDT)
+	Side      string    `json:"side" gorm:"uniqueIndex:idx_liquidations_unique;size:16;not null"`    // 被强平的仓位方向 (long, short)
+	Price     float64   `json:"price" gorm:"uniqueIndex:idx_liquidations_unique;not null"`           // 成交价格
+	Quantity  float64   `json:"quantity" gorm:"uniqueIndex:idx_liquidations_unique;not null"`        // 数量（以币计）
+	Notional  float64   `json:"notional" gorm:"not null"`                                            // 名义价值（USDT）
+	Timestamp time.Time `json:"timestamp" gorm:"index;uniqueIndex:idx_liquidations_unique;not null"` // 强平时间
+}
+
+// LiquidationBucket 按时间桶聚合的强平数据
+type LiquidationBucket struct {
+	Timestamp     time.Time `json:"timestamp"`      // 时间桶起始时间
+	LongNotional  float64   `json:"long_notional"`  // 多头强平名义价值
+	ShortNotional float64   `json:"short_notional"` // 空头强平名义价值
+	LongCount     int       `json:"long_count"`     // 多头强平笔数
+	ShortCount    int       `json:"short_count"`    // 空头强平笔数
+}
+
+// LiquidationRepository 强平数据仓库
+type LiquidationRepository struct {
+	db *gorm.DB
+}
+
+// NewLiquidationRepository 创建新的强平数据仓库
+func NewLiquidationRepository(db *gorm.DB) *LiquidationRepository {
+	return &LiquidationRepository{db: db}
+}
+
+// liquidationInsertIgnore 相同强平事件已存在时跳过
+var liquidationInsertIgnore = clause.OnConflict{
+	Columns: []clause.Column{
+		{Name: "exchange"}, {Name: "symbol"}, {Name: "side"}, {Name: "timestamp"}, {Name: "price"}, {Name: "quantity"},
+	},
+	DoNothing: true,
+}
+
+// CreateIfNotExists 创建强平记录，相同事件已存在时跳过（REST轮询和推送会重复返回同一事件），返回是否新建。
+// 依赖唯一索引在一条语句内完成，并发写入不会产生重复记录
+func (r *LiquidationRepository) CreateIfNotExists(ctx context.Context, liq *Liquidation) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(liquidationInsertIgnore).Create(liq)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetRecent 获取最近的强平记录，exchange和symbol为空时不过滤
+func (r *LiquidationRepository) GetRecent(ctx context.Context, exchange, symbol string, limit int) ([]Liquidation, error) {
+	var liqs []Liquidation
+	query := r.db.WithContext(ctx).Model(&Liquidation{})
+	if exchange != "" {
+		query = query.Where("exchange = ?", exchange)
+	}
+	if symbol != "" {
+		query = query.Where("symbol = ?", symbol)
+	}
+	err := query.Order("timestamp DESC").
+		Limit(limit).
+		Find(&liqs).Error
+	return liqs, err
+}
+
+// Aggregate 按时间桶聚合多空强平名义价值，exchange为空时合并所有交易所
+func (r *LiquidationRepository) Aggregate(ctx context.Context, exchange, symbol string, bucket time.Duration, since time.Time) ([]LiquidationBucket, error) {
+	var liqs []Liquidation
+	query := r.db.WithContext(ctx).Where("symbol = ? AND timestamp >= ?", symbol, since)
+	if exchange != "" {
+		query = query.Where("exchange = ?", exchange)
+	}
+	if err := query.Order("timestamp ASC").Find(&liqs).Error; err != nil {
+		return nil, err
+	}
+
+	// 在内存中分桶，避免依赖数据库方言的时间函数
+	var buckets []LiquidationBucket
+	for _, liq := range liqs {
+		start := liq.Timestamp.Truncate(bucket)
+		if len(buckets) == 0 || !buckets[len(buckets)-1].Timestamp.Equal(start) {
+			buckets = append(buckets, LiquidationBucket{Timestamp: start})
+		}
+
+		current := &buckets[len(buckets)-1]
+		if liq.Side == "short" {
+			current.ShortNotional += liq.Notional
+			current.ShortCount++
+		} else {
+			current.LongNotional += liq.Notional
+			current.LongCount++
+		}
+	}
+
+	return buckets, nil
+}
+
+// ForEach 按交易所、交易对、时间顺序逐条读取[start, end)内的强平记录，exchanges或symbols为空时不过滤
+func (r *LiquidationRepository) ForEach(ctx context.Context, exchanges, symbols []string, start, end time.Time, fn func(*Liquidation) error) error {
+	return forEach(ctx, r.db, "timestamp", "exchange, symbol, timestamp, side, price, quantity", exchanges, symbols, start, end, fn)
+}
+
+// DeleteOldData 删除指定时间之前的旧数据
+func (r *LiquidationRepository) DeleteOldData(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("timestamp < ?", before).Delete(&Liquidation{}).Error
+}
@@ -1,21 +1,28 @@
 package models
 
 import (
+	"context"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// DefaultRatioPeriod 未指定时间粒度时多空比数据使用的粒度
+const DefaultRatioPeriod = "5m"
+
 // LongShortRatio 多空比数据模型
 type LongShortRatio struct {
 	ID        uint      `json:"id" gorm:"primarykey"`
 	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"index:idx_long_short_ratios_updated_at"`
 
-	Exchange  string    `json:"exchange" gorm:"index;not null"`  // 交易所名称 (binance, okx)
-	Symbol    string    `json:"symbol" gorm:"index;not null"`    // 交易对 (BTC, ETH)
-	Ratio     float64   `json:"ratio" gorm:"not null"`           // 多空比值
-	Timestamp time.Time `json:"timestamp" gorm:"index;not null"` // 数据时间戳
+	Exchange  string    `json:"exchange" gorm:"index;uniqueIndex:idx_long_short_ratios_unique;not null"`                      // 交易所名称 (binance, okx)
+	Symbol    string    `json:"symbol" gorm:"index;uniqueIndex:idx_long_short_ratios_unique;not null"`                        // 交易对 (BTC, ETH)
+	Metric    string    `json:"metric" gorm:"index;uniqueIndex:idx_long_short_ratios_unique;not null;default:global_account"` // 指标类型 (global_account, top_account, top_position, taker_volume)
+	Period    string    `json:"period" gorm:"uniqueIndex:idx_long_short_ratios_unique;not null;default:5m"`                   // 时间粒度 (5m, 15m, 1h...)
+	Ratio     float64   `json:"ratio" gorm:"not null"`                                                                        // 多空比值
+	Timestamp time.Time `json:"timestamp" gorm:"index;uniqueIndex:idx_long_short_ratios_unique;not null"`                     // 数据时间戳
 }
 
 // LongShortRatioRepository 多空比数据仓库
@@ -29,52 +36,109 @@ func NewLongShortRatioRepository(db *gorm.DB) *LongShortRatioRepository {
 }
 
 // Create 创建新的多空比记录
-func (r *LongShortRatioRepository) Create(ratio *LongShortRatio) error {
-	return r.db.Create(ratio).Error
+func (r *LongShortRatioRepository) Create(ctx context.Context, ratio *LongShortRatio) error {
+	return r.db.WithContext(ctx).Create(ratio).Error
+}
+
+// ratioUpsert 唯一键冲突时更新多空比值
+var ratioUpsert = clause.OnConflict{
+	Columns:   []clause.Column{{Name: "exchange"}, {Name: "symbol"}, {Name: "metric"}, {Name: "period"}, {Name: "timestamp"}},
+	DoUpdates: clause.AssignmentColumns([]string{"ratio", "updated_at"}),
+}
+
+// CreateOrUpdate 创建或更新多空比记录，依赖唯一索引在一条语句内完成，并发写入不会产生重复记录
+func (r *LongShortRatioRepository) CreateOrUpdate(ctx context.Context, ratio *LongShortRatio) error {
+	if ratio.Period == "" {
+		ratio.Period = DefaultRatioPeriod
+	}
+	return r.db.WithContext(ctx).Clauses(ratioUpsert).Create(ratio).Error
 }
 
-// CreateOrUpdate 创建或更新多空比记录（避免重复）
-func (r *LongShortRatioRepository) CreateOrUpdate(ratio *LongShortRatio) error {
-	// 检查是否已存在相同的记录
-	var existing LongShortRatio
-	err := r.db.Where("exchange = ? AND symbol = ? AND timestamp = ?",
-		ratio.Exchange, ratio.Symbol, ratio.Timestamp).First(&existing).Error
-
-	if err == gorm.ErrRecordNotFound {
-		// 记录不存在，创建新记录
-		return r.db.Create(ratio).Error
-	} else if err != nil {
-		// 其他错误
-		return err
-	} else {
-		// 记录已存在，更新比值
-		return r.db.Model(&existing).Update("ratio", ratio.Ratio).Error
+// UpsertMany 在一个事务内批量创建或更新多空比记录，同一批中唯一键相同的记录只保留最后一条
+func (r *LongShortRatioRepository) UpsertMany(ctx context.Context, ratios []*LongShortRatio) error {
+	type ratioKey struct {
+		exchange, symbol, metric, period string
+		timestamp                        int64
+	}
+
+	positions := make(map[ratioKey]int, len(ratios))
+	unique := make([]*LongShortRatio, 0, len(ratios))
+	for _, ratio := range ratios {
+		if ratio.Period == "" {
+			ratio.Period = DefaultRatioPeriod
+		}
+		key := ratioKey{ratio.Exchange, ratio.Symbol, ratio.Metric, ratio.Period, ratio.Timestamp.UnixNano()}
+		if i, ok := positions[key]; ok {
+			unique[i] = ratio
+			continue
+		}
+		positions[key] = len(unique)
+		unique = append(unique, ratio)
 	}
+	if len(unique) == 0 {
+		return nil
+	}
+
+	// PostgreSQL不允许同一条INSERT ... ON CONFLICT语句中两次更新同一行
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Clauses(ratioUpsert).CreateInBatches(unique, 100).Error
+	})
 }
 
-// GetByExchangeAndSymbol 根据交易所和交易对获取最近的多空比数据
-func (r *LongShortRatioRepository) GetByExchangeAndSymbol(exchange, symbol string, limit int) ([]LongShortRatio, error) {
+// GetByExchangeAndSymbol 根据交易所和交易对获取指定时间粒度最近的多空比数据
+func (r *LongShortRatioRepository) GetByExchangeAndSymbol(ctx context.Context, exchange, symbol, metric, period string, limit int) ([]LongShortRatio, error) {
 	var ratios []LongShortRatio
-	err := r.db.Where("exchange = ? AND symbol = ?", exchange, symbol).
+	err := r.db.WithContext(ctx).Where("exchange = ? AND symbol = ? AND metric = ? AND period = ?", exchange, symbol, metric, period).
 		Order("timestamp DESC").
 		Limit(limit).
 		Find(&ratios).Error
 	return ratios, err
 }
 
-// GetRecentData 获取最近指定时间范围内的数据
-func (r *LongShortRatioRepository) GetRecentData(exchange, symbol string, since time.Time) ([]LongShortRatio, error) {
+// GetRecentData 获取指定时间粒度最近指定时间范围内的数据
+func (r *LongShortRatioRepository) GetRecentData(ctx context.Context, exchange, symbol, metric, period string, since time.Time) ([]LongShortRatio, error) {
 	var ratios []LongShortRatio
-	err := r.db.Where("exchange = ? AND symbol = ? AND timestamp >= ?", exchange, symbol, since).
+	err := r.db.WithContext(ctx).Where("exchange = ? AND symbol = ? AND metric = ? AND period = ? AND timestamp >= ?", exchange, symbol, metric, period, since).
 		Order("timestamp ASC").
 		Find(&ratios).Error
 	return ratios, err
 }
 
-// GetLatest 获取最新的多空比数据
-func (r *LongShortRatioRepository) GetLatest(exchange, symbol string) (*LongShortRatio, error) {
+// GetTimestamps 获取指定时间粒度在[since, until]内已保存数据的时间戳（升序），用于查找缺失的数据
+func (r *LongShortRatioRepository) GetTimestamps(ctx context.Context, exchange, symbol, metric, period string, since, until time.Time) ([]time.Time, error) {
+	var timestamps []time.Time
+	err := r.db.WithContext(ctx).Model(&LongShortRatio{}).
+		Where("exchange = ? AND symbol = ? AND metric = ? AND period = ? AND timestamp >= ? AND timestamp <= ?",
+			exchange, symbol, metric, period, since, until).
+		Order("timestamp ASC").
+		Pluck("timestamp", &timestamps).Error
+	return timestamps, err
+}
+
+// GetEarliestUpdated 获取指定时间粒度在updatedAfter之后写入或更新的数据中最早的数据时间戳，没有时返回false
+func (r *LongShortRatioRepository) GetEarliestUpdated(ctx context.Context, period string, updatedAfter time.Time) (time.Time, bool, error) {
+	var timestamps []time.Time
+	err := r.db.WithContext(ctx).Model(&LongShortRatio{}).
+		Where("period = ? AND updated_at > ?", period, updatedAfter).
+		Order("timestamp ASC").
+		Limit(1).
+		Pluck("timestamp", &timestamps).Error
+	if err != nil || len(timestamps) == 0 {
+		return time.Time{}, false, err
+	}
+	return timestamps[0], true, nil
+}
+
+// ForEach 按交易所、交易对、时间顺序逐条读取[start, end)内的多空比数据，exchanges或symbols为空时不过滤。
+// 数据逐行读取，不会一次性加载到内存
+func (r *LongShortRatioRepository) ForEach(ctx context.Context, exchanges, symbols []string, start, end time.Time, fn func(*LongShortRatio) error) error {
+	return forEach(ctx, r.db, "timestamp", "exchange, symbol, timestamp, metric, period", exchanges, symbols, start, end, fn)
+}
+
+// GetLatest 获取指定时间粒度最新的多空比数据
+func (r *LongShortRatioRepository) GetLatest(ctx context.Context, exchange, symbol, metric, period string) (*LongShortRatio, error) {
 	var ratio LongShortRatio
-	err := r.db.Where("exchange = ? AND symbol = ?", exchange, symbol).
+	err := r.db.WithContext(ctx).Where("exchange = ? AND symbol = ? AND metric = ? AND period = ?", exchange, symbol, metric, period).
 		Order("timestamp DESC").
 		First(&ratio).Error
 	if err != nil {
@@ -84,6 +148,6 @@ func (r *LongShortRatioRepository) GetLatest(exchange, symbol string) (*LongShor
 }
 
 // DeleteOldData 删除指定时间之前的旧数据
-func (r *LongShortRatioRepository) DeleteOldData(before time.Time) error {
-	return r.db.Where("timestamp < ?", before).Delete(&LongShortRatio{}).Error
+func (r *LongShortRatioRepository) DeleteOldData(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("timestamp < ?", before).Delete(&LongShortRatio{}).Error
 }
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OpenInterest 持仓量数据模型
+type OpenInterest struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Exchange     string    `json:"exchange" gorm:"index;uniqueIndex:idx_open_interests_unique;not null"`  // 交易所名称 (binance, okx)
+	Symbol       string    `json:"symbol" gorm:"index;uniqueIndex:idx_open_interests_unique;not null"`    // 交易对 (BTCUSDT, ETHUSDT)
+	OpenInterest float64   `json:"open_interest" gorm:"not null"`                                         // 持仓量（以币计）
+	Notional     float64   `json:"notional" gorm:"not null"`                                              // 持仓名义价值（USDT）
+	Timestamp    time.Time `json:"timestamp" gorm:"index;uniqueIndex:idx_open_interests_unique;not null"` // 数据时间戳
+}
+
+// OpenInterestRepository 持仓量数据仓库
+type OpenInterestRepository struct {
+	db *gorm.DB
+}
+
+// NewOpenInterestRepository 创建新的持仓量数据仓库
+func NewOpenInterestRepository(db *gorm.DB) *OpenInterestRepository {
+	return &OpenInterestRepository{db: db}
+}
+
+// Create 创建新的持仓量记录
+func (r *OpenInterestRepository) Create(ctx context.Context, oi *OpenInterest) error {
+	return r.db.WithContext(ctx).Create(oi).Error
+}
+
+// openInterestUpsert 唯一键冲突时更新持仓量
+var openInterestUpsert = clause.OnConflict{
+	Columns:   []clause.Column{{Name: "exchange"}, {Name: "symbol"}, {Name: "timestamp"}},
+	DoUpdates: clause.AssignmentColumns([]string{"open_interest", "notional", "updated_at"}),
+}
+
+// CreateOrUpdate 创建或更新持仓量记录，依赖唯一索引在一条语句内完成，推送和轮询并发写入不会产生重复记录
+func (r *OpenInterestRepository) CreateOrUpdate(ctx context.Context, oi *OpenInterest) error {
+	return r.db.WithContext(ctx).Clauses(openInterestUpsert).Create(oi).Error
+}
+
+// GetByExchangeAndSymbol 根据交易所和交易对获取最近的持仓量数据
+func (r *OpenInterestRepository) GetByExchangeAndSymbol(ctx context.Context, exchange, symbol string, limit int) ([]OpenInterest, error) {
+	var items []OpenInterest
+	err := r.db.WithContext(ctx).Where("exchange = ? AND symbol = ?", exchange, symbol).
+		Order("timestamp DESC").
+		Limit(limit).
+		Find(&items).Error
+	return items, err
+}
+
+// GetRecentData 获取最近指定时间范围内的数据
+func (r *OpenInterestRepository) GetRecentData(ctx context.Context, exchange, symbol string, since time.Time) ([]OpenInterest, error) {
+	var items []OpenInterest
+	err := r.db.WithContext(ctx).Where("exchange = ? AND symbol = ? AND timestamp >= ?", exchange, symbol, since).
+		Order("timestamp ASC").
+		Find(&items).Error
+	return items, err
+}
+
+// GetLatest 获取最新的持仓量数据
+func (r *OpenInterestRepository) GetLatest(ctx context.Context, exchange, symbol string) (*OpenInterest, error) {
+	var item OpenInterest
+	err := r.db.WithContext(ctx).Where("exchange = ? AND symbol = ?", exchange, symbol).
+		Order("timestamp DESC").
+		First(&item).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// ForEach 按交易所、交易对、时间顺序逐条读取[start, end)内的持仓量数据，exchanges或symbols为空时不过滤
+func (r *OpenInterestRepository) ForEach(ctx context.Context, exchanges, symbols []string, start, end time.Time, fn func(*OpenInterest) error) error {
+	return forEach(ctx, r.db, "timestamp", "exchange, symbol, timestamp", exchanges, symbols, start, end, fn)
+}
+
+// DeleteOldData 删除指定时间之前的旧数据
+func (r *OpenInterestRepository) DeleteOldData(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("timestamp < ?", before).Delete(&OpenInterest{}).Error
+}
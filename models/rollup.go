@@ -0,0 +1,122 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RollupResolutions 多空比汇总的时间粒度，从细到粗排列
+var RollupResolutions = []string{"1h", "4h", "1d"}
+
+// LongShortRatioRollup 多空比汇总数据模型，按时间粒度汇总5分钟数据
+type LongShortRatioRollup struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Exchange    string    `json:"exchange" gorm:"uniqueIndex:idx_long_short_ratio_rollups_unique;size:32;not null"`   // 交易所名称
+	Symbol      string    `json:"symbol" gorm:"uniqueIndex:idx_long_short_ratio_rollups_unique;size:64;not null"`     // 交易对
+	Metric      string    `json:"metric" gorm:"uniqueIndex:idx_long_short_ratio_rollups_unique;size:32;not null"`     // 指标类型
+	Resolution  string    `json:"resolution" gorm:"uniqueIndex:idx_long_short_ratio_rollups_unique;size:16;not null"` // 汇总粒度 (1h, 4h, 1d)
+	BucketStart time.Time `json:"bucket_start" gorm:"uniqueIndex:idx_long_short_ratio_rollups_unique;not null"`       // 汇总区间开始时间（UTC对齐）
+	Open        float64   `json:"open" gorm:"not null"`                                                               // 区间内第一个多空比
+	High        float64   `json:"high" gorm:"not null"`                                                               // 区间内最高多空比
+	Low         float64   `json:"low" gorm:"not null"`                                                                // 区间内最低多空比
+	Close       float64   `json:"close" gorm:"not null"`                                                              // 区间内最后一个多空比
+	Mean        float64   `json:"mean" gorm:"not null"`                                                               // 区间内多空比均值
+	Count       int       `json:"count" gorm:"not null"`                                                              // 参与汇总的数据点数量
+}
+
+// RollupRepository 多空比汇总数据仓库
+type RollupRepository struct {
+	db *gorm.DB
+}
+
+// NewRollupRepository 创建新的多空比汇总数据仓库
+func NewRollupRepository(db *gorm.DB) *RollupRepository {
+	return &RollupRepository{db: db}
+}
+
+// rollupUpsert 唯一键冲突时更新汇总值
+var rollupUpsert = clause.OnConflict{
+	Columns: []clause.Column{
+		{Name: "exchange"}, {Name: "symbol"}, {Name: "metric"}, {Name: "resolution"}, {Name: "bucket_start"},
+	},
+	DoUpdates: clause.AssignmentColumns([]string{"open", "high", "low", "close", "mean", "count", "updated_at"}),
+}
+
+// Refresh 用sourcePeriod粒度的多空比重新计算since所在区间及之后的汇总数据，返回写入的汇总条数
+func (r *RollupRepository) Refresh(ctx context.Context, resolution string, bucket time.Duration, sourcePeriod string, since time.Time) (int, error) {
+	since = since.Truncate(bucket)
+
+	var ratios []LongShortRatio
+	err := r.db.WithContext(ctx).Where("period = ? AND timestamp >= ?", sourcePeriod, since).
+		Order("exchange, symbol, metric, timestamp").
+		Find(&ratios).Error
+	if err != nil {
+		return 0, err
+	}
+
+	type rollupKey struct {
+		exchange, symbol, metric string
+		bucketStart              int64
+	}
+	var rollups []*LongShortRatioRollup
+	index := make(map[rollupKey]*LongShortRatioRollup)
+	for _, ratio := range ratios {
+		bucketStart := ratio.Timestamp.Truncate(bucket)
+		key := rollupKey{ratio.Exchange, ratio.Symbol, ratio.Metric, bucketStart.Unix()}
+
+		rollup, ok := index[key]
+		if !ok {
+			rollup = &LongShortRatioRollup{
+				Exchange:    ratio.Exchange,
+				Symbol:      ratio.Symbol,
+				Metric:      ratio.Metric,
+				Resolution:  resolution,
+				BucketStart: bucketStart,
+				Open:        ratio.Ratio,
+				High:        ratio.Ratio,
+				Low:         ratio.Ratio,
+			}
+			index[key] = rollup
+			rollups = append(rollups, rollup)
+		}
+
+		// 数据按时间升序，最后一个即收盘值；Mean先累计总和，最后再求均值
+		rollup.High = max(rollup.High, ratio.Ratio)
+		rollup.Low = min(rollup.Low, ratio.Ratio)
+		rollup.Close = ratio.Ratio
+		rollup.Mean += ratio.Ratio
+		rollup.Count++
+	}
+	if len(rollups) == 0 {
+		return 0, nil
+	}
+	for _, rollup := range rollups {
+		rollup.Mean /= float64(rollup.Count)
+	}
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Clauses(rollupUpsert).CreateInBatches(rollups, 100).Error
+	})
+	return len(rollups), err
+}
+
+// GetRecentData 获取指定汇总粒度最近指定时间范围内的数据
+func (r *RollupRepository) GetRecentData(ctx context.Context, exchange, symbol, metric, resolution string, since time.Time) ([]LongShortRatioRollup, error) {
+	var rollups []LongShortRatioRollup
+	err := r.db.WithContext(ctx).Where("exchange = ? AND symbol = ? AND metric = ? AND resolution = ? AND bucket_start >= ?",
+		exchange, symbol, metric, resolution, since).
+		Order("bucket_start ASC").
+		Find(&rollups).Error
+	return rollups, err
+}
+
+// DeleteOldData 删除指定时间之前的旧数据
+func (r *RollupRepository) DeleteOldData(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("bucket_start < ?", before).Delete(&LongShortRatioRollup{}).Error
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"CurrencyMonitor/internal/testdb"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRollupRefresh(t *testing.T) {
+	db := testdb.Migrated(t)
+	ctx := context.Background()
+	ratios := NewLongShortRatioRepository(db)
+	rollups := NewRollupRepository(db)
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var data []*LongShortRatio
+	for i, value := range []float64{1.0, 1.4, 0.8, 1.2, 2.0} {
+		// 前4个点在00:00的小时区间内，最后一个点在01:00的区间内
+		ts := base.Add(time.Duration(i) * 15 * time.Minute)
+		data = append(data, &LongShortRatio{Exchange: "binance", Symbol: "BTCUSDT", Metric: "global_account", Period: "5m", Ratio: value, Timestamp: ts})
+	}
+	// 其他时间粒度的数据不参与汇总
+	data = append(data, &LongShortRatio{Exchange: "binance", Symbol: "BTCUSDT", Metric: "global_account", Period: "1h", Ratio: 9, Timestamp: base})
+	if err := ratios.UpsertMany(ctx, data); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := rollups.Refresh(ctx, "1h", time.Hour, "5m", base.Add(10*time.Minute))
+	if err != nil || count != 2 {
+		t.Fatalf("Refresh = %d, %v, 期望2条", count, err)
+	}
+
+	stored, err := rollups.GetRecentData(ctx, "binance", "BTCUSDT", "global_account", "1h", base)
+	if err != nil || len(stored) != 2 {
+		t.Fatalf("汇总数据 = %+v, %v", stored, err)
+	}
+	first := stored[0]
+	if !first.BucketStart.Equal(base) || first.Open != 1.0 || first.High != 1.4 || first.Low != 0.8 || first.Close != 1.2 || first.Mean != 1.1 || first.Count != 4 {
+		t.Errorf("00:00汇总错误: %+v", first)
+	}
+	if second := stored[1]; !second.BucketStart.Equal(base.Add(time.Hour)) || second.Close != 2.0 || second.Count != 1 {
+		t.Errorf("01:00汇总错误: %+v", second)
+	}
+
+	// 数据更新后重新汇总覆盖原有记录
+	if err := ratios.CreateOrUpdate(ctx, &LongShortRatio{Exchange: "binance", Symbol: "BTCUSDT", Metric: "global_account", Period: "5m", Ratio: 1.6, Timestamp: base.Add(45 * time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rollups.Refresh(ctx, "1h", time.Hour, "5m", base); err != nil {
+		t.Fatal(err)
+	}
+	stored, err = rollups.GetRecentData(ctx, "binance", "BTCUSDT", "global_account", "1h", base)
+	if err != nil || len(stored) != 2 || stored[0].Close != 1.6 || stored[0].High != 1.6 || stored[0].Count != 4 {
+		t.Errorf("重新汇总后的数据错误: %+v, %v", stored, err)
+	}
+}
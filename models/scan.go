@@ -0,0 +1,37 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// forEach 逐行读取T对应数据表中timeColumn在[start, end)内的记录，按order排序，exchanges或symbols为空时不过滤。
+// 数据逐行读取，不会一次性加载到内存
+func forEach[T any](ctx context.Context, db *gorm.DB, timeColumn, order string, exchanges, symbols []string, start, end time.Time, fn func(*T) error) error {
+	query := db.WithContext(ctx).Model(new(T)).Where(timeColumn+" >= ? AND "+timeColumn+" < ?", start, end)
+	if len(exchanges) > 0 {
+		query = query.Where("exchange IN ?", exchanges)
+	}
+	if len(symbols) > 0 {
+		query = query.Where("symbol IN ?", symbols)
+	}
+
+	rows, err := query.Order(order).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item T
+		if err := db.ScanRows(rows, &item); err != nil {
+			return err
+		}
+		if err := fn(&item); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Symbol 交易所合约目录，记录统一格式交易对与交易所原生合约代码的对应关系
+type Symbol struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Exchange        string  `json:"exchange" gorm:"index;not null"`              // 交易所名称
+	Symbol          string  `json:"symbol" gorm:"index;not null"`                // 统一格式交易对 (BTCUSDT)
+	ExchangeSymbol  string  `json:"exchange_symbol" gorm:"not null"`             // 交易所原生合约代码 (BTC-USDT-SWAP)
+	BaseAsset       string  `json:"base_asset"`                                  // 基础货币
+	QuoteAsset      string  `json:"quote_asset"`                                 // 计价货币
+	OpenInterestUSD float64 `json:"open_interest_usd"`                           // 最近一次刷新时的持仓价值（USDT）
+	Listed          bool    `json:"listed" gorm:"not null;default:true"`         // 是否仍在交易所上线
+	Tracked         bool    `json:"tracked" gorm:"index;not null;default:false"` // 是否在跟踪的交易对范围内
+}
+
+// SymbolRepository 合约目录仓库
+type SymbolRepository struct {
+	db *gorm.DB
+}
+
+// NewSymbolRepository 创建新的合约目录仓库
+func NewSymbolRepository(db *gorm.DB) *SymbolRepository {
+	return &SymbolRepository{db: db}
+}
+
+// CreateOrUpdate 创建或更新合约记录
+func (r *SymbolRepository) CreateOrUpdate(ctx context.Context, symbol *Symbol) error {
+	var existing Symbol
+	err := r.db.WithContext(ctx).Where("exchange = ? AND symbol = ?", symbol.Exchange, symbol.Symbol).First(&existing).Error
+
+	if err == gorm.ErrRecordNotFound {
+		symbol.Listed = true
+		return r.db.WithContext(ctx).Create(symbol).Error
+	} else if err != nil {
+		return err
+	} else {
+		return r.db.WithContext(ctx).Model(&existing).Updates(map[string]interface{}{
+			"exchange_symbol":   symbol.ExchangeSymbol,
+			"base_asset":        symbol.BaseAsset,
+			"quote_asset":       symbol.QuoteAsset,
+			"open_interest_usd": symbol.OpenInterestUSD,
+			"listed":            true,
+		}).Error
+	}
+}
+
+// MarkDelisted 将交易所中不在listed列表内的合约标记为已下线
+func (r *SymbolRepository) MarkDelisted(ctx context.Context, exchange string, listed []string) error {
+	query := r.db.WithContext(ctx).Model(&Symbol{}).Where("exchange = ?", exchange)
+	if len(listed) > 0 {
+		query = query.Where("symbol NOT IN ?", listed)
+	}
+	return query.Update("listed", false).Error
+}
+
+// SetTracked 将指定交易对设为跟踪范围，其余交易对取消跟踪
+func (r *SymbolRepository) SetTracked(ctx context.Context, symbols []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Symbol{}).Where("tracked = ?", true).Update("tracked", false).Error; err != nil {
+			return err
+		}
+		if len(symbols) == 0 {
+			return nil
+		}
+		return tx.Model(&Symbol{}).Where("symbol IN ?", symbols).Update("tracked", true).Error
+	})
+}
+
+// GetTrackedSymbols 获取跟踪范围内的统一格式交易对
+func (r *SymbolRepository) GetTrackedSymbols(ctx context.Context) ([]string, error) {
+	var symbols []string
+	err := r.db.WithContext(ctx).Model(&Symbol{}).
+		Where("tracked = ?", true).
+		Distinct("symbol").
+		Order("symbol").
+		Pluck("symbol", &symbols).Error
+	return symbols, err
+}
+
+// GetBySymbol 获取统一格式交易对在各交易所的合约记录
+func (r *SymbolRepository) GetBySymbol(ctx context.Context, symbol string) ([]Symbol, error) {
+	var items []Symbol
+	err := r.db.WithContext(ctx).Where("symbol = ?", symbol).
+		Order("exchange").
+		Find(&items).Error
+	return items, err
+}
+
+// GetAll 获取合约目录，exchange为空时返回所有交易所，trackedOnly为true时只返回跟踪范围内的合约
+func (r *SymbolRepository) GetAll(ctx context.Context, exchange string, trackedOnly bool) ([]Symbol, error) {
+	var items []Symbol
+	query := r.db.WithContext(ctx).Where("listed = ?", true)
+	if exchange != "" {
+		query = query.Where("exchange = ?", exchange)
+	}
+	if trackedOnly {
+		query = query.Where("tracked = ?", true)
+	}
+	err := query.Order("open_interest_usd DESC, symbol, exchange").Find(&items).Error
+	return items, err
+}
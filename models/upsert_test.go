@@ -0,0 +1,187 @@
+package models
+
+import (
+	"CurrencyMonitor/internal/testdb"
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 并发写入的轮数和每轮的写入方数量，每轮使用新的唯一键，多轮才能稳定触发先查询后写入的竞争
+const (
+	writeRounds = 20
+	writers     = 8
+)
+
+// concurrently 每轮在writers个goroutine中同时对同一个唯一键执行fn，模拟推送、轮询和手动刷新同时写入同一条记录
+func concurrently(t *testing.T, fn func(ts time.Time, i int) error) {
+	t.Helper()
+
+	errs := make(chan error, writeRounds*writers)
+	for round := 0; round < writeRounds; round++ {
+		ts := time.Unix(1700000000+int64(round)*300, 0)
+
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				<-start
+				if err := fn(ts, i); err != nil {
+					errs <- err
+				}
+			}(i)
+		}
+		close(start)
+		wg.Wait()
+	}
+	close(errs)
+	for err := range errs {
+		t.Errorf("写入失败: %v", err)
+	}
+}
+
+// countRows 统计表中的记录数
+func countRows(t *testing.T, db *gorm.DB, model interface{}) int64 {
+	t.Helper()
+
+	var count int64
+	if err := db.Model(model).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestConcurrentUpserts(t *testing.T) {
+	// 每个写入方使用独立的数据库连接，先查询后写入的实现在这里会因唯一约束冲突而失败
+	db := testdb.File(t, writers)
+	ctx := context.Background()
+
+	ratios := NewLongShortRatioRepository(db)
+	concurrently(t, func(ts time.Time, i int) error {
+		ratio := &LongShortRatio{Exchange: "binance", Symbol: "BTCUSDT", Metric: "global_account", Period: "5m", Ratio: float64(i), Timestamp: ts}
+		if i%2 == 0 {
+			return ratios.CreateOrUpdate(ctx, ratio)
+		}
+		return ratios.UpsertMany(ctx, []*LongShortRatio{ratio})
+	})
+	if n := countRows(t, db, &LongShortRatio{}); n != writeRounds {
+		t.Errorf("多空比记录数 = %d, 期望 %d", n, writeRounds)
+	}
+
+	klines := NewKlineRepository(db)
+	concurrently(t, func(ts time.Time, i int) error {
+		return klines.CreateOrUpdate(ctx, &Kline{Exchange: "binance", Symbol: "BTCUSDT", Period: "5m", OpenTime: ts, Close: float64(i)})
+	})
+	if n := countRows(t, db, &Kline{}); n != writeRounds {
+		t.Errorf("K线记录数 = %d, 期望 %d", n, writeRounds)
+	}
+
+	openInterests := NewOpenInterestRepository(db)
+	concurrently(t, func(ts time.Time, i int) error {
+		return openInterests.CreateOrUpdate(ctx, &OpenInterest{Exchange: "okx", Symbol: "BTCUSDT", Timestamp: ts, OpenInterest: float64(i)})
+	})
+	if n := countRows(t, db, &OpenInterest{}); n != writeRounds {
+		t.Errorf("持仓量记录数 = %d, 期望 %d", n, writeRounds)
+	}
+
+	funding := NewFundingRateRepository(db)
+	concurrently(t, func(ts time.Time, i int) error {
+		return funding.CreateOrUpdate(ctx, &FundingRate{Exchange: "binance", Symbol: "BTCUSDT", FundingTime: ts, Rate: float64(i)})
+	})
+	concurrently(t, func(ts time.Time, i int) error {
+		return funding.SavePredicted(ctx, &PredictedFunding{Exchange: "binance", Symbol: "BTCUSDT", NextFundingTime: ts, Rate: float64(i), Timestamp: ts})
+	})
+	if n := countRows(t, db, &FundingRate{}); n != writeRounds {
+		t.Errorf("资金费率记录数 = %d, 期望 %d", n, writeRounds)
+	}
+	if n := countRows(t, db, &PredictedFunding{}); n != writeRounds {
+		t.Errorf("预测资金费率记录数 = %d, 期望 %d", n, writeRounds)
+	}
+
+	liquidations := NewLiquidationRepository(db)
+	var created atomic.Int32
+	concurrently(t, func(ts time.Time, i int) error {
+		ok, err := liquidations.CreateIfNotExists(ctx, &Liquidation{
+			Exchange: "binance", Symbol: "BTCUSDT", Side: "long", Price: 100, Quantity: 1, Notional: 100, Timestamp: ts,
+		})
+		if ok {
+			created.Add(1)
+		}
+		return err
+	})
+	if n := countRows(t, db, &Liquidation{}); n != writeRounds || created.Load() != writeRounds {
+		t.Errorf("强平记录数 = %d, 新建次数 = %d, 期望都为 %d", n, created.Load(), writeRounds)
+	}
+}
+
+func TestUpsertUpdatesExistingRow(t *testing.T) {
+	db := testdb.Migrated(t)
+	ctx := context.Background()
+	ts := time.Unix(1700000000, 0)
+
+	ratios := NewLongShortRatioRepository(db)
+	ratio := func(period string, value float64, at time.Time) *LongShortRatio {
+		return &LongShortRatio{Exchange: "okx", Symbol: "BTCUSDT", Metric: "global_account", Period: period, Ratio: value, Timestamp: at}
+	}
+	// 同一批中唯一键相同的记录保留最后一条，未指定时间粒度的按5m保存
+	if err := ratios.UpsertMany(ctx, []*LongShortRatio{
+		ratio("5m", 1.1, ts), ratio("", 1.2, ts), ratio("1h", 2.0, ts), ratio("5m", 1.5, ts.Add(5*time.Minute)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ratios.CreateOrUpdate(ctx, ratio("5m", 1.6, ts.Add(5*time.Minute))); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := ratios.GetRecentData(ctx, "okx", "BTCUSDT", "global_account", "5m", ts)
+	if err != nil || len(stored) != 2 || stored[0].Ratio != 1.2 || stored[1].Ratio != 1.6 {
+		t.Errorf("5m多空比错误: %+v, %v", stored, err)
+	}
+	if n := countRows(t, db, &LongShortRatio{}); n != 3 {
+		t.Errorf("多空比记录数 = %d, 期望 3", n)
+	}
+
+	klines := NewKlineRepository(db)
+	for _, close := range []float64{100, 105} {
+		if err := klines.CreateOrUpdate(ctx, &Kline{Exchange: "binance", Symbol: "BTCUSDT", Period: "5m", OpenTime: ts, Close: close, Volume: close}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	storedKlines, err := klines.GetRange(ctx, "binance", "BTCUSDT", "5m", ts, ts)
+	if err != nil || len(storedKlines) != 1 || storedKlines[0].Close != 105 || storedKlines[0].Volume != 105 {
+		t.Errorf("K线未被更新: %+v, %v", storedKlines, err)
+	}
+
+	funding := NewFundingRateRepository(db)
+	later := ts.Add(time.Minute)
+	for _, item := range []*PredictedFunding{
+		{Exchange: "okx", Symbol: "BTCUSDT", NextFundingTime: ts, Rate: 0.0001, Timestamp: ts},
+		{Exchange: "okx", Symbol: "BTCUSDT", NextFundingTime: ts, Rate: 0.0002, Timestamp: later},
+	} {
+		if err := funding.SavePredicted(ctx, item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	predicted, err := funding.GetLatestPredicted(ctx, "okx", "BTCUSDT")
+	if err != nil || predicted.Rate != 0.0002 || !predicted.Timestamp.Equal(later) {
+		t.Errorf("预测资金费率未被更新: %+v, %v", predicted, err)
+	}
+
+	liquidations := NewLiquidationRepository(db)
+	liq := Liquidation{Exchange: "okx", Symbol: "BTCUSDT", Side: "short", Price: 100, Quantity: 2, Notional: 200, Timestamp: ts}
+	first, err := liquidations.CreateIfNotExists(ctx, &liq)
+	if err != nil || !first {
+		t.Errorf("第一次写入强平记录 = %v, %v, 期望新建", first, err)
+	}
+	duplicate := liq
+	duplicate.ID = 0
+	second, err := liquidations.CreateIfNotExists(ctx, &duplicate)
+	if err != nil || second {
+		t.Errorf("重复写入强平记录 = %v, %v, 期望跳过", second, err)
+	}
+}
@@ -0,0 +1,278 @@
+package retention
+
+import (
+	"CurrencyMonitor/config"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// archiveBatch 归档时每批读取、写入并删除的行数
+const archiveBatch = 1000
+
+// table 数据表中按保留策略过滤使用的列
+type table struct {
+	timeColumn   string // 判断数据是否过期的时间列
+	periodColumn string // 时间粒度列，为空表示不区分时间粒度
+}
+
+// tables 支持保留策略的数据表，与config.RetentionTables对应
+var tables = map[string]table{
+	"long_short_ratios":        {timeColumn: "timestamp", periodColumn: "period"},
+	"long_short_ratio_rollups": {timeColumn: "bucket_start", periodColumn: "resolution"},
+	"klines":                   {timeColumn: "open_time", periodColumn: "period"},
+	"open_interests":           {timeColumn: "timestamp"},
+	"funding_rates":            {timeColumn: "funding_time"},
+	"predicted_fundings":       {timeColumn: "next_funding_time"},
+	"liquidations":             {timeColumn: "timestamp"},
+	"api_logs":                 {timeColumn: "created_at"},
+}
+
+// rule 一条展开后的保留规则
+type rule struct {
+	table   string
+	period  string   // 只处理该时间粒度的数据
+	exclude []string // 跳过这些单独配置了策略的时间粒度
+	days    int
+	archive bool
+}
+
+// Result 一条保留规则的执行结果
+type Result struct {
+	Table       string    `json:"table"`
+	Period      string    `json:"period,omitempty"`
+	Days        int       `json:"days"`
+	Cutoff      time.Time `json:"cutoff"`
+	Deleted     int64     `json:"deleted"`
+	Archived    int64     `json:"archived"`
+	ArchiveFile string    `json:"archive_file,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Report 一次清理的报告
+type Report struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Deleted    int64     `json:"deleted"`  // 删除的总行数
+	Archived   int64     `json:"archived"` // 归档的总行数
+	Results    []Result  `json:"results"`
+}
+
+// Cleaner 按保留策略清理过期数据
+type Cleaner struct {
+	db *gorm.DB
+
+	mu   sync.Mutex // 保证同一时间只有一次清理在执行
+	last *Report
+}
+
+// NewCleaner 创建数据清理器
+func NewCleaner(db *gorm.DB) *Cleaner {
+	return &Cleaner{db: db}
+}
+
+// LastReport 获取最近一次清理的报告，还没有执行过时返回nil
+func (c *Cleaner) LastReport() *Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.last
+}
+
+// Run 按保留策略清理所有数据表，没有配置策略的数据表保留defaultDays天。
+// 单条规则失败不影响其他规则，错误记录在报告中。ctx取消时正在执行和剩余的规则记为失败，已归档的批次不受影响
+func (c *Cleaner) Run(ctx context.Context, cfg config.RetentionConfig, defaultDays int) Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := Report{StartedAt: time.Now()}
+	for _, r := range rules(cfg, defaultDays) {
+		result := c.apply(ctx, r, cfg.ArchiveDir, report.StartedAt)
+
+		name := result.Table
+		if result.Period != "" {
+			name += "." + result.Period
+		}
+		switch {
+		case result.Error != "":
+			log.Printf("清理%s失败: %s", name, result.Error)
+		case result.Deleted > 0:
+			log.Printf("清理%s: 删除%s之前的数据%d条，归档%d条", name, result.Cutoff.Format("2006-01-02 15:04:05"), result.Deleted, result.Archived)
+		}
+
+		report.Deleted += result.Deleted
+		report.Archived += result.Archived
+		report.Results = append(report.Results, result)
+	}
+	report.FinishedAt = time.Now()
+
+	c.last = &report
+	return report
+}
+
+// rules 将保留策略展开为规则：单独配置的时间粒度各一条，表的其余数据一条；保留天数为0的规则跳过
+func rules(cfg config.RetentionConfig, defaultDays int) []rule {
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var rules []rule
+	for _, name := range names {
+		var periods []string
+		for key := range cfg.Policies {
+			if table, period, ok := strings.Cut(key, "."); ok && table == name {
+				periods = append(periods, period)
+			}
+		}
+		sort.Strings(periods)
+
+		for _, period := range periods {
+			policy := cfg.Policies[name+"."+period]
+			if policy.Days > 0 {
+				rules = append(rules, rule{table: name, period: period, days: policy.Days, archive: policy.Archive})
+			}
+		}
+
+		policy, ok := cfg.Policies[name]
+		if !ok {
+			policy = config.RetentionPolicy{Days: defaultDays}
+		}
+		if policy.Days > 0 {
+			rules = append(rules, rule{table: name, exclude: periods, days: policy.Days, archive: policy.Archive})
+		}
+	}
+	return rules
+}
+
+// apply 执行一条保留规则
+func (c *Cleaner) apply(ctx context.Context, r rule, archiveDir string, now time.Time) Result {
+	result := Result{
+		Table:  r.table,
+		Period: r.period,
+		Days:   r.days,
+		Cutoff: now.AddDate(0, 0, -r.days),
+	}
+
+	t := tables[r.table]
+	expired := func() *gorm.DB {
+		query := c.db.WithContext(ctx).Table(r.table).Where(t.timeColumn+" < ?", result.Cutoff)
+		if r.period != "" {
+			query = query.Where(t.periodColumn+" = ?", r.period)
+		}
+		if len(r.exclude) > 0 {
+			query = query.Where(t.periodColumn+" NOT IN ?", r.exclude)
+		}
+		return query
+	}
+
+	var err error
+	if r.archive {
+		err = c.archive(ctx, r, archiveDir, now, expired, &result)
+	} else {
+		deleted := expired().Delete(map[string]interface{}{})
+		err = deleted.Error
+		result.Deleted = deleted.RowsAffected
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// archive 分批将过期数据写入归档文件后删除，每批数据写入并同步到磁盘后才删除，中途失败时已删除的数据都已归档
+func (c *Cleaner) archive(ctx context.Context, r rule, dir string, now time.Time, expired func() *gorm.DB, result *Result) error {
+	var (
+		file    *os.File
+		writer  *gzip.Writer
+		encoder *json.Encoder
+	)
+	defer func() {
+		if writer != nil {
+			writer.Close()
+			file.Close()
+		}
+	}()
+
+	for {
+		var rows []map[string]interface{}
+		if err := expired().Order("id").Limit(archiveBatch).Find(&rows).Error; err != nil {
+			return fmt.Errorf("读取过期数据失败: %w", err)
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		// 有过期数据时才创建归档文件
+		if writer == nil {
+			path, err := archivePath(dir, r, now)
+			if err != nil {
+				return err
+			}
+			if file, err = os.Create(path); err != nil {
+				return fmt.Errorf("创建归档文件失败: %w", err)
+			}
+			writer = gzip.NewWriter(file)
+			encoder = json.NewEncoder(writer)
+			result.ArchiveFile = path
+		}
+
+		ids := make([]interface{}, 0, len(rows))
+		for _, row := range rows {
+			if err := encoder.Encode(row); err != nil {
+				return fmt.Errorf("写入归档文件失败: %w", err)
+			}
+			ids = append(ids, row["id"])
+		}
+		if err := writer.Flush(); err != nil {
+			return fmt.Errorf("写入归档文件失败: %w", err)
+		}
+		if err := file.Sync(); err != nil {
+			return fmt.Errorf("写入归档文件失败: %w", err)
+		}
+		result.Archived += int64(len(rows))
+
+		deleted := c.db.WithContext(ctx).Table(r.table).Where("id IN ?", ids).Delete(map[string]interface{}{})
+		if deleted.Error != nil {
+			return fmt.Errorf("删除已归档数据失败: %w", deleted.Error)
+		}
+		result.Deleted += deleted.RowsAffected
+
+		if len(rows) < archiveBatch {
+			break
+		}
+	}
+
+	if writer == nil {
+		return nil
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("写入归档文件失败: %w", err)
+	}
+	writer = nil
+	return file.Close()
+}
+
+// archivePath 归档文件路径：<dir>/<表名>/<表名>[_<时间粒度>]_<清理时间>.jsonl.gz
+func archivePath(dir string, r rule, now time.Time) (string, error) {
+	tableDir := filepath.Join(dir, r.table)
+	if err := os.MkdirAll(tableDir, 0o755); err != nil {
+		return "", fmt.Errorf("创建归档目录失败: %w", err)
+	}
+
+	name := r.table
+	if r.period != "" {
+		name += "_" + r.period
+	}
+	return filepath.Join(tableDir, fmt.Sprintf("%s_%s.jsonl.gz", name, now.Format("20060102T150405"))), nil
+}
@@ -0,0 +1,221 @@
+package retention
+
+import (
+	"CurrencyMonitor/config"
+	"CurrencyMonitor/internal/testdb"
+	"CurrencyMonitor/models"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name        string
+		policies    map[string]config.RetentionPolicy
+		defaultDays int
+		want        []rule
+	}{
+		{
+			name:        "未配置策略的表使用默认天数",
+			defaultDays: 7,
+			want: []rule{
+				{table: "api_logs", days: 7},
+				{table: "funding_rates", days: 7},
+				{table: "klines", days: 7},
+				{table: "liquidations", days: 7},
+				{table: "long_short_ratio_rollups", days: 7},
+				{table: "long_short_ratios", days: 7},
+				{table: "open_interests", days: 7},
+				{table: "predicted_fundings", days: 7},
+			},
+		},
+		{
+			name: "默认天数为0时只展开配置的表",
+			policies: map[string]config.RetentionPolicy{
+				"api_logs": {Days: 14, Archive: true},
+			},
+			want: []rule{{table: "api_logs", days: 14, archive: true}},
+		},
+		{
+			// 单独配置的粒度各一条规则，表的其余数据排除这些粒度；保留天数为0的粒度永久保留，但仍从表的规则中排除
+			name: "按时间粒度覆盖表的策略",
+			policies: map[string]config.RetentionPolicy{
+				"long_short_ratios.5m": {Days: 30, Archive: true},
+				"long_short_ratios.1d": {Days: 0},
+				"long_short_ratios":    {Days: 90},
+				"klines.1h":            {Days: 60},
+				"klines":               {Days: 0},
+			},
+			defaultDays: 7,
+			want: []rule{
+				{table: "api_logs", days: 7},
+				{table: "funding_rates", days: 7},
+				{table: "klines", period: "1h", days: 60},
+				{table: "liquidations", days: 7},
+				{table: "long_short_ratio_rollups", days: 7},
+				{table: "long_short_ratios", period: "5m", days: 30, archive: true},
+				{table: "long_short_ratios", exclude: []string{"1d", "5m"}, days: 90},
+				{table: "open_interests", days: 7},
+				{table: "predicted_fundings", days: 7},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rules(config.RetentionConfig{Policies: tt.policies}, tt.defaultDays)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rules() = %+v\n期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// insertRatios 为每个时间粒度写入一条距今ageDays天的多空比
+func insertRatios(t *testing.T, db *gorm.DB, ageDays int, periods ...string) {
+	t.Helper()
+
+	repo := models.NewLongShortRatioRepository(db)
+	ts := time.Now().AddDate(0, 0, -ageDays)
+	for _, period := range periods {
+		if err := repo.CreateOrUpdate(context.Background(), &models.LongShortRatio{
+			Exchange: "binance", Symbol: "BTCUSDT", Metric: "global_account", Period: period, Ratio: 1, Timestamp: ts,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// remainingRatios 返回剩余多空比的"时间粒度/距今天数d"，按字典序排列
+func remainingRatios(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+
+	var ratios []models.LongShortRatio
+	if err := db.Find(&ratios).Error; err != nil {
+		t.Fatal(err)
+	}
+	remaining := make([]string, 0, len(ratios))
+	for _, ratio := range ratios {
+		days := int(time.Since(ratio.Timestamp).Hours()/24 + 0.5)
+		remaining = append(remaining, fmt.Sprintf("%s/%dd", ratio.Period, days))
+	}
+	sort.Strings(remaining)
+	return remaining
+}
+
+func TestRunExcludesPeriodsWithOwnPolicy(t *testing.T) {
+	db := testdb.Migrated(t)
+	insertRatios(t, db, 10, "5m", "1h", "1d")
+	insertRatios(t, db, 60, "5m", "1h", "1d")
+	insertRatios(t, db, 120, "5m", "1h", "1d")
+
+	// 5m保留30天，1d永久保留，其余粒度保留90天
+	report := NewCleaner(db).Run(context.Background(), config.RetentionConfig{
+		Policies: map[string]config.RetentionPolicy{
+			"long_short_ratios.5m": {Days: 30},
+			"long_short_ratios.1d": {Days: 0},
+			"long_short_ratios":    {Days: 90},
+		},
+	}, 0)
+
+	if report.Deleted != 3 || len(report.Results) != 2 {
+		t.Fatalf("清理报告错误: %+v", report)
+	}
+	for _, result := range report.Results {
+		if result.Error != "" {
+			t.Errorf("清理%s.%s失败: %s", result.Table, result.Period, result.Error)
+		}
+	}
+	if result := report.Results[0]; result.Period != "5m" || result.Deleted != 2 {
+		t.Errorf("5m规则结果 = %+v, 期望删除2条", result)
+	}
+	if result := report.Results[1]; result.Period != "" || result.Deleted != 1 {
+		t.Errorf("表规则结果 = %+v, 期望只删除120天前的1h数据", result)
+	}
+
+	want := []string{"1d/10d", "1d/120d", "1d/60d", "1h/10d", "1h/60d", "5m/10d"}
+	if got := remainingRatios(t, db); !reflect.DeepEqual(got, want) {
+		t.Errorf("剩余数据 = %v, 期望 %v", got, want)
+	}
+}
+
+func TestRunArchivesBeforeDelete(t *testing.T) {
+	db := testdb.Migrated(t)
+	insertRatios(t, db, 60, "5m", "1h")
+	insertRatios(t, db, 10, "5m")
+
+	dir := t.TempDir()
+	report := NewCleaner(db).Run(context.Background(), config.RetentionConfig{
+		ArchiveDir: dir,
+		Policies:   map[string]config.RetentionPolicy{"long_short_ratios.5m": {Days: 30, Archive: true}},
+	}, 0)
+
+	if len(report.Results) != 1 {
+		t.Fatalf("清理报告错误: %+v", report)
+	}
+	result := report.Results[0]
+	if result.Error != "" || result.Archived != 1 || result.Deleted != 1 {
+		t.Fatalf("归档结果错误: %+v", result)
+	}
+	if filepath.Dir(result.ArchiveFile) != filepath.Join(dir, "long_short_ratios") {
+		t.Errorf("归档文件路径错误: %s", result.ArchiveFile)
+	}
+
+	f, err := os.Open(result.ArchiveFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines int
+	for scanner := bufio.NewScanner(gz); scanner.Scan(); lines++ {
+	}
+	if lines != 1 {
+		t.Errorf("归档文件行数 = %d, 期望 1", lines)
+	}
+
+	want := []string{"1h/60d", "5m/10d"}
+	if got := remainingRatios(t, db); !reflect.DeepEqual(got, want) {
+		t.Errorf("剩余数据 = %v, 期望 %v", got, want)
+	}
+}
+
+func TestRunKeepsDataWhenArchiveFails(t *testing.T) {
+	db := testdb.Migrated(t)
+	insertRatios(t, db, 60, "5m", "1h")
+
+	// 归档目录是一个普通文件，无法创建归档文件
+	dir := filepath.Join(t.TempDir(), "archive")
+	if err := os.WriteFile(dir, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	report := NewCleaner(db).Run(context.Background(), config.RetentionConfig{
+		ArchiveDir: dir,
+		Policies:   map[string]config.RetentionPolicy{"long_short_ratios": {Days: 30, Archive: true}},
+	}, 0)
+
+	if len(report.Results) != 1 {
+		t.Fatalf("清理报告错误: %+v", report)
+	}
+	if result := report.Results[0]; result.Error == "" || result.Archived != 0 || result.Deleted != 0 {
+		t.Errorf("归档失败时的结果 = %+v, 期望记录错误且不删除数据", result)
+	}
+
+	want := []string{"1h/60d", "5m/60d"}
+	if got := remainingRatios(t, db); !reflect.DeepEqual(got, want) {
+		t.Errorf("归档失败后剩余数据 = %v, 期望 %v", got, want)
+	}
+}
@@ -1,13 +1,15 @@
 package routes
 
 import (
+	"CurrencyMonitor/config"
 	"CurrencyMonitor/handlers"
+	"CurrencyMonitor/scheduler"
 
 	"github.com/gin-gonic/gin"
 )
 
-// SetupRoutes 设置路由
-func SetupRoutes() *gin.Engine {
+// SetupRoutes 设置路由，reload重新加载配置并返回变更说明
+func SetupRoutes(cfg *config.Config, dataScheduler *scheduler.DataScheduler, reload func() ([]string, error)) *gin.Engine {
 	r := gin.Default()
 
 	// 静态文件服务
@@ -15,9 +17,27 @@ func SetupRoutes() *gin.Engine {
 	r.LoadHTMLGlob("templates/*")
 
 	// 多空比处理器
-	lsrHandler := handlers.NewLongShortRatioHandler()
+	lsrHandler := handlers.NewLongShortRatioHandler(dataScheduler.DataCollectionService, cfg.Universe.Symbols)
+	// 持仓量处理器
+	oiHandler := handlers.NewOpenInterestHandler(dataScheduler.DataCollectionService, cfg.Universe.Symbols)
+	// 资金费率处理器
+	fundingHandler := handlers.NewFundingHandler(cfg.Universe.Symbols)
+	// 强平数据处理器
+	liqHandler := handlers.NewLiquidationHandler()
+	// 合约目录处理器
+	symbolHandler := handlers.NewSymbolHandler(cfg.Universe.Symbols)
 	// API日志处理器
 	logHandler := handlers.NewAPILogHandler()
+	// 交易所接口状态处理器
+	exchangeStatusHandler := handlers.NewExchangeStatusHandler()
+	// 数据导出处理器
+	exportHandler := handlers.NewExportHandler()
+	// 管理接口处理器
+	adminHandler := handlers.NewAdminHandler(cfg.Server.AdminToken, reload, dataScheduler.GetStatus)
+	// 历史数据补齐处理器
+	backfillHandler := handlers.NewBackfillHandler(dataScheduler.Context(), dataScheduler.DataCollectionService, dataScheduler.Config)
+	// 数据保留策略处理器
+	retentionHandler := handlers.NewRetentionHandler(dataScheduler.CleanupNow, dataScheduler.LastCleanupReport)
 
 	// API路由组
 	api := r.Group("/api/v1")
@@ -33,12 +53,60 @@ func SetupRoutes() *gin.Engine {
 			longShort.GET("/dashboard", lsrHandler.GetDashboardData)
 		}
 
+		// 持仓量相关API
+		openInterest := api.Group("/open-interest")
+		{
+			openInterest.GET("/current", oiHandler.GetCurrent)
+			openInterest.GET("/historical", oiHandler.GetHistorical)
+			openInterest.GET("/chart", oiHandler.GetChartData)
+		}
+
+		// 资金费率相关API
+		funding := api.Group("/funding")
+		{
+			funding.GET("/history", fundingHandler.GetHistory)
+			funding.GET("/next", fundingHandler.GetNext)
+			funding.GET("/comparison", fundingHandler.GetComparison)
+		}
+
+		// 强平数据相关API
+		liquidations := api.Group("/liquidations")
+		{
+			liquidations.GET("/recent", liqHandler.GetRecent)
+			liquidations.GET("/aggregate", liqHandler.GetAggregate)
+		}
+
+		// 合约目录相关API
+		symbols := api.Group("/symbols")
+		{
+			symbols.GET("", symbolHandler.GetCatalog)
+			symbols.GET("/tracked", symbolHandler.GetTracked)
+			symbols.GET("/mapping/:symbol", symbolHandler.GetMapping)
+		}
+
 		// API日志相关API
 		logs := api.Group("/logs")
 		{
 			logs.GET("/recent", logHandler.GetRecentLogs)
 			logs.GET("/statistics", logHandler.GetStatistics)
 		}
+
+		// 交易所接口熔断与限流状态
+		api.GET("/exchanges/status", exchangeStatusHandler.GetStatus)
+
+		// 数据导出API
+		api.GET("/export", exportHandler.Export)
+
+		// 管理相关API
+		admin := api.Group("/admin", adminHandler.RequireToken)
+		{
+			admin.POST("/reload", adminHandler.Reload)
+			admin.GET("/status", adminHandler.GetStatus)
+			admin.POST("/backfill", backfillHandler.Start)
+			admin.GET("/backfill", backfillHandler.GetProgress)
+			admin.POST("/retention", retentionHandler.Cleanup)
+			admin.GET("/retention", retentionHandler.GetReport)
+		}
 	}
 
 	// 前端页面路由
@@ -0,0 +1,204 @@
+package scheduler
+
+import (
+	"CurrencyMonitor/config"
+	"CurrencyMonitor/services"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Reload 应用新配置并返回变更说明。执行周期有变化的定时任务会重新注册，其余任务保持原有调度；
+// 交易所配置或交易对规则有变化时重建数据收集服务，新服务准备好之后才替换正在使用的服务。
+func (s *DataScheduler) Reload(cfg *config.Config) ([]string, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	s.mu.RLock()
+	oldCfg, oldExchanges, oldRule, oldCollector := s.cfg, s.exchangeCfgs, s.universeRule, s.dataCollectionSvc
+	s.mu.RUnlock()
+
+	var changes []string
+	newRule := universeRule(cfg.Universe)
+	exchangesChanged := !reflect.DeepEqual(oldExchanges, cfg.Exchanges)
+	ruleChanged := !reflect.DeepEqual(oldRule, newRule)
+
+	// 先创建新的数据收集服务，失败时保持原配置不变
+	collector := oldCollector
+	if exchangesChanged || ruleChanged {
+		exchanges := oldCollector.Exchanges()
+		if exchangesChanged {
+			var err error
+			exchanges, err = services.NewExchanges(cfg.Exchanges)
+			if err != nil {
+				return nil, fmt.Errorf("创建交易所服务失败: %w", err)
+			}
+			changes = append(changes, diffExchanges(oldExchanges, cfg.Exchanges)...)
+		}
+
+		symbols := oldCollector.Symbols()
+		if ruleChanged {
+			symbols = cfg.Universe.Symbols
+			changes = append(changes, fmt.Sprintf("universe: %s → %s", describeRule(oldRule), describeRule(newRule)))
+		}
+
+		collector = services.NewDataCollectionService(exchanges, symbols)
+		if ruleChanged {
+			ctx, cancel := s.tickContext()
+			s.updateUniverse(ctx, collector, newRule)
+			cancel()
+		}
+	}
+
+	jobChanges, err := s.rescheduleJobs(oldCfg, cfg.Scheduler)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, jobChanges...)
+
+	if oldCfg.CollectTimeout != cfg.Scheduler.CollectTimeout {
+		changes = append(changes, fmt.Sprintf("采集超时: %s → %s", oldCfg.CollectTimeout, cfg.Scheduler.CollectTimeout))
+	}
+	if oldCfg.RetentionDays != cfg.Scheduler.RetentionDays {
+		changes = append(changes, fmt.Sprintf("数据保留天数: %d → %d", oldCfg.RetentionDays, cfg.Scheduler.RetentionDays))
+	}
+	if !reflect.DeepEqual(oldCfg.Retention, cfg.Scheduler.Retention) {
+		changes = append(changes, "数据保留策略已更新")
+	}
+	if oldCfg.IngestionMode != cfg.Scheduler.IngestionMode {
+		changes = append(changes, fmt.Sprintf("采集模式: %s → %s", oldCfg.IngestionMode, cfg.Scheduler.IngestionMode))
+	}
+
+	oldSymbols, newSymbols := oldCollector.Symbols(), collector.Symbols()
+	if !sameSymbols(oldSymbols, newSymbols) {
+		changes = append(changes, fmt.Sprintf("跟踪交易对: %v → %v", oldSymbols, newSymbols))
+	}
+
+	s.mu.Lock()
+	s.cfg = cfg.Scheduler
+	s.exchangeCfgs = cfg.Exchanges
+	s.universeRule = newRule
+	s.dataCollectionSvc = collector
+	s.mu.Unlock()
+
+	// 按新的采集模式、交易所和交易对调整推送订阅
+	switch {
+	case cfg.Scheduler.IngestionMode != IngestionModeStream:
+		s.stopAllStreams()
+	case !s.streaming() || exchangesChanged || !sameSymbols(oldSymbols, newSymbols):
+		s.startStreams(newSymbols)
+	}
+
+	if len(changes) == 0 {
+		log.Println("重新加载配置完成: 没有变化")
+	} else {
+		log.Printf("重新加载配置完成: %v", changes)
+	}
+	return changes, nil
+}
+
+// rescheduleJobs 重新注册执行周期有变化的定时任务并返回变更说明，其余任务保持原有调度。
+// 新的任务全部注册成功后才移除旧任务，任何一个注册失败时撤销已注册的新任务，原有调度不变
+func (s *DataScheduler) rescheduleJobs(oldCfg, newCfg config.SchedulerConfig) ([]string, error) {
+	var changed []job
+	for _, j := range s.jobs() {
+		if j.spec(oldCfg) != j.spec(newCfg) {
+			changed = append(changed, j)
+		}
+	}
+
+	added := make(map[string]cron.EntryID)
+	for _, j := range changed {
+		spec := j.spec(newCfg)
+		if spec == "" {
+			continue
+		}
+		id, err := s.cron.AddFunc(spec, j.run)
+		if err != nil {
+			for _, id := range added {
+				s.cron.Remove(id)
+			}
+			return nil, fmt.Errorf("添加%s任务失败: %w", j.name, err)
+		}
+		added[j.name] = id
+	}
+
+	var changes []string
+	for _, j := range changed {
+		if id, ok := s.entries[j.name]; ok {
+			s.cron.Remove(id)
+			delete(s.entries, j.name)
+		}
+		if id, ok := added[j.name]; ok {
+			s.entries[j.name] = id
+		}
+		changes = append(changes, fmt.Sprintf("%s任务执行周期: %s → %s", j.name, describeSpec(j.spec(oldCfg)), describeSpec(j.spec(newCfg))))
+	}
+	return changes, nil
+}
+
+// diffExchanges 比较交易所配置，返回变更说明
+func diffExchanges(oldCfgs, newCfgs map[string]config.ExchangeConfig) []string {
+	names := make(map[string]bool)
+	for name := range oldCfgs {
+		names[name] = true
+	}
+	for name := range newCfgs {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var changes []string
+	for _, name := range sorted {
+		oldCfg, newCfg := oldCfgs[name], newCfgs[name]
+		if oldCfg.Disabled != newCfg.Disabled {
+			state := "启用"
+			if newCfg.Disabled {
+				state = "停用"
+			}
+			changes = append(changes, fmt.Sprintf("exchanges.%s: %s", name, state))
+		}
+		if oldCfg.BaseURL != newCfg.BaseURL {
+			changes = append(changes, fmt.Sprintf("exchanges.%s.base_url: %s → %s", name, oldCfg.BaseURL, newCfg.BaseURL))
+		}
+		if oldCfg.WSURL != newCfg.WSURL {
+			changes = append(changes, fmt.Sprintf("exchanges.%s.ws_url: %s → %s", name, oldCfg.WSURL, newCfg.WSURL))
+		}
+		if oldCfg.Timeout != newCfg.Timeout {
+			changes = append(changes, fmt.Sprintf("exchanges.%s.timeout: %v → %v", name, oldCfg.Timeout, newCfg.Timeout))
+		}
+		if oldCfg.MaxAttempts != newCfg.MaxAttempts {
+			changes = append(changes, fmt.Sprintf("exchanges.%s.max_attempts: %d → %d", name, oldCfg.MaxAttempts, newCfg.MaxAttempts))
+		}
+		if oldCfg.RetryBackoff != newCfg.RetryBackoff {
+			changes = append(changes, fmt.Sprintf("exchanges.%s.retry_backoff: %v → %v", name, oldCfg.RetryBackoff, newCfg.RetryBackoff))
+		}
+		if oldCfg.BreakerThreshold != newCfg.BreakerThreshold {
+			changes = append(changes, fmt.Sprintf("exchanges.%s.breaker_threshold: %d → %d", name, oldCfg.BreakerThreshold, newCfg.BreakerThreshold))
+		}
+		if oldCfg.BreakerCooldown != newCfg.BreakerCooldown {
+			changes = append(changes, fmt.Sprintf("exchanges.%s.breaker_cooldown: %v → %v", name, oldCfg.BreakerCooldown, newCfg.BreakerCooldown))
+		}
+	}
+	return changes
+}
+
+// describeSpec 执行周期的文字说明，空周期表示任务未启用
+func describeSpec(spec string) string {
+	if spec == "" {
+		return "未启用"
+	}
+	return spec
+}
+
+// describeRule 交易对选择规则的文字说明
+func describeRule(rule services.UniverseRule) string {
+	return fmt.Sprintf("symbols=%v top_n=%d min_exchanges=%d", rule.Symbols, rule.TopN, rule.MinExchanges)
+}
@@ -0,0 +1,143 @@
+package scheduler
+
+import (
+	"CurrencyMonitor/config"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// newTestScheduler 创建按cfg注册了全部定时任务的调度器，不连接数据库，也不启动cron
+func newTestScheduler(t *testing.T, cfg config.SchedulerConfig) *DataScheduler {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	s := &DataScheduler{
+		cron:    cron.New(),
+		entries: make(map[string]cron.EntryID),
+		ctx:     ctx,
+		cancel:  cancel,
+		cfg:     cfg,
+	}
+	for _, j := range s.jobs() {
+		if err := s.addJob(j, cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+// copyEntries 复制任务名称到定时任务ID的映射
+func copyEntries(entries map[string]cron.EntryID) map[string]cron.EntryID {
+	copied := make(map[string]cron.EntryID, len(entries))
+	for name, id := range entries {
+		copied[name] = id
+	}
+	return copied
+}
+
+func TestRescheduleJobs(t *testing.T) {
+	oldCfg := config.Default().Scheduler
+	s := newTestScheduler(t, oldCfg)
+	before := copyEntries(s.entries)
+
+	newCfg := oldCfg
+	newCfg.CollectSpec = "*/10 * * * *"               // 修改执行周期
+	newCfg.RatioPeriods = []string{"5m", "30m", "1h"} // 添加30m，移除15m、4h、1d
+	newCfg.LiquidationSpec = ""                       // 停用
+
+	changes, err := s.rescheduleJobs(oldCfg, newCfg)
+	if err != nil {
+		t.Fatalf("重新注册任务失败: %v", err)
+	}
+	// 持仓量、资金费率、K线3个收集任务，强平任务，4个多空比粒度
+	if len(changes) != 8 {
+		t.Errorf("变更说明 = %v, 期望8条", changes)
+	}
+
+	for _, name := range []string{"多空比收集(15m)", "多空比收集(4h)", "多空比收集(1d)", "强平数据收集"} {
+		if _, ok := s.entries[name]; ok {
+			t.Errorf("%s任务没有被移除", name)
+		}
+		if s.cron.Entry(before[name]).Valid() {
+			t.Errorf("%s任务的旧定时任务仍在cron中", name)
+		}
+	}
+	if _, ok := s.entries["多空比收集(30m)"]; !ok {
+		t.Error("多空比收集(30m)任务没有被添加")
+	}
+	for _, name := range []string{"持仓量收集", "资金费率收集", "K线收集"} {
+		if id := s.entries[name]; id == before[name] || !s.cron.Entry(id).Valid() || s.cron.Entry(before[name]).Valid() {
+			t.Errorf("%s任务没有按新周期重新注册", name)
+		}
+	}
+	// 执行周期不变的任务保持原有调度
+	for _, name := range []string{"合约目录刷新", "多空比汇总", "数据清理", "多空比收集(5m)", "多空比收集(1h)"} {
+		if s.entries[name] != before[name] {
+			t.Errorf("%s任务不应重新注册", name)
+		}
+	}
+	if len(s.cron.Entries()) != len(s.entries) {
+		t.Errorf("cron中有%d个任务, 期望 %d", len(s.cron.Entries()), len(s.entries))
+	}
+}
+
+func TestRescheduleJobsKeepsOldEntriesOnError(t *testing.T) {
+	oldCfg := config.Default().Scheduler
+	s := newTestScheduler(t, oldCfg)
+	before := copyEntries(s.entries)
+
+	// 第一个任务可以注册，之后的任务周期无效
+	newCfg := oldCfg
+	newCfg.UniverseSpec = "30 * * * *"
+	newCfg.CollectSpec = "invalid"
+
+	if _, err := s.rescheduleJobs(oldCfg, newCfg); err == nil {
+		t.Fatal("无效的执行周期应返回错误")
+	}
+	if len(s.entries) != len(before) || len(s.cron.Entries()) != len(before) {
+		t.Fatalf("失败后任务数量 = %d/%d, 期望 %d", len(s.entries), len(s.cron.Entries()), len(before))
+	}
+	for name, id := range before {
+		if s.entries[name] != id || !s.cron.Entry(id).Valid() {
+			t.Errorf("失败后%s任务的调度被修改", name)
+		}
+	}
+}
+
+func TestTickContext(t *testing.T) {
+	cfg := config.Default().Scheduler
+	cfg.CollectTimeout = 50 * time.Millisecond
+	s := newTestScheduler(t, cfg)
+
+	// 超过采集超时时间后取消
+	ctx, cancel := s.tickContext()
+	defer cancel()
+	select {
+	case <-ctx.Done():
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			t.Errorf("超时后的错误 = %v", ctx.Err())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("超过采集超时时间后没有取消")
+	}
+
+	// 调度器停止时立即取消
+	cfg.CollectTimeout = time.Hour
+	s.cfg = cfg
+	ctx, cancel = s.tickContext()
+	defer cancel()
+	s.cancel()
+	select {
+	case <-ctx.Done():
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			t.Errorf("停止后的错误 = %v", ctx.Err())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("调度器停止后没有取消")
+	}
+}
@@ -1,127 +1,689 @@
 package scheduler
 
 import (
+	"CurrencyMonitor/config"
 	"CurrencyMonitor/database"
 	"CurrencyMonitor/models"
+	"CurrencyMonitor/retention"
 	"CurrencyMonitor/services"
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/robfig/cron/v3"
 )
 
+// 数据采集模式
+const (
+	IngestionModePoll   = "poll"   // 仅通过REST接口定时轮询
+	IngestionModeStream = "stream" // 在轮询之外维持WebSocket推送连接
+)
+
 // DataScheduler 数据调度器
 type DataScheduler struct {
-	cron              *cron.Cron
+	cron    *cron.Cron
+	entries map[string]cron.EntryID // 任务名称到定时任务ID的映射
+
+	// ctx 所有任务共用的根context，Stop时取消，进行中的交易所请求和数据库操作随之中止
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// mu 保护可在运行中重新加载的配置和数据收集服务
+	mu                sync.RWMutex
 	dataCollectionSvc *services.DataCollectionService
-	repo              *models.LongShortRatioRepository
-	symbols           []string
-}
+	cfg               config.SchedulerConfig
+	exchangeCfgs      map[string]config.ExchangeConfig
+	universeRule      services.UniverseRule
+	reloadMu          sync.Mutex
+
+	repo        *models.LongShortRatioRepository
+	rollupRepo  *models.RollupRepository
+	oiRepo      *models.OpenInterestRepository
+	fundingRepo *models.FundingRateRepository
+	liqRepo     *models.LiquidationRepository
+	klineRepo   *models.KlineRepository
+	symbolRepo  *models.SymbolRepository
+	cleaner     *retention.Cleaner
 
-// NewDataScheduler 创建新的数据调度器
-func NewDataScheduler() *DataScheduler {
-	symbols := []string{"BTCUSDT", "ETHUSDT"}
+	// rollupMu 保证汇总任务串行执行，rolledUpAt为上次汇总成功时已处理到的多空比更新时间
+	rollupMu   sync.Mutex
+	rolledUpAt time.Time
 
+	streamsMu   sync.Mutex
+	streams     []*services.StreamClient
+	stopStreams chan struct{}
+}
+
+// NewDataScheduler 使用调度配置、交易对选择规则和交易所服务创建数据调度器
+func NewDataScheduler(cfg *config.Config, exchanges []services.ExchangeService) *DataScheduler {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &DataScheduler{
 		cron:              cron.New(),
-		dataCollectionSvc: services.NewDataCollectionService(symbols),
+		entries:           make(map[string]cron.EntryID),
+		ctx:               ctx,
+		cancel:            cancel,
+		dataCollectionSvc: services.NewDataCollectionService(exchanges, cfg.Universe.Symbols),
 		repo:              models.NewLongShortRatioRepository(database.GetDB()),
-		symbols:           symbols,
+		rollupRepo:        models.NewRollupRepository(database.GetDB()),
+		oiRepo:            models.NewOpenInterestRepository(database.GetDB()),
+		fundingRepo:       models.NewFundingRateRepository(database.GetDB()),
+		liqRepo:           models.NewLiquidationRepository(database.GetDB()),
+		klineRepo:         models.NewKlineRepository(database.GetDB()),
+		symbolRepo:        models.NewSymbolRepository(database.GetDB()),
+		cleaner:           retention.NewCleaner(database.GetDB()),
+		cfg:               cfg.Scheduler,
+		exchangeCfgs:      cfg.Exchanges,
+		universeRule:      universeRule(cfg.Universe),
 	}
 }
 
-// Start 启动调度器
-func (s *DataScheduler) Start() error {
-	// 每15分钟收集一次数据
-	_, err := s.cron.AddFunc("*/15 * * * *", s.collectData)
-	if err != nil {
-		return fmt.Errorf("添加数据收集任务失败: %w", err)
+// job 定时任务
+type job struct {
+	name string                                  // 任务名称
+	spec func(cfg config.SchedulerConfig) string // 从配置中取执行周期
+	run  func()
+}
+
+// jobs 调度器的所有定时任务，执行周期为空的任务不注册
+func (s *DataScheduler) jobs() []job {
+	collectSpec := func(cfg config.SchedulerConfig) string { return cfg.CollectSpec }
+
+	jobs := []job{
+		// 刷新合约目录和跟踪的交易对
+		{name: "合约目录刷新", spec: func(cfg config.SchedulerConfig) string { return cfg.UniverseSpec }, run: s.refreshUniverse},
+		// 收集持仓量、资金费率和5分钟K线
+		{name: "持仓量收集", spec: collectSpec, run: s.collectOpenInterest},
+		{name: "资金费率收集", spec: collectSpec, run: s.collectFunding},
+		{name: "K线收集", spec: collectSpec, run: s.collectKlines},
+		// 通过REST接口收集强平数据
+		{name: "强平数据收集", spec: func(cfg config.SchedulerConfig) string { return cfg.LiquidationSpec }, run: s.collectLiquidations},
+		// 将多空比汇总为1h/4h/1d数据，供长时间范围的查询使用
+		{name: "多空比汇总", spec: func(cfg config.SchedulerConfig) string { return cfg.RollupSpec }, run: s.rollupRatios},
+		// 按保留策略清理旧数据
+		{name: "数据清理", spec: func(cfg config.SchedulerConfig) string { return cfg.CleanupSpec }, run: s.cleanupOldData},
+	}
+
+	// 每个时间粒度的多空比单独按周期边界收集，未配置的粒度不注册
+	for _, period := range config.RatioPeriods {
+		period := period
+		jobs = append(jobs, job{
+			name: fmt.Sprintf("多空比收集(%s)", period),
+			spec: func(cfg config.SchedulerConfig) string {
+				if !slices.Contains(cfg.RatioPeriods, period) {
+					return ""
+				}
+				return ratioSpec(period)
+			},
+			run: func() { s.collectRatios(period) },
+		})
+	}
+	return jobs
+}
+
+// ratioSpec 多空比的采集周期：在UTC周期边界后1分钟执行，此时交易所已发布刚结束的周期的数据
+func ratioSpec(period string) string {
+	d, _ := services.PeriodDuration(period)
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("CRON_TZ=UTC 1-59/%d * * * *", int(d.Minutes()))
+	case d == time.Hour:
+		return "CRON_TZ=UTC 1 * * * *"
+	case d < 24*time.Hour:
+		return fmt.Sprintf("CRON_TZ=UTC 1 */%d * * *", int(d.Hours()))
+	default:
+		return "CRON_TZ=UTC 1 0 * * *"
 	}
+}
 
-	// 每天凌晨2点清理7天前的旧数据
-	_, err = s.cron.AddFunc("0 2 * * *", s.cleanupOldData)
+// addJob 按配置注册定时任务
+func (s *DataScheduler) addJob(j job, cfg config.SchedulerConfig) error {
+	spec := j.spec(cfg)
+	if spec == "" {
+		delete(s.entries, j.name)
+		return nil
+	}
+	id, err := s.cron.AddFunc(spec, j.run)
 	if err != nil {
-		return fmt.Errorf("添加数据清理任务失败: %w", err)
+		return fmt.Errorf("添加%s任务失败: %w", j.name, err)
+	}
+	s.entries[j.name] = id
+	return nil
+}
+
+// collector 获取当前的数据收集服务
+func (s *DataScheduler) collector() *services.DataCollectionService {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.dataCollectionSvc
+}
+
+// config 获取当前的调度配置
+func (s *DataScheduler) config() config.SchedulerConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg
+}
+
+// Config 获取当前的调度配置，重新加载配置后会返回新的配置
+func (s *DataScheduler) Config() config.SchedulerConfig {
+	return s.config()
+}
+
+// DataCollectionService 获取当前的数据收集服务，重新加载配置后会返回新的实例
+func (s *DataScheduler) DataCollectionService() *services.DataCollectionService {
+	return s.collector()
+}
+
+// universeRule 将配置转换为交易对选择规则
+func universeRule(cfg config.UniverseConfig) services.UniverseRule {
+	return services.UniverseRule{
+		Symbols:      cfg.Symbols,
+		TopN:         cfg.TopN,
+		MinExchanges: cfg.MinExchanges,
+	}
+}
+
+// Start 启动调度器
+func (s *DataScheduler) Start() error {
+	// 启动时先刷新一次合约目录，确定跟踪的交易对
+	s.refreshUniverse()
+
+	cfg := s.config()
+	for _, j := range s.jobs() {
+		if err := s.addJob(j, cfg); err != nil {
+			return err
+		}
 	}
 
 	s.cron.Start()
 	log.Println("数据调度器启动成功")
 
 	// 立即执行一次数据收集
-	go s.collectData()
+	for _, period := range cfg.RatioPeriods {
+		go s.collectRatios(period)
+	}
+	go s.collectOpenInterest()
+	go s.collectFunding()
+	go s.collectLiquidations()
+	go s.collectKlines()
+	go s.rollupRatios()
+
+	// 推送模式下为支持推送的交易所建立长连接，REST轮询保留用于没有推送的数据
+	if cfg.IngestionMode == IngestionModeStream {
+		s.startStreams(s.collector().Symbols())
+	}
 
 	return nil
 }
 
-// Stop 停止调度器
+// Stop 停止调度器，取消所有进行中的任务
 func (s *DataScheduler) Stop() {
+	s.cancel()
+	s.stopAllStreams()
 	s.cron.Stop()
 	log.Println("数据调度器已停止")
 }
 
-// collectData 收集数据
-func (s *DataScheduler) collectData() {
-	log.Println("开始收集多空比数据...")
+// ratioCollectLimit 每次采集每个时间粒度获取的数据点数量，多取几个周期覆盖交易所延迟发布和上次采集失败的数据
+const ratioCollectLimit = 3
+
+// Context 获取调度器的根context，调度器停止时取消，用于在后台执行的手动任务
+func (s *DataScheduler) Context() context.Context {
+	return s.ctx
+}
+
+// tickContext 创建一次采集任务使用的context，超过采集超时时间或调度器停止后取消尚未完成的请求
+func (s *DataScheduler) tickContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(s.ctx, s.config().CollectTimeout)
+}
 
-	// 收集所有交易所的数据
-	data, err := s.dataCollectionSvc.CollectAllData()
+// logCollectErrors 记录采集失败的各个采集项，其余采集项的数据照常保存
+func logCollectErrors(what string, err error) {
+	var failed services.CollectErrors
+	if !errors.As(err, &failed) {
+		log.Printf("收集%s失败: %v", what, err)
+		return
+	}
+	log.Printf("收集%s时%d个采集项失败", what, len(failed))
+	for _, item := range failed {
+		log.Printf("  %v", item)
+	}
+}
+
+// collectRatios 收集指定时间粒度的多空比数据
+func (s *DataScheduler) collectRatios(period string) {
+	log.Printf("开始收集%s多空比数据...", period)
+
+	ctx, cancel := s.tickContext()
+	defer cancel()
+	data, err := s.collector().CollectRatios(ctx, period, ratioCollectLimit)
 	if err != nil {
-		log.Printf("收集数据失败: %v", err)
+		logCollectErrors(period+"多空比数据", err)
+	}
+
+	if len(data) == 0 {
+		log.Printf("没有收集到任何%s多空比数据", period)
+		return
+	}
+
+	// 在一个事务内批量保存到数据库
+	// 采集超时后仍保存已收集的数据，只在调度器停止时中止
+	if err := s.repo.UpsertMany(s.ctx, services.RatioModels(data)); err != nil {
+		log.Printf("保存%s多空比数据失败: %v", period, err)
 		return
 	}
 
+	log.Printf("%s多空比收集完成: 收集并保存%d条", period, len(data))
+}
+
+// rollupSourcePeriod 汇总使用的原始数据粒度：配置中最细的多空比时间粒度
+func rollupSourcePeriod(cfg config.SchedulerConfig) string {
+	for _, period := range config.RatioPeriods {
+		if slices.Contains(cfg.RatioPeriods, period) {
+			return period
+		}
+	}
+	return models.DefaultRatioPeriod
+}
+
+// rollupRatios 将新写入或更新的多空比重新汇总到1h/4h/1d汇总表。
+// 启动后第一次执行汇总保留天数内的全部数据，之后只重新计算有数据变化的区间
+func (s *DataScheduler) rollupRatios() {
+	s.rollupMu.Lock()
+	defer s.rollupMu.Unlock()
+
+	cfg := s.config()
+	source := rollupSourcePeriod(cfg)
+	// 留出余量，覆盖开始汇总前已写入但尚未提交的数据
+	startedAt := time.Now().Add(-time.Minute)
+
+	since := time.Now().AddDate(0, 0, -cfg.RetentionDays)
+	if !s.rolledUpAt.IsZero() {
+		earliest, ok, err := s.repo.GetEarliestUpdated(s.ctx, source, s.rolledUpAt)
+		if err != nil {
+			log.Printf("查询待汇总的多空比数据失败: %v", err)
+			return
+		}
+		if !ok {
+			return
+		}
+		since = earliest
+	}
+
+	sourceDuration, _ := services.PeriodDuration(source)
+	for _, resolution := range models.RollupResolutions {
+		d, _ := services.PeriodDuration(resolution)
+		if d <= sourceDuration {
+			continue
+		}
+		count, err := s.rollupRepo.Refresh(s.ctx, resolution, d, source, since)
+		if err != nil {
+			log.Printf("汇总%s多空比数据失败: %v", resolution, err)
+			return
+		}
+		log.Printf("%s多空比汇总完成: 从%s开始更新%d条", resolution, since.Format("2006-01-02 15:04"), count)
+	}
+	s.rolledUpAt = startedAt
+}
+
+// collectOpenInterest 收集持仓量数据
+func (s *DataScheduler) collectOpenInterest() {
+	log.Println("开始收集持仓量数据...")
+
+	ctx, cancel := s.tickContext()
+	defer cancel()
+	data, err := s.collector().CollectOpenInterest(ctx)
+	if err != nil {
+		logCollectErrors("持仓量", err)
+	}
+
 	if len(data) == 0 {
-		log.Println("没有收集到任何数据")
+		log.Println("没有收集到任何持仓量数据")
 		return
 	}
 
-	// 保存到数据库
 	var savedCount int
-	var errors []string
-
 	for _, item := range data {
-		ratio := &models.LongShortRatio{
-			Exchange:  item.Exchange,
-			Symbol:    item.Symbol,
-			Ratio:     item.Ratio,
-			Timestamp: item.Timestamp,
+		if err := s.saveOpenInterest(s.ctx, item); err != nil {
+			log.Printf("保存%s-%s持仓量失败: %v", item.Exchange, item.Symbol, err)
+		} else {
+			savedCount++
 		}
+	}
 
-		if err := s.repo.CreateOrUpdate(ratio); err != nil {
-			errorMsg := fmt.Sprintf("保存%s-%s数据失败: %v", item.Exchange, item.Symbol, err)
-			errors = append(errors, errorMsg)
-			log.Println(errorMsg)
+	log.Printf("持仓量收集完成: 收集%d条，保存%d条", len(data), savedCount)
+}
+
+// collectFunding 收集资金费率数据
+func (s *DataScheduler) collectFunding() {
+	log.Println("开始收集资金费率数据...")
+
+	ctx, cancel := s.tickContext()
+	defer cancel()
+	history, predicted, err := s.collector().CollectFunding(ctx)
+	if err != nil {
+		logCollectErrors("资金费率", err)
+	}
+
+	var savedCount int
+	for _, item := range history {
+		if err := s.saveFundingRate(s.ctx, item); err != nil {
+			log.Printf("保存%s-%s资金费率失败: %v", item.Exchange, item.Symbol, err)
 		} else {
 			savedCount++
 		}
 	}
 
-	log.Printf("数据收集完成: 收集%d条，保存%d条", len(data), savedCount)
+	for _, item := range predicted {
+		if err := s.savePredictedFunding(s.ctx, item); err != nil {
+			log.Printf("保存%s-%s预测资金费率失败: %v", item.Exchange, item.Symbol, err)
+		}
+	}
+
+	log.Printf("资金费率收集完成: 结算费率%d条（保存%d条），预测费率%d条", len(history), savedCount, len(predicted))
+}
+
+// collectLiquidations 通过REST接口收集强平数据
+func (s *DataScheduler) collectLiquidations() {
+	ctx, cancel := s.tickContext()
+	defer cancel()
+	data, err := s.collector().CollectLiquidations(ctx)
+	if err != nil {
+		logCollectErrors("强平数据", err)
+	}
 
-	if len(errors) > 0 {
-		log.Printf("保存过程中发生%d个错误", len(errors))
+	var savedCount int
+	for _, item := range data {
+		created, err := s.saveLiquidation(s.ctx, item)
+		if err != nil {
+			log.Printf("保存%s-%s强平数据失败: %v", item.Exchange, item.Symbol, err)
+		} else if created {
+			savedCount++
+		}
 	}
+
+	log.Printf("强平数据收集完成: 收集%d条，新增%d条", len(data), savedCount)
 }
 
-// cleanupOldData 清理旧数据
-func (s *DataScheduler) cleanupOldData() {
-	log.Println("开始清理旧数据...")
+// saveLiquidation 保存单条强平事件
+func (s *DataScheduler) saveLiquidation(ctx context.Context, item *services.LiquidationData) (bool, error) {
+	return s.liqRepo.CreateIfNotExists(ctx, &models.Liquidation{
+		Exchange:  item.Exchange,
+		Symbol:    item.Symbol,
+		Side:      item.Side,
+		Price:     item.Price,
+		Quantity:  item.Quantity,
+		Notional:  item.Notional,
+		Timestamp: item.Timestamp,
+	})
+}
+
+// collectKlines 收集K线数据
+func (s *DataScheduler) collectKlines() {
+	log.Println("开始收集K线数据...")
 
-	// 删除7天前的数据
-	cutoff := time.Now().AddDate(0, 0, -7)
-	err := s.repo.DeleteOldData(cutoff)
+	// 按采集间隔内产生的5分钟K线数量获取，多取一根覆盖上次未收盘的K线
+	ctx, cancel := s.tickContext()
+	defer cancel()
+	data, err := s.collector().CollectKlines(ctx, "5m", klineLimit(s.config().CollectSpec, 5*time.Minute))
 	if err != nil {
-		log.Printf("清理旧数据失败: %v", err)
+		logCollectErrors("K线", err)
+	}
+
+	var savedCount int
+	for _, item := range data {
+		if err := s.klineRepo.CreateOrUpdate(s.ctx, klineModel(item)); err != nil {
+			log.Printf("保存%s-%s K线失败: %v", item.Exchange, item.Symbol, err)
+		} else {
+			savedCount++
+		}
+	}
+
+	log.Printf("K线收集完成: 收集%d条，保存%d条", len(data), savedCount)
+}
+
+// startStreams 为支持推送的交易所启动流客户端，已有的流客户端会先停止
+func (s *DataScheduler) startStreams(symbols []string) {
+	s.streamsMu.Lock()
+	defer s.streamsMu.Unlock()
+
+	if s.stopStreams != nil {
+		close(s.stopStreams)
+	}
+	s.stopStreams = make(chan struct{})
+	s.streams = nil
+
+	for _, streamer := range s.collector().StreamingServices() {
+		client := streamer.NewStream(symbols, s.handleStreamEvent)
+		s.streams = append(s.streams, client)
+		log.Printf("订阅%s推送...", streamer.Name())
+		go client.Run(s.stopStreams)
+	}
+}
+
+// stopAllStreams 停止所有流客户端
+func (s *DataScheduler) stopAllStreams() {
+	s.streamsMu.Lock()
+	defer s.streamsMu.Unlock()
+
+	if s.stopStreams != nil {
+		close(s.stopStreams)
+		s.stopStreams = nil
+	}
+	s.streams = nil
+}
+
+// streaming 是否有运行中的流客户端
+func (s *DataScheduler) streaming() bool {
+	s.streamsMu.Lock()
+	defer s.streamsMu.Unlock()
+	return s.stopStreams != nil
+}
+
+// refreshUniverse 刷新合约目录并按规则更新跟踪的交易对
+func (s *DataScheduler) refreshUniverse() {
+	s.mu.RLock()
+	collector, rule := s.dataCollectionSvc, s.universeRule
+	s.mu.RUnlock()
+
+	ctx, cancel := s.tickContext()
+	defer cancel()
+
+	previous := collector.Symbols()
+	if !s.updateUniverse(ctx, collector, rule) {
 		return
 	}
 
-	log.Printf("旧数据清理完成，删除了%s之前的数据", cutoff.Format("2006-01-02 15:04:05"))
+	// 跟踪范围变化时重新建立推送订阅
+	if symbols := collector.Symbols(); s.streaming() && !sameSymbols(previous, symbols) {
+		s.startStreams(symbols)
+	}
 }
 
-// CollectDataNow 立即收集数据（用于手动触发）
+// updateUniverse 刷新合约目录并按规则更新collector跟踪的交易对，失败时保持原交易对
+func (s *DataScheduler) updateUniverse(ctx context.Context, collector *services.DataCollectionService, rule services.UniverseRule) bool {
+	log.Println("开始刷新合约目录...")
+
+	infos, err := collector.CollectSymbols(ctx)
+	if err != nil {
+		log.Printf("刷新合约目录失败: %v，继续使用当前交易对", err)
+		return false
+	}
+
+	listed := make(map[string][]string)
+	for _, info := range infos {
+		err := s.symbolRepo.CreateOrUpdate(s.ctx, &models.Symbol{
+			Exchange:        info.Exchange,
+			Symbol:          info.Symbol,
+			ExchangeSymbol:  info.ExchangeSymbol,
+			BaseAsset:       info.BaseAsset,
+			QuoteAsset:      info.QuoteAsset,
+			OpenInterestUSD: info.OpenInterestUSD,
+		})
+		if err != nil {
+			log.Printf("保存%s-%s合约信息失败: %v", info.Exchange, info.Symbol, err)
+			continue
+		}
+		listed[info.Exchange] = append(listed[info.Exchange], info.Symbol)
+	}
+
+	// 只处理本次成功返回列表的交易所，避免接口失败时误判下线
+	for exchange, symbols := range listed {
+		if err := s.symbolRepo.MarkDelisted(s.ctx, exchange, symbols); err != nil {
+			log.Printf("更新%s下线合约失败: %v", exchange, err)
+		}
+	}
+
+	symbols := services.SelectUniverse(infos, rule)
+	if len(symbols) == 0 {
+		log.Println("选择规则没有选出任何交易对，继续使用当前交易对")
+		return false
+	}
+
+	if err := s.symbolRepo.SetTracked(s.ctx, symbols); err != nil {
+		log.Printf("保存跟踪交易对失败: %v", err)
+	}
+
+	collector.SetSymbols(symbols)
+	log.Printf("合约目录刷新完成: %d个合约，跟踪交易对%v", len(infos), symbols)
+	return true
+}
+
+// sameSymbols 判断两个交易对列表是否包含相同的交易对
+func sameSymbols(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]bool, len(a))
+	for _, symbol := range a {
+		seen[symbol] = true
+	}
+	for _, symbol := range b {
+		if !seen[symbol] {
+			return false
+		}
+	}
+	return true
+}
+
+// handleStreamEvent 将推送事件写入与轮询相同的持久化路径
+func (s *DataScheduler) handleStreamEvent(event *services.StreamEvent) {
+	var err error
+	var exchange, symbol string
+
+	switch {
+	case event.Kline != nil:
+		exchange, symbol = event.Kline.Exchange, event.Kline.Symbol
+		err = s.klineRepo.CreateOrUpdate(s.ctx, klineModel(event.Kline))
+	case event.OpenInterest != nil:
+		exchange, symbol = event.OpenInterest.Exchange, event.OpenInterest.Symbol
+		err = s.saveOpenInterest(s.ctx, event.OpenInterest)
+	case event.PredictedFunding != nil:
+		exchange, symbol = event.PredictedFunding.Exchange, event.PredictedFunding.Symbol
+		err = s.savePredictedFunding(s.ctx, event.PredictedFunding)
+	case event.Liquidation != nil:
+		exchange, symbol = event.Liquidation.Exchange, event.Liquidation.Symbol
+		_, err = s.saveLiquidation(s.ctx, event.Liquidation)
+	}
+
+	if err != nil {
+		log.Printf("保存%s-%s推送数据失败: %v", exchange, symbol, err)
+	}
+}
+
+// saveOpenInterest 保存单条持仓量数据
+func (s *DataScheduler) saveOpenInterest(ctx context.Context, item *services.OpenInterestData) error {
+	return s.oiRepo.CreateOrUpdate(ctx, &models.OpenInterest{
+		Exchange:     item.Exchange,
+		Symbol:       item.Symbol,
+		OpenInterest: item.OpenInterest,
+		Notional:     item.Notional,
+		Timestamp:    item.Timestamp,
+	})
+}
+
+// saveFundingRate 保存单条已结算资金费率
+func (s *DataScheduler) saveFundingRate(ctx context.Context, item *services.FundingRateData) error {
+	return s.fundingRepo.CreateOrUpdate(ctx, &models.FundingRate{
+		Exchange:    item.Exchange,
+		Symbol:      item.Symbol,
+		Rate:        item.Rate,
+		FundingTime: item.FundingTime,
+	})
+}
+
+// savePredictedFunding 保存单条预测资金费率
+func (s *DataScheduler) savePredictedFunding(ctx context.Context, item *services.PredictedFundingData) error {
+	return s.fundingRepo.SavePredicted(ctx, &models.PredictedFunding{
+		Exchange:        item.Exchange,
+		Symbol:          item.Symbol,
+		Rate:            item.Rate,
+		NextFundingTime: item.NextFundingTime,
+		Timestamp:       item.Timestamp,
+	})
+}
+
+// klineLimit 计算两次采集之间产生的K线数量（多取一根），无法解析采集周期时取4根
+func klineLimit(spec string, period time.Duration) int {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return 4
+	}
+	next := schedule.Next(time.Now())
+	limit := int(schedule.Next(next).Sub(next)/period) + 1
+
+	// OKX单次最多返回300根K线
+	if limit > 300 {
+		limit = 300
+	}
+	return limit
+}
+
+// klineModel 将K线数据转换为数据库模型
+func klineModel(item *services.KlineData) *models.Kline {
+	return &models.Kline{
+		Exchange: item.Exchange,
+		Symbol:   item.Symbol,
+		Period:   item.Period,
+		OpenTime: item.OpenTime,
+		Open:     item.Open,
+		High:     item.High,
+		Low:      item.Low,
+		Close:    item.Close,
+		Volume:   item.Volume,
+	}
+}
+
+// cleanupOldData 按保留策略清理旧数据
+func (s *DataScheduler) cleanupOldData() {
+	s.CleanupNow(s.ctx)
+}
+
+// CleanupNow 立即按保留策略清理旧数据并返回清理报告（用于手动触发），ctx取消时中止尚未完成的清理
+func (s *DataScheduler) CleanupNow(ctx context.Context) retention.Report {
+	log.Println("开始清理旧数据...")
+
+	cfg := s.config()
+	report := s.cleaner.Run(ctx, cfg.Retention, cfg.RetentionDays)
+
+	log.Printf("旧数据清理完成: 删除%d条，归档%d条，耗时%v", report.Deleted, report.Archived, report.FinishedAt.Sub(report.StartedAt))
+	return report
+}
+
+// LastCleanupReport 获取最近一次清理的报告，还没有执行过时返回nil
+func (s *DataScheduler) LastCleanupReport() *retention.Report {
+	return s.cleaner.LastReport()
+}
+
+// CollectDataNow 立即收集所有配置的时间粒度的多空比数据（用于手动触发）
 func (s *DataScheduler) CollectDataNow() error {
-	s.collectData()
+	for _, period := range s.config().RatioPeriods {
+		s.collectRatios(period)
+	}
 	return nil
 }
 
@@ -134,11 +696,22 @@ func (s *DataScheduler) GetStatus() map[string]interface{} {
 		nextRuns = append(nextRuns, entry.Next.Format("2006-01-02 15:04:05"))
 	}
 
+	var streams []map[string]interface{}
+	s.streamsMu.Lock()
+	for _, client := range s.streams {
+		streams = append(streams, client.GetStatus())
+	}
+	s.streamsMu.Unlock()
+
 	return map[string]interface{}{
 		"running":      len(entries) > 0,
 		"tasks_count":  len(entries),
 		"next_runs":    nextRuns,
-		"symbols":      s.symbols,
+		"symbols":      s.collector().Symbols(),
+		"mode":         s.config().IngestionMode,
+		"streams":      streams,
+		"rate_limits":  services.RateLimitStatus(),
+		"breakers":     services.CircuitBreakerStatus(),
 		"last_updated": time.Now().Format("2006-01-02 15:04:05"),
 	}
 }
@@ -1,14 +1,19 @@
 package services
 
 import (
+	"CurrencyMonitor/config"
 	"CurrencyMonitor/database"
 	"CurrencyMonitor/models"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/gorilla/websocket"
 )
 
 // BinanceLongShortRatioResponse Binance多空比API响应结构
@@ -17,77 +22,267 @@ type BinanceLongShortRatioResponse struct {
 	LongShortRatio string `json:"longShortRatio"`
 	LongAccount    string `json:"longAccount"`
 	ShortAccount   string `json:"shortAccount"`
+	BuySellRatio   string `json:"buySellRatio"` // 仅主动买卖量接口返回
 	Timestamp      int64  `json:"timestamp"`
 }
 
+// value 获取响应中的比值
+func (r BinanceLongShortRatioResponse) value() string {
+	if r.BuySellRatio != "" {
+		return r.BuySellRatio
+	}
+	return r.LongShortRatio
+}
+
+// BinanceOpenInterestResponse Binance持仓量历史API响应结构
+type BinanceOpenInterestResponse struct {
+	Symbol               string `json:"symbol"`
+	SumOpenInterest      string `json:"sumOpenInterest"`
+	SumOpenInterestValue string `json:"sumOpenInterestValue"`
+	Timestamp            int64  `json:"timestamp"`
+}
+
+// BinanceFundingRateResponse Binance资金费率历史API响应结构
+type BinanceFundingRateResponse struct {
+	Symbol      string `json:"symbol"`
+	FundingRate string `json:"fundingRate"`
+	FundingTime int64  `json:"fundingTime"`
+}
+
+// BinancePremiumIndexResponse Binance标记价格与预测资金费率API响应结构
+type BinancePremiumIndexResponse struct {
+	Symbol          string `json:"symbol"`
+	MarkPrice       string `json:"markPrice"`
+	LastFundingRate string `json:"lastFundingRate"`
+	NextFundingTime int64  `json:"nextFundingTime"`
+	Time            int64  `json:"time"`
+}
+
+// BinanceExchangeInfoResponse Binance交易规则API响应结构
+type BinanceExchangeInfoResponse struct {
+	Symbols []struct {
+		Symbol       string `json:"symbol"`
+		ContractType string `json:"contractType"`
+		Status       string `json:"status"`
+		BaseAsset    string `json:"baseAsset"`
+		QuoteAsset   string `json:"quoteAsset"`
+	} `json:"symbols"`
+}
+
+// BinanceStreamEvent Binance推送消息结构（强平、K线、标记价格）
+type BinanceStreamEvent struct {
+	EventType       string `json:"e"`
+	EventTime       int64  `json:"E"`
+	Symbol          string `json:"s"`
+	FundingRate     string `json:"r"` // 标记价格推送：预测资金费率
+	NextFundingTime int64  `json:"T"` // 标记价格推送：下一次结算时间
+	Order           *struct {
+		Symbol       string `json:"s"`
+		Side         string `json:"S"`
+		AveragePrice string `json:"ap"`
+		Price        string `json:"p"`
+		FilledQty    string `json:"z"`
+		OriginalQty  string `json:"q"`
+		TradeTime    int64  `json:"T"`
+	} `json:"o"`
+	Kline *struct {
+		OpenTime int64  `json:"t"`
+		Symbol   string `json:"s"`
+		Interval string `json:"i"`
+		Open     string `json:"o"`
+		High     string `json:"h"`
+		Low      string `json:"l"`
+		Close    string `json:"c"`
+		Volume   string `json:"v"`
+		Closed   bool   `json:"x"` // K线是否已收盘
+	} `json:"k"`
+}
+
+// BinanceErrorResponse Binance错误响应结构
+type BinanceErrorResponse struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+// binanceErrorKinds Binance错误码对应的错误类型
+var binanceErrorKinds = map[int]error{
+	-1003: ErrRateLimited, // 请求权重超限
+	-1121: ErrBadSymbol,   // 交易对无效
+}
+
+// binanceMetricEndpoints 指标类型到Binance接口路径的映射
+var binanceMetricEndpoints = map[string]string{
+	MetricGlobalAccount: "/futures/data/globalLongShortAccountRatio",
+	MetricTopAccount:    "/futures/data/topLongShortAccountRatio",
+	MetricTopPosition:   "/futures/data/topLongShortPositionRatio",
+	MetricTakerVolume:   "/futures/data/takerlongshortRatio",
+}
+
+// binanceRateLimits Binance限流规则：每个IP每分钟2400请求权重，已用权重在X-MBX-USED-WEIGHT-1M响应头中返回
+var binanceRateLimits = rateLimits{
+	total:      quota{limit: 2400, interval: time.Minute},
+	usedHeader: "X-MBX-USED-WEIGHT-1M",
+	endpoints: []endpointQuota{
+		// 统计数据接口每个IP每5分钟1000次，不计入请求权重
+		{prefix: "/futures/data/", quota: quota{limit: 1000, interval: 5 * time.Minute}, weight: func(url.Values) int { return 0 }},
+		// 资金费率历史除请求权重外还与其他交易对共用每5分钟500次的额度
+		{prefix: "/fapi/v1/fundingRate", quota: quota{limit: 500, interval: 5 * time.Minute}},
+		{prefix: "/fapi/v1/klines", weight: binanceKlineWeight},
+	},
+}
+
+// binanceKlineWeight K线接口的请求权重随limit增加
+func binanceKlineWeight(query url.Values) int {
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil {
+		limit = 500
+	}
+	switch {
+	case limit < 100:
+		return 1
+	case limit < 500:
+		return 2
+	case limit <= 1000:
+		return 5
+	default:
+		return 10
+	}
+}
+
 // BinanceService Binance服务
 type BinanceService struct {
 	baseURL string
-	client  *http.Client
+	wsURL   string
+	http    *exchangeClient
+}
+
+func init() {
+	RegisterExchange("binance", func(cfg config.ExchangeConfig) ExchangeService { return NewBinanceService(cfg) })
 }
 
-// NewBinanceService 创建新的Binance服务
-func NewBinanceService() *BinanceService {
+// NewBinanceService 使用交易所配置创建Binance服务
+func NewBinanceService(cfg config.ExchangeConfig) *BinanceService {
 	return &BinanceService{
-		baseURL: "https://fapi.binance.com",
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		baseURL: cfg.BaseURL,
+		wsURL:   cfg.WSURL,
+		http:    newExchangeClient("Binance", cfg, rateLimiter("binance", binanceRateLimits), circuitBreakers("binance", cfg)),
 	}
 }
 
-// GetLongShortRatio 获取多空比数据
-func (b *BinanceService) GetLongShortRatio(symbol string) (*LongShortRatioData, error) {
-	return b.GetLongShortRatioWithPeriod(symbol, "5m", 1)
+// Name 交易所名称
+func (b *BinanceService) Name() string {
+	return "binance"
 }
 
-// GetLongShortRatioWithPeriod 获取指定周期的多空比数据
-func (b *BinanceService) GetLongShortRatioWithPeriod(symbol, period string, limit int) (*LongShortRatioData, error) {
-	url := fmt.Sprintf("%s/futures/data/globalLongShortAccountRatio?symbol=%s&period=%s&limit=%d",
-		b.baseURL, symbol, period, limit)
+// GetLongShortRatio 获取多空比数据
+func (b *BinanceService) GetLongShortRatio(ctx context.Context, symbol string) (*LongShortRatioData, error) {
+	return b.GetLongShortRatioWithPeriod(ctx, symbol, "5m", 1)
+}
 
-	resp, err := b.client.Get(url)
+// GetLongShortRatioWithPeriod 获取指定周期的最新多空比数据
+func (b *BinanceService) GetLongShortRatioWithPeriod(ctx context.Context, symbol, period string, limit int) (*LongShortRatioData, error) {
+	ratios, err := b.GetLongShortRatioHistory(ctx, symbol, period, limit)
 	if err != nil {
-		return nil, fmt.Errorf("请求Binance API失败: %w", err)
+		return nil, err
+	}
+	if len(ratios) == 0 {
+		return nil, fmt.Errorf("没有获取到多空比数据")
+	}
+	return ratios[len(ratios)-1], nil
+}
+
+// GetLongShortRatioHistory 获取多空比历史数据
+func (b *BinanceService) GetLongShortRatioHistory(ctx context.Context, symbol, period string, limit int) ([]*LongShortRatioData, error) {
+	return b.GetMetricHistory(ctx, symbol, MetricGlobalAccount, period, limit)
+}
+
+// SupportedMetrics 获取支持的指标类型
+func (b *BinanceService) SupportedMetrics() []string {
+	return Metrics
+}
+
+// GetMetricHistory 获取指定指标的历史数据
+func (b *BinanceService) GetMetricHistory(ctx context.Context, symbol, metric, period string, limit int) ([]*LongShortRatioData, error) {
+	return b.metricHistory(ctx, symbol, metric, period, limit, time.Time{}, time.Time{})
+}
+
+// GetLongShortRatioRange 获取时间范围内的全市场账户多空比，单次最多返回500条
+func (b *BinanceService) GetLongShortRatioRange(ctx context.Context, symbol, period string, start, end time.Time) ([]*LongShortRatioData, error) {
+	return b.metricHistory(ctx, symbol, MetricGlobalAccount, period, 500, start, end)
+}
+
+// RatioLookback Binance统计数据只保留最近30天
+func (b *BinanceService) RatioLookback(period string) time.Duration {
+	return 30 * 24 * time.Hour
+}
+
+// metricHistory 获取指定指标的历史数据，start和end不为零时只查询该时间范围
+func (b *BinanceService) metricHistory(ctx context.Context, symbol, metric, period string, limit int, start, end time.Time) ([]*LongShortRatioData, error) {
+	endpoint, ok := binanceMetricEndpoints[metric]
+	if !ok {
+		return nil, fmt.Errorf("Binance不支持指标: %s", metric)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Binance API返回错误状态码: %d", resp.StatusCode)
+	url := fmt.Sprintf("%s%s?symbol=%s&period=%s&limit=%d",
+		b.baseURL, endpoint, symbol, period, limit)
+	if !start.IsZero() {
+		url += fmt.Sprintf("&startTime=%d&endTime=%d", start.UnixMilli(), end.UnixMilli())
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
+	// 创建日志记录
+	apiLog := &models.APILog{
+		Exchange: "binance",
+		Symbol:   symbol,
+		Period:   period,
+		Limit:    limit,
+		URL:      url,
 	}
 
 	var ratios []BinanceLongShortRatioResponse
-	if err := json.Unmarshal(body, &ratios); err != nil {
-		return nil, fmt.Errorf("解析JSON失败: %w", err)
+	if err := b.fetchJSON(ctx, apiLog, &ratios); err != nil {
+		return nil, err
 	}
 
-	if len(ratios) == 0 {
-		return nil, fmt.Errorf("没有获取到多空比数据")
+	var results []*LongShortRatioData
+	for _, ratio := range ratios {
+		ratioValue, err := strconv.ParseFloat(ratio.value(), 64)
+		if err != nil {
+			continue
+		}
+
+		results = append(results, &LongShortRatioData{
+			Exchange:  "binance",
+			Symbol:    symbol,
+			Metric:    metric,
+			Period:    period,
+			Ratio:     ratioValue,
+			Timestamp: time.Unix(ratio.Timestamp/1000, 0),
+		})
 	}
 
-	ratio := ratios[0]
-	ratioValue, err := strconv.ParseFloat(ratio.LongShortRatio, 64)
+	// 记录成功的日志
+	apiLog.Success = true
+	apiLog.DataCount = len(results)
+	b.saveLog(ctx, apiLog)
+
+	return results, nil
+}
+
+// GetOpenInterest 获取最新持仓量
+func (b *BinanceService) GetOpenInterest(ctx context.Context, symbol string) (*OpenInterestData, error) {
+	data, err := b.GetOpenInterestHistory(ctx, symbol, "5m", 1)
 	if err != nil {
-		return nil, fmt.Errorf("解析多空比数值失败: %w", err)
+		return nil, err
 	}
-
-	return &LongShortRatioData{
-		Exchange:  "binance",
-		Symbol:    symbol,
-		Ratio:     ratioValue,
-		Timestamp: time.Unix(ratio.Timestamp/1000, 0),
-	}, nil
+	if len(data) == 0 {
+		return nil, fmt.Errorf("没有获取到持仓量数据")
+	}
+	return data[len(data)-1], nil
 }
 
-// GetLongShortRatioHistory 获取多空比历史数据
-func (b *BinanceService) GetLongShortRatioHistory(symbol, period string, limit int) ([]*LongShortRatioData, error) {
-	startTime := time.Now()
-	url := fmt.Sprintf("%s/futures/data/globalLongShortAccountRatio?symbol=%s&period=%s&limit=%d",
+// GetOpenInterestHistory 获取持仓量历史数据
+func (b *BinanceService) GetOpenInterestHistory(ctx context.Context, symbol, period string, limit int) ([]*OpenInterestData, error) {
+	url := fmt.Sprintf("%s/futures/data/openInterestHist?symbol=%s&period=%s&limit=%d",
 		b.baseURL, symbol, period, limit)
 
 	// 创建日志记录
@@ -99,91 +294,375 @@ func (b *BinanceService) GetLongShortRatioHistory(symbol, period string, limit i
 		URL:      url,
 	}
 
-	resp, err := b.client.Get(url)
-	responseTime := time.Since(startTime).Milliseconds()
-	apiLog.ResponseTime = responseTime
-
-	if err != nil {
-		apiLog.Success = false
-		apiLog.ErrorMsg = err.Error()
-		apiLog.StatusCode = 0
-		b.saveLog(apiLog)
-		return nil, fmt.Errorf("请求Binance API失败: %w", err)
+	var items []BinanceOpenInterestResponse
+	if err := b.fetchJSON(ctx, apiLog, &items); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	apiLog.StatusCode = resp.StatusCode
-	if resp.StatusCode != http.StatusOK {
-		apiLog.Success = false
-		apiLog.ErrorMsg = fmt.Sprintf("HTTP状态码: %d", resp.StatusCode)
-		b.saveLog(apiLog)
-		return nil, fmt.Errorf("Binance API返回错误状态码: %d", resp.StatusCode)
+	var results []*OpenInterestData
+	for _, item := range items {
+		openInterest, err := strconv.ParseFloat(item.SumOpenInterest, 64)
+		if err != nil {
+			continue
+		}
+		notional, err := strconv.ParseFloat(item.SumOpenInterestValue, 64)
+		if err != nil {
+			continue
+		}
+
+		results = append(results, &OpenInterestData{
+			Exchange:     "binance",
+			Symbol:       symbol,
+			OpenInterest: openInterest,
+			Notional:     notional,
+			Timestamp:    time.Unix(item.Timestamp/1000, 0),
+		})
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		apiLog.Success = false
-		apiLog.ErrorMsg = err.Error()
-		b.saveLog(apiLog)
-		return nil, fmt.Errorf("读取响应失败: %w", err)
+	// 记录成功的日志
+	apiLog.Success = true
+	apiLog.DataCount = len(results)
+	b.saveLog(ctx, apiLog)
+
+	return results, nil
+}
+
+// GetFundingRateHistory 获取已结算资金费率历史
+func (b *BinanceService) GetFundingRateHistory(ctx context.Context, symbol string, limit int) ([]*FundingRateData, error) {
+	url := fmt.Sprintf("%s/fapi/v1/fundingRate?symbol=%s&limit=%d", b.baseURL, symbol, limit)
+
+	// 创建日志记录
+	apiLog := &models.APILog{
+		Exchange: "binance",
+		Symbol:   symbol,
+		Period:   "8h",
+		Limit:    limit,
+		URL:      url,
 	}
 
-	var ratios []BinanceLongShortRatioResponse
-	if err := json.Unmarshal(body, &ratios); err != nil {
-		apiLog.Success = false
-		apiLog.ErrorMsg = err.Error()
-		b.saveLog(apiLog)
-		return nil, fmt.Errorf("解析JSON失败: %w", err)
+	var items []BinanceFundingRateResponse
+	if err := b.fetchJSON(ctx, apiLog, &items); err != nil {
+		return nil, err
 	}
 
-	var results []*LongShortRatioData
-	for _, ratio := range ratios {
-		ratioValue, err := strconv.ParseFloat(ratio.LongShortRatio, 64)
+	var results []*FundingRateData
+	for _, item := range items {
+		rate, err := strconv.ParseFloat(item.FundingRate, 64)
 		if err != nil {
 			continue
 		}
 
-		results = append(results, &LongShortRatioData{
-			Exchange:  "binance",
-			Symbol:    symbol,
-			Ratio:     ratioValue,
-			Timestamp: time.Unix(ratio.Timestamp/1000, 0),
+		results = append(results, &FundingRateData{
+			Exchange:    "binance",
+			Symbol:      symbol,
+			Rate:        rate,
+			FundingTime: time.Unix(item.FundingTime/1000, 0),
 		})
 	}
 
 	// 记录成功的日志
 	apiLog.Success = true
 	apiLog.DataCount = len(results)
-	b.saveLog(apiLog)
+	b.saveLog(ctx, apiLog)
 
 	return results, nil
 }
 
-// GetMultipleSymbolsLongShortRatio 获取多个交易对的多空比数据
-func (b *BinanceService) GetMultipleSymbolsLongShortRatio(symbols []string) ([]*LongShortRatioData, error) {
-	var results []*LongShortRatioData
+// GetPredictedFunding 获取预测资金费率和下一次结算时间
+func (b *BinanceService) GetPredictedFunding(ctx context.Context, symbol string) (*PredictedFundingData, error) {
+	url := fmt.Sprintf("%s/fapi/v1/premiumIndex?symbol=%s", b.baseURL, symbol)
+
+	// 创建日志记录
+	apiLog := &models.APILog{
+		Exchange: "binance",
+		Symbol:   symbol,
+		Limit:    1,
+		URL:      url,
+	}
+
+	var item BinancePremiumIndexResponse
+	if err := b.fetchJSON(ctx, apiLog, &item); err != nil {
+		return nil, err
+	}
 
+	rate, err := strconv.ParseFloat(item.LastFundingRate, 64)
+	if err != nil {
+		return nil, fmt.Errorf("解析资金费率失败: %w", err)
+	}
+
+	// 记录成功的日志
+	apiLog.Success = true
+	apiLog.DataCount = 1
+	b.saveLog(ctx, apiLog)
+
+	return &PredictedFundingData{
+		Exchange:        "binance",
+		Symbol:          symbol,
+		Rate:            rate,
+		NextFundingTime: time.Unix(item.NextFundingTime/1000, 0),
+		Timestamp:       time.Unix(item.Time/1000, 0),
+	}, nil
+}
+
+// NewStream 创建订阅强平、5分钟K线和标记价格（资金费率）推送的流客户端
+func (b *BinanceService) NewStream(symbols []string, handler func(*StreamEvent)) *StreamClient {
+	var params []string
 	for _, symbol := range symbols {
-		data, err := b.GetLongShortRatio(symbol)
+		lower := strings.ToLower(symbol)
+		params = append(params, lower+"@forceOrder", lower+"@kline_5m", lower+"@markPrice")
+	}
+
+	return NewStreamClient(StreamOptions{
+		Name: "binance",
+		URL:  b.wsURL + "/ws",
+		Subscribe: func(conn *websocket.Conn) error {
+			return conn.WriteJSON(map[string]interface{}{
+				"method": "SUBSCRIBE",
+				"params": params,
+				"id":     1,
+			})
+		},
+		HeartbeatInterval: 3 * time.Minute,
+		OnMessage: func(message []byte) {
+			var event BinanceStreamEvent
+			if err := json.Unmarshal(message, &event); err != nil {
+				return
+			}
+
+			switch event.EventType {
+			case "forceOrder":
+				if data := b.parseForceOrder(&event); data != nil {
+					handler(&StreamEvent{Liquidation: data})
+				}
+			case "kline":
+				if data := b.parseKlineEvent(&event); data != nil {
+					handler(&StreamEvent{Kline: data})
+				}
+			case "markPriceUpdate":
+				rate, err := strconv.ParseFloat(event.FundingRate, 64)
+				if err != nil {
+					return
+				}
+				handler(&StreamEvent{PredictedFunding: &PredictedFundingData{
+					Exchange:        "binance",
+					Symbol:          event.Symbol,
+					Rate:            rate,
+					NextFundingTime: time.Unix(event.NextFundingTime/1000, 0),
+					Timestamp:       time.Unix(event.EventTime/1000, 0),
+				}})
+			}
+		},
+	})
+}
+
+// parseKlineEvent 解析K线推送。未收盘的K线每秒推送多次，只返回已收盘的K线，
+// 当前周期的K线由定时采集写入
+func (b *BinanceService) parseKlineEvent(event *BinanceStreamEvent) *KlineData {
+	k := event.Kline
+	if k == nil || !k.Closed {
+		return nil
+	}
+
+	values := make([]float64, 5)
+	for i, raw := range []string{k.Open, k.High, k.Low, k.Close, k.Volume} {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil
+		}
+		values[i] = v
+	}
+
+	return &KlineData{
+		Exchange: "binance",
+		Symbol:   k.Symbol,
+		Period:   k.Interval,
+		OpenTime: time.Unix(k.OpenTime/1000, 0),
+		Open:     values[0],
+		High:     values[1],
+		Low:      values[2],
+		Close:    values[3],
+		Volume:   values[4],
+	}
+}
+
+// parseForceOrder 解析强平订单推送，卖单表示多头仓位被强平
+func (b *BinanceService) parseForceOrder(event *BinanceStreamEvent) *LiquidationData {
+	order := event.Order
+	if order == nil {
+		return nil
+	}
+
+	price, err := strconv.ParseFloat(order.AveragePrice, 64)
+	if err != nil || price == 0 {
+		price, err = strconv.ParseFloat(order.Price, 64)
 		if err != nil {
-			// 记录错误但继续处理其他交易对
-			fmt.Printf("获取%s多空比失败: %v\n", symbol, err)
+			return nil
+		}
+	}
+	quantity, err := strconv.ParseFloat(order.FilledQty, 64)
+	if err != nil || quantity == 0 {
+		quantity, err = strconv.ParseFloat(order.OriginalQty, 64)
+		if err != nil {
+			return nil
+		}
+	}
+
+	side := LiquidationSideLong
+	if order.Side == "BUY" {
+		side = LiquidationSideShort
+	}
+
+	return &LiquidationData{
+		Exchange:  "binance",
+		Symbol:    order.Symbol,
+		Side:      side,
+		Price:     price,
+		Quantity:  quantity,
+		Notional:  price * quantity,
+		Timestamp: time.Unix(order.TradeTime/1000, 0),
+	}
+}
+
+// GetKlines 获取K线数据（按时间升序返回）
+func (b *BinanceService) GetKlines(ctx context.Context, symbol, period string, limit int) ([]*KlineData, error) {
+	url := fmt.Sprintf("%s/fapi/v1/klines?symbol=%s&interval=%s&limit=%d",
+		b.baseURL, symbol, period, limit)
+
+	// 创建日志记录
+	apiLog := &models.APILog{
+		Exchange: "binance",
+		Symbol:   symbol,
+		Period:   period,
+		Limit:    limit,
+		URL:      url,
+	}
+
+	// Binance返回的数据格式: [[openTime, "open", "high", "low", "close", "volume", closeTime, ...], ...]
+	var rows [][]interface{}
+	if err := b.fetchJSON(ctx, apiLog, &rows); err != nil {
+		return nil, err
+	}
+
+	var results []*KlineData
+	for _, row := range rows {
+		if len(row) < 6 {
+			continue
+		}
+
+		values := make([]float64, 6)
+		valid := true
+		for i := 0; i < 6; i++ {
+			v, err := parseNumber(row[i])
+			if err != nil {
+				valid = false
+				break
+			}
+			values[i] = v
+		}
+		if !valid {
 			continue
 		}
-		results = append(results, data)
 
-		// 避免请求过于频繁
-		time.Sleep(100 * time.Millisecond)
+		results = append(results, &KlineData{
+			Exchange: "binance",
+			Symbol:   symbol,
+			Period:   period,
+			OpenTime: time.Unix(int64(values[0])/1000, 0),
+			Open:     values[1],
+			High:     values[2],
+			Low:      values[3],
+			Close:    values[4],
+			Volume:   values[5],
+		})
+	}
+
+	// 记录成功的日志
+	apiLog.Success = true
+	apiLog.DataCount = len(results)
+	b.saveLog(ctx, apiLog)
+
+	return results, nil
+}
+
+// GetSymbols 获取USDT本位永续合约列表，Binance没有批量持仓量接口，持仓价值为0
+func (b *BinanceService) GetSymbols(ctx context.Context) ([]*SymbolInfo, error) {
+	url := fmt.Sprintf("%s/fapi/v1/exchangeInfo", b.baseURL)
+
+	// 创建日志记录
+	apiLog := &models.APILog{
+		Exchange: "binance",
+		URL:      url,
+	}
+
+	var response BinanceExchangeInfoResponse
+	if err := b.fetchJSON(ctx, apiLog, &response); err != nil {
+		return nil, err
+	}
+
+	var results []*SymbolInfo
+	for _, item := range response.Symbols {
+		if item.ContractType != "PERPETUAL" || item.QuoteAsset != "USDT" || item.Status != "TRADING" {
+			continue
+		}
+		results = append(results, &SymbolInfo{
+			Exchange:       "binance",
+			Symbol:         normalizeSymbol(item.BaseAsset, item.QuoteAsset),
+			ExchangeSymbol: item.Symbol,
+			BaseAsset:      item.BaseAsset,
+			QuoteAsset:     item.QuoteAsset,
+		})
 	}
 
+	// 记录成功的日志
+	apiLog.Success = true
+	apiLog.DataCount = len(results)
+	b.saveLog(ctx, apiLog)
+
 	return results, nil
 }
 
-// saveLog 保存API日志
-func (b *BinanceService) saveLog(apiLog *models.APILog) {
+// fetchJSON 请求apiLog.URL并解析JSON响应，失败时记录API日志
+func (b *BinanceService) fetchJSON(ctx context.Context, apiLog *models.APILog, out interface{}) error {
+	startTime := time.Now()
+	resp, err := b.http.Get(ctx, apiLog.URL)
+	apiLog.ResponseTime = time.Since(startTime).Milliseconds()
+	if resp != nil {
+		apiLog.StatusCode = resp.StatusCode
+	}
+
+	if err == nil {
+		if jsonErr := json.Unmarshal(resp.Body, out); jsonErr != nil {
+			err = decodeError("Binance", jsonErr)
+		}
+	}
+	if err != nil {
+		err = b.classify(resp, err)
+		markFailed(apiLog, err)
+		b.saveLog(ctx, apiLog)
+		return err
+	}
+
+	return nil
+}
+
+// classify 根据HTTP错误响应中的Binance错误码修正错误类型
+func (b *BinanceService) classify(resp *exchangeResponse, err error) error {
+	if resp == nil || resp.StatusCode == http.StatusOK {
+		return err
+	}
+
+	var response BinanceErrorResponse
+	if json.Unmarshal(resp.Body, &response) != nil || response.Code == 0 {
+		return err
+	}
+	return withCodeKind(err, binanceErrorKinds[response.Code])
+}
+
+// saveLog 保存API日志，请求已取消时不再写入
+func (b *BinanceService) saveLog(ctx context.Context, apiLog *models.APILog) {
 	if db := database.GetDB(); db != nil {
 		repo := models.NewAPILogRepository(db)
-		if err := repo.Create(apiLog); err != nil {
+		if err := repo.Create(ctx, apiLog); err != nil && ctx.Err() == nil {
 			fmt.Printf("保存Binance API日志失败: %v\n", err)
 		}
 	}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// binanceAccountRatioFixture Binance多空比接口按时间升序返回
+const binanceAccountRatioFixture = `[
+	{"symbol": "BTCUSDT", "longShortRatio": "1.2", "longAccount": "0.5455", "shortAccount": "0.4545", "timestamp": 1700000000000},
+	{"symbol": "BTCUSDT", "longShortRatio": "1.4", "longAccount": "0.5833", "shortAccount": "0.4167", "timestamp": 1700000300000}
+]`
+
+func TestBinanceLatestRatio(t *testing.T) {
+	db := useTestDB(t)
+	server, requests := newFixtureServer(t, map[string]string{
+		"/futures/data/globalLongShortAccountRatio": binanceAccountRatioFixture,
+	})
+	binance := NewBinanceService(testExchangeConfig(server.URL))
+
+	latest, err := binance.GetLongShortRatioWithPeriod(context.Background(), "BTCUSDT", "15m", 2)
+	if err != nil {
+		t.Fatalf("获取最新多空比失败: %v", err)
+	}
+
+	query := (<-requests).URL.Query()
+	if query.Get("symbol") != "BTCUSDT" || query.Get("period") != "15m" || query.Get("limit") != "2" {
+		t.Errorf("请求参数错误: %v", query)
+	}
+
+	// 最新数据取升序结果的最后一条
+	if latest.Exchange != "binance" || latest.Metric != MetricGlobalAccount || latest.Period != "15m" ||
+		latest.Ratio != 1.4 || !latest.Timestamp.Equal(time.Unix(1700000300, 0)) {
+		t.Errorf("最新多空比错误: %+v", latest)
+	}
+
+	apiLog := lastAPILog(t, db)
+	if !apiLog.Success || apiLog.Exchange != "binance" || apiLog.Symbol != "BTCUSDT" || apiLog.Period != "15m" ||
+		apiLog.Limit != 2 || apiLog.StatusCode != http.StatusOK || apiLog.DataCount != 2 {
+		t.Errorf("API日志错误: %+v", apiLog)
+	}
+}
@@ -0,0 +1,394 @@
+package services
+
+import (
+	"CurrencyMonitor/config"
+	"CurrencyMonitor/database"
+	"CurrencyMonitor/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bitgetEnvelope Bitget API通用响应结构
+type bitgetEnvelope struct {
+	Code string          `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+// bitgetErrorKinds Bitget错误码对应的错误类型
+var bitgetErrorKinds = map[string]error{
+	"429":   ErrRateLimited, // 请求频率过高
+	"40034": ErrBadSymbol,   // 参数（交易对）不存在
+	"40309": ErrBadSymbol,   // 合约已下架
+}
+
+// BitgetAccountRatioResponse Bitget多空比API响应结构（data字段元素）
+type BitgetAccountRatioResponse struct {
+	LongAccountRatio      string `json:"longAccountRatio"`
+	ShortAccountRatio     string `json:"shortAccountRatio"`
+	LongShortAccountRatio string `json:"longShortAccountRatio"`
+	Ts                    string `json:"ts"`
+}
+
+// BitgetContractResponse Bitget合约信息API响应结构（data字段元素）
+type BitgetContractResponse struct {
+	Symbol       string `json:"symbol"`
+	BaseCoin     string `json:"baseCoin"`
+	QuoteCoin    string `json:"quoteCoin"`
+	SymbolType   string `json:"symbolType"`
+	SymbolStatus string `json:"symbolStatus"`
+}
+
+// BitgetTickerResponse Bitget行情API响应结构（data字段元素）
+type BitgetTickerResponse struct {
+	Symbol        string `json:"symbol"`
+	LastPr        string `json:"lastPr"`
+	HoldingAmount string `json:"holdingAmount"` // 持仓量（以币计）
+}
+
+// bitgetPeriods Bitget支持的时间粒度
+var bitgetPeriods = map[string]bool{
+	"5m":  true,
+	"15m": true,
+	"30m": true,
+	"1h":  true,
+	"2h":  true,
+	"4h":  true,
+	"1d":  true,
+}
+
+// bitgetGranularities 通用时间粒度到Bitget K线granularity参数的映射
+var bitgetGranularities = map[string]string{
+	"5m":  "5m",
+	"15m": "15m",
+	"30m": "30m",
+	"1h":  "1H",
+	"4h":  "4H",
+	"1d":  "1D",
+}
+
+// bitgetRateLimits Bitget限流规则：行情接口每个IP每秒20次，各接口分别计算
+var bitgetRateLimits = rateLimits{
+	defaultQuota: quota{limit: 20, interval: time.Second},
+}
+
+// BitgetService Bitget服务
+type BitgetService struct {
+	baseURL string
+	http    *exchangeClient
+}
+
+func init() {
+	RegisterExchange("bitget", func(cfg config.ExchangeConfig) ExchangeService { return NewBitgetService(cfg) })
+}
+
+// NewBitgetService 使用交易所配置创建Bitget服务
+func NewBitgetService(cfg config.ExchangeConfig) *BitgetService {
+	return &BitgetService{
+		baseURL: cfg.BaseURL,
+		http:    newExchangeClient("Bitget", cfg, rateLimiter("bitget", bitgetRateLimits), circuitBreakers("bitget", cfg)),
+	}
+}
+
+// Name 交易所名称
+func (b *BitgetService) Name() string {
+	return "bitget"
+}
+
+// GetLongShortRatio 获取多空比数据
+func (b *BitgetService) GetLongShortRatio(ctx context.Context, symbol string) (*LongShortRatioData, error) {
+	ratios, err := b.GetLongShortRatioHistory(ctx, symbol, "5m", 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(ratios) == 0 {
+		return nil, fmt.Errorf("没有获取到多空比数据")
+	}
+	return ratios[len(ratios)-1], nil
+}
+
+// GetLongShortRatioHistory 获取多空比历史数据（按时间升序返回）
+func (b *BitgetService) GetLongShortRatioHistory(ctx context.Context, symbol, period string, limit int) ([]*LongShortRatioData, error) {
+	if !bitgetPeriods[period] {
+		return nil, fmt.Errorf("Bitget不支持的时间粒度: %s", period)
+	}
+
+	instSymbol, productType := b.convertSymbol(symbol)
+	// Bitget接口不支持limit参数，获取全部数据后截取
+	url := fmt.Sprintf("%s/api/v2/mix/market/account-long-short?symbol=%s&productType=%s&period=%s",
+		b.baseURL, instSymbol, productType, period)
+
+	// 创建日志记录
+	apiLog := &models.APILog{
+		Exchange: "bitget",
+		Symbol:   symbol,
+		Period:   period,
+		Limit:    limit,
+		URL:      url,
+	}
+
+	var items []BitgetAccountRatioResponse
+	if err := b.fetchJSON(ctx, apiLog, &items); err != nil {
+		return nil, err
+	}
+
+	var results []*LongShortRatioData
+	for _, item := range items {
+		ratioValue, err := strconv.ParseFloat(item.LongShortAccountRatio, 64)
+		if err != nil {
+			continue
+		}
+		timestamp, err := strconv.ParseInt(item.Ts, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		results = append(results, &LongShortRatioData{
+			Exchange:  "bitget",
+			Symbol:    symbol,
+			Metric:    MetricGlobalAccount,
+			Period:    period,
+			Ratio:     ratioValue,
+			Timestamp: time.Unix(timestamp/1000, 0),
+		})
+	}
+
+	// 按时间升序排列并保留最近的limit条
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Timestamp.Before(results[j].Timestamp)
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[len(results)-limit:]
+	}
+
+	// 记录成功的日志
+	apiLog.Success = true
+	apiLog.DataCount = len(results)
+	b.saveLog(ctx, apiLog)
+
+	return results, nil
+}
+
+// GetKlines 获取K线数据（按时间升序返回）
+func (b *BitgetService) GetKlines(ctx context.Context, symbol, period string, limit int) ([]*KlineData, error) {
+	granularity, ok := bitgetGranularities[period]
+	if !ok {
+		return nil, fmt.Errorf("Bitget不支持的时间粒度: %s", period)
+	}
+
+	instSymbol, productType := b.convertSymbol(symbol)
+	url := fmt.Sprintf("%s/api/v2/mix/market/candles?symbol=%s&productType=%s&granularity=%s&limit=%d",
+		b.baseURL, instSymbol, productType, granularity, limit)
+
+	// 创建日志记录
+	apiLog := &models.APILog{
+		Exchange: "bitget",
+		Symbol:   symbol,
+		Period:   period,
+		Limit:    limit,
+		URL:      url,
+	}
+
+	// Bitget返回的数据格式: [["ts", "open", "high", "low", "close", "baseVolume", "quoteVolume"], ...]
+	var rows [][]string
+	if err := b.fetchJSON(ctx, apiLog, &rows); err != nil {
+		return nil, err
+	}
+
+	var results []*KlineData
+	for _, row := range rows {
+		if len(row) < 6 {
+			continue
+		}
+
+		values := make([]float64, 6)
+		valid := true
+		for j := 0; j < 6; j++ {
+			v, err := strconv.ParseFloat(row[j], 64)
+			if err != nil {
+				valid = false
+				break
+			}
+			values[j] = v
+		}
+		if !valid {
+			continue
+		}
+
+		results = append(results, &KlineData{
+			Exchange: "bitget",
+			Symbol:   symbol,
+			Period:   period,
+			OpenTime: time.Unix(int64(values[0])/1000, 0),
+			Open:     values[1],
+			High:     values[2],
+			Low:      values[3],
+			Close:    values[4],
+			Volume:   values[5],
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].OpenTime.Before(results[j].OpenTime)
+	})
+
+	// 记录成功的日志
+	apiLog.Success = true
+	apiLog.DataCount = len(results)
+	b.saveLog(ctx, apiLog)
+
+	return results, nil
+}
+
+// GetSymbols 获取USDT本位永续合约列表，持仓价值由行情接口的持仓量和最新价计算
+func (b *BitgetService) GetSymbols(ctx context.Context) ([]*SymbolInfo, error) {
+	url := fmt.Sprintf("%s/api/v2/mix/market/contracts?productType=USDT-FUTURES", b.baseURL)
+
+	// 创建日志记录
+	apiLog := &models.APILog{
+		Exchange: "bitget",
+		URL:      url,
+	}
+
+	var contracts []BitgetContractResponse
+	if err := b.fetchJSON(ctx, apiLog, &contracts); err != nil {
+		return nil, err
+	}
+
+	var results []*SymbolInfo
+	for _, item := range contracts {
+		if item.SymbolType != "perpetual" || item.QuoteCoin != "USDT" || item.SymbolStatus != "normal" {
+			continue
+		}
+		results = append(results, &SymbolInfo{
+			Exchange:       "bitget",
+			Symbol:         normalizeSymbol(item.BaseCoin, item.QuoteCoin),
+			ExchangeSymbol: item.Symbol,
+			BaseAsset:      item.BaseCoin,
+			QuoteAsset:     item.QuoteCoin,
+		})
+	}
+
+	// 记录成功的日志
+	apiLog.Success = true
+	apiLog.DataCount = len(results)
+	b.saveLog(ctx, apiLog)
+
+	url = fmt.Sprintf("%s/api/v2/mix/market/tickers?productType=USDT-FUTURES", b.baseURL)
+	apiLog = &models.APILog{
+		Exchange: "bitget",
+		URL:      url,
+	}
+
+	var tickers []BitgetTickerResponse
+	if err := b.fetchJSON(ctx, apiLog, &tickers); err != nil {
+		// 持仓价值只用于排序，获取失败时仍返回合约列表
+		return results, nil
+	}
+
+	openInterest := make(map[string]float64, len(tickers))
+	for _, ticker := range tickers {
+		holding, err := strconv.ParseFloat(ticker.HoldingAmount, 64)
+		if err != nil {
+			continue
+		}
+		price, err := strconv.ParseFloat(ticker.LastPr, 64)
+		if err != nil {
+			continue
+		}
+		openInterest[ticker.Symbol] = holding * price
+	}
+	for _, item := range results {
+		item.OpenInterestUSD = openInterest[item.ExchangeSymbol]
+	}
+
+	apiLog.Success = true
+	apiLog.DataCount = len(tickers)
+	b.saveLog(ctx, apiLog)
+
+	return results, nil
+}
+
+// fetchJSON 请求apiLog.URL并将响应中的data字段解析到out，失败时记录API日志
+func (b *BitgetService) fetchJSON(ctx context.Context, apiLog *models.APILog, out interface{}) error {
+	startTime := time.Now()
+	resp, err := b.http.Get(ctx, apiLog.URL)
+	apiLog.ResponseTime = time.Since(startTime).Milliseconds()
+	if resp != nil {
+		apiLog.StatusCode = resp.StatusCode
+	}
+
+	if err == nil {
+		err = b.decode(resp.Body, out)
+	} else {
+		err = b.classify(resp, err)
+	}
+	if err != nil {
+		markFailed(apiLog, err)
+		b.saveLog(ctx, apiLog)
+		return err
+	}
+
+	return nil
+}
+
+// decode 检查Bitget响应的业务错误码并将data字段解析到out
+func (b *BitgetService) decode(body []byte, out interface{}) error {
+	var response bitgetEnvelope
+	if err := json.Unmarshal(body, &response); err != nil {
+		return decodeError("Bitget", err)
+	}
+
+	if response.Code != "00000" {
+		return codeError("Bitget", bitgetErrorKinds[response.Code], response.Code, response.Msg)
+	}
+
+	if err := json.Unmarshal(response.Data, out); err != nil {
+		return decodeError("Bitget", err)
+	}
+	return nil
+}
+
+// classify 根据HTTP错误响应中的Bitget错误码修正错误类型
+func (b *BitgetService) classify(resp *exchangeResponse, err error) error {
+	if resp == nil || resp.StatusCode == http.StatusOK {
+		return err
+	}
+
+	var response bitgetEnvelope
+	if json.Unmarshal(resp.Body, &response) != nil || response.Code == "" {
+		return err
+	}
+	return withCodeKind(err, bitgetErrorKinds[response.Code])
+}
+
+// convertSymbol 转换交易对格式，返回Bitget的交易对和产品类型
+func (b *BitgetService) convertSymbol(symbol string) (string, string) {
+	// Bitget通过productType区分U本位、USDC本位和币本位合约
+	switch {
+	case strings.HasSuffix(symbol, "USDT"):
+		return symbol, "USDT-FUTURES"
+	case strings.HasSuffix(symbol, "USDC"):
+		return symbol, "USDC-FUTURES"
+	case strings.HasSuffix(symbol, "USD"):
+		return symbol, "COIN-FUTURES"
+	default:
+		return symbol + "USDT", "USDT-FUTURES"
+	}
+}
+
+// saveLog 保存API日志，请求已取消时不再写入
+func (b *BitgetService) saveLog(ctx context.Context, apiLog *models.APILog) {
+	if db := database.GetDB(); db != nil {
+		repo := models.NewAPILogRepository(db)
+		if err := repo.Create(ctx, apiLog); err != nil && ctx.Err() == nil {
+			fmt.Printf("保存Bitget API日志失败: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// bitgetAccountRatioFixture Bitget多空比接口不保证返回顺序，包含一条无法解析的数据
+const bitgetAccountRatioFixture = `{
+	"code": "00000",
+	"msg": "success",
+	"data": [
+		{"longAccountRatio": "0.55", "shortAccountRatio": "0.45", "longShortAccountRatio": "1.22", "ts": "1700000300000"},
+		{"longAccountRatio": "0.6", "shortAccountRatio": "0.4", "longShortAccountRatio": "1.5", "ts": "1700000600000"},
+		{"longAccountRatio": "0.5", "shortAccountRatio": "0.5", "longShortAccountRatio": "1", "ts": "1700000000000"},
+		{"longAccountRatio": "0.5", "shortAccountRatio": "0.5", "longShortAccountRatio": "", "ts": "1700000900000"}
+	]
+}`
+
+func TestBitgetConvertSymbol(t *testing.T) {
+	bitget := NewBitgetService(testExchangeConfig(""))
+
+	tests := []struct {
+		symbol, instSymbol, productType string
+	}{
+		{"BTCUSDT", "BTCUSDT", "USDT-FUTURES"},
+		{"BTCUSDC", "BTCUSDC", "USDC-FUTURES"},
+		{"BTCUSD", "BTCUSD", "COIN-FUTURES"},
+		{"BTC", "BTCUSDT", "USDT-FUTURES"},
+	}
+	for _, tt := range tests {
+		instSymbol, productType := bitget.convertSymbol(tt.symbol)
+		if instSymbol != tt.instSymbol || productType != tt.productType {
+			t.Errorf("convertSymbol(%s) = %s, %s, 期望 %s, %s", tt.symbol, instSymbol, productType, tt.instSymbol, tt.productType)
+		}
+	}
+}
+
+func TestBitgetRatioHistory(t *testing.T) {
+	db := useTestDB(t)
+	server, requests := newFixtureServer(t, map[string]string{
+		"/api/v2/mix/market/account-long-short": bitgetAccountRatioFixture,
+	})
+	bitget := NewBitgetService(testExchangeConfig(server.URL))
+
+	data, err := bitget.GetLongShortRatioHistory(context.Background(), "ETHUSDC", "15m", 2)
+	if err != nil {
+		t.Fatalf("获取多空比失败: %v", err)
+	}
+
+	query := (<-requests).URL.Query()
+	if query.Get("symbol") != "ETHUSDC" || query.Get("productType") != "USDC-FUTURES" || query.Get("period") != "15m" {
+		t.Errorf("请求参数错误: %v", query)
+	}
+
+	// 按时间升序排列后保留最近的2条
+	if len(data) != 2 {
+		t.Fatalf("数据条数 = %d, 期望 2", len(data))
+	}
+	for i, want := range []struct {
+		ratio float64
+		ts    int64
+	}{
+		{1.22, 1700000300},
+		{1.5, 1700000600},
+	} {
+		item := data[i]
+		if item.Exchange != "bitget" || item.Symbol != "ETHUSDC" || item.Metric != MetricGlobalAccount || item.Period != "15m" {
+			t.Errorf("第%d条数据字段错误: %+v", i, item)
+		}
+		if item.Ratio != want.ratio || !item.Timestamp.Equal(time.Unix(want.ts, 0)) {
+			t.Errorf("第%d条数据 = %v %v, 期望 %v %v", i, item.Ratio, item.Timestamp, want.ratio, time.Unix(want.ts, 0))
+		}
+	}
+
+	apiLog := lastAPILog(t, db)
+	if !apiLog.Success || apiLog.Exchange != "bitget" || apiLog.Symbol != "ETHUSDC" || apiLog.Period != "15m" ||
+		apiLog.Limit != 2 || apiLog.StatusCode != http.StatusOK || apiLog.DataCount != 2 {
+		t.Errorf("API日志错误: %+v", apiLog)
+	}
+	if apiLog.URL != server.URL+"/api/v2/mix/market/account-long-short?symbol=ETHUSDC&productType=USDC-FUTURES&period=15m" {
+		t.Errorf("API日志URL错误: %s", apiLog.URL)
+	}
+}
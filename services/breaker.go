@@ -0,0 +1,209 @@
+package services
+
+import (
+	"CurrencyMonitor/config"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 熔断器状态
+const (
+	BreakerClosed   = "closed"    // 正常放行
+	BreakerOpen     = "open"      // 熔断中，请求直接失败
+	BreakerHalfOpen = "half_open" // 熔断冷却结束，放行一个探测请求
+)
+
+// maxBreakerCooldown 探测连续失败时冷却时间翻倍的上限
+const maxBreakerCooldown = 5 * time.Minute
+
+// circuitBreaker 单个交易所接口的熔断器
+type circuitBreaker struct {
+	mu        sync.Mutex
+	state     string
+	failures  int       // 连续失败次数
+	opens     int       // 连续熔断次数，探测失败时冷却时间翻倍
+	openedAt  time.Time // 最近一次熔断的时间
+	nextProbe time.Time // 熔断中时下一次探测的时间
+	probing   bool      // 半开状态下探测请求是否正在执行
+	lastError string
+}
+
+// circuitBreakerGroup 一个交易所各接口的熔断器
+type circuitBreakerGroup struct {
+	exchange string
+
+	mu        sync.Mutex
+	threshold int           // 连续失败多少次后熔断
+	cooldown  time.Duration // 熔断后第一次探测前的等待时间
+	breakers  map[string]*circuitBreaker
+}
+
+// breakerGroups 按交易所名称共用的熔断器，重新加载配置重建交易所服务后状态保留
+var breakerGroups = struct {
+	sync.Mutex
+	byExchange map[string]*circuitBreakerGroup
+}{
+	byExchange: make(map[string]*circuitBreakerGroup),
+}
+
+// circuitBreakers 获取交易所的熔断器，并使用交易所配置更新熔断阈值和冷却时间
+func circuitBreakers(exchange string, cfg config.ExchangeConfig) *circuitBreakerGroup {
+	breakerGroups.Lock()
+	group, ok := breakerGroups.byExchange[exchange]
+	if !ok {
+		group = &circuitBreakerGroup{
+			exchange: exchange,
+			breakers: make(map[string]*circuitBreaker),
+		}
+		breakerGroups.byExchange[exchange] = group
+	}
+	breakerGroups.Unlock()
+
+	group.mu.Lock()
+	group.threshold = cfg.BreakerThreshold
+	group.cooldown = cfg.BreakerCooldown
+	group.mu.Unlock()
+	return group
+}
+
+// CircuitBreakerStatus 获取所有交易所接口熔断器的状态
+func CircuitBreakerStatus() []map[string]interface{} {
+	breakerGroups.Lock()
+	groups := make([]*circuitBreakerGroup, 0, len(breakerGroups.byExchange))
+	for _, group := range breakerGroups.byExchange {
+		groups = append(groups, group)
+	}
+	breakerGroups.Unlock()
+
+	sort.Slice(groups, func(i, j int) bool { return groups[i].exchange < groups[j].exchange })
+	results := make([]map[string]interface{}, 0)
+	for _, group := range groups {
+		results = append(results, group.status()...)
+	}
+	return results
+}
+
+// allow 检查接口是否放行请求，熔断中时返回ErrCircuitOpen
+func (g *circuitBreakerGroup) allow(exchange, endpoint string) error {
+	b := g.breaker(endpoint)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	switch b.state {
+	case BreakerOpen:
+		if now.Before(b.nextProbe) {
+			return newExchangeError(exchange, ErrCircuitOpen, fmt.Errorf("%s连续%d次请求失败，%s后重试（最近错误: %s）",
+				endpoint, b.failures, b.nextProbe.Format("15:04:05"), b.lastError))
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return newExchangeError(exchange, ErrCircuitOpen, fmt.Errorf("%s正在探测是否恢复", endpoint))
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// record 记录请求结果。只有服务不可用（5xx、超时、网络错误）计为失败，交易所正常响应的其他错误视为接口可用；
+// 调用方取消的请求不影响熔断状态
+func (g *circuitBreakerGroup) record(endpoint string, err error) {
+	g.mu.Lock()
+	threshold, cooldown := g.threshold, g.cooldown
+	g.mu.Unlock()
+
+	b := g.breaker(endpoint)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wasProbing := b.probing
+	b.probing = false
+
+	switch {
+	case errors.Is(err, ErrUnavailable):
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		return
+	default:
+		b.state = BreakerClosed
+		b.failures = 0
+		b.opens = 0
+		return
+	}
+
+	b.failures++
+	b.lastError = err.Error()
+	if b.state == BreakerHalfOpen && wasProbing {
+		b.open(cooldown)
+		return
+	}
+	if threshold > 0 && b.state == BreakerClosed && b.failures >= threshold {
+		b.open(cooldown)
+	}
+}
+
+// open 进入熔断状态，连续熔断时冷却时间翻倍
+func (b *circuitBreaker) open(cooldown time.Duration) {
+	b.state = BreakerOpen
+	b.opens++
+	wait := cooldown << (b.opens - 1)
+	if wait <= 0 || wait > maxBreakerCooldown {
+		wait = maxBreakerCooldown
+	}
+	b.openedAt = time.Now()
+	b.nextProbe = b.openedAt.Add(wait)
+}
+
+// breaker 获取接口的熔断器，不存在时创建
+func (g *circuitBreakerGroup) breaker(endpoint string) *circuitBreaker {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	b, ok := g.breakers[endpoint]
+	if !ok {
+		b = &circuitBreaker{state: BreakerClosed}
+		g.breakers[endpoint] = b
+	}
+	return b
+}
+
+// status 获取各接口熔断器的状态，按接口路径排序
+func (g *circuitBreakerGroup) status() []map[string]interface{} {
+	g.mu.Lock()
+	endpoints := make([]string, 0, len(g.breakers))
+	for endpoint := range g.breakers {
+		endpoints = append(endpoints, endpoint)
+	}
+	g.mu.Unlock()
+	sort.Strings(endpoints)
+
+	results := make([]map[string]interface{}, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		b := g.breaker(endpoint)
+		b.mu.Lock()
+		status := map[string]interface{}{
+			"exchange": g.exchange,
+			"endpoint": endpoint,
+			"state":    b.state,
+			"failures": b.failures,
+		}
+		if b.lastError != "" {
+			status["last_error"] = b.lastError
+		}
+		if b.state != BreakerClosed {
+			status["opened_at"] = b.openedAt.Format("2006-01-02 15:04:05")
+			status["next_probe"] = b.nextProbe.Format("2006-01-02 15:04:05")
+		}
+		b.mu.Unlock()
+		results = append(results, status)
+	}
+	return results
+}
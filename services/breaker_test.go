@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// breakerStep 熔断器测试的一步操作：allow检查是否放行，record记录请求结果，expire使冷却时间立即结束
+type breakerStep struct {
+	action   string
+	err      error         // record的请求结果
+	rejected bool          // allow是否应返回ErrCircuitOpen
+	state    string        // 操作后的状态
+	cooldown time.Duration // 状态为熔断时本次的冷却时间，为0时不检查
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	unavailable := newExchangeError("test", ErrUnavailable, errors.New("503"))
+	badSymbol := newExchangeError("test", ErrBadSymbol, errors.New("400"))
+	canceled := newExchangeError("test", nil, context.Canceled)
+
+	tests := []struct {
+		name      string
+		threshold int
+		steps     []breakerStep
+	}{
+		{"连续失败达到阈值后熔断", 3, []breakerStep{
+			{action: "record", err: unavailable, state: BreakerClosed},
+			{action: "record", err: unavailable, state: BreakerClosed},
+			{action: "record", err: unavailable, state: BreakerOpen, cooldown: time.Minute},
+			{action: "allow", rejected: true, state: BreakerOpen},
+		}},
+		{"交易所正常响应的错误重置失败计数", 2, []breakerStep{
+			{action: "record", err: unavailable, state: BreakerClosed},
+			{action: "record", err: badSymbol, state: BreakerClosed},
+			{action: "record", err: unavailable, state: BreakerClosed},
+		}},
+		{"半开状态只放行一个探测请求", 1, []breakerStep{
+			{action: "record", err: unavailable, state: BreakerOpen},
+			{action: "expire", state: BreakerOpen},
+			{action: "allow", state: BreakerHalfOpen},
+			{action: "allow", rejected: true, state: BreakerHalfOpen},
+		}},
+		{"探测失败后冷却时间翻倍直到上限", 1, []breakerStep{
+			{action: "record", err: unavailable, state: BreakerOpen, cooldown: time.Minute},
+			{action: "expire", state: BreakerOpen},
+			{action: "allow", state: BreakerHalfOpen},
+			{action: "record", err: unavailable, state: BreakerOpen, cooldown: 2 * time.Minute},
+			{action: "expire", state: BreakerOpen},
+			{action: "allow", state: BreakerHalfOpen},
+			{action: "record", err: unavailable, state: BreakerOpen, cooldown: 4 * time.Minute},
+			{action: "expire", state: BreakerOpen},
+			{action: "allow", state: BreakerHalfOpen},
+			{action: "record", err: unavailable, state: BreakerOpen, cooldown: maxBreakerCooldown},
+		}},
+		{"探测成功后恢复并重置冷却时间", 2, []breakerStep{
+			{action: "record", err: unavailable, state: BreakerClosed},
+			{action: "record", err: unavailable, state: BreakerOpen, cooldown: time.Minute},
+			{action: "expire", state: BreakerOpen},
+			{action: "allow", state: BreakerHalfOpen},
+			{action: "record", state: BreakerClosed},
+			{action: "allow", state: BreakerClosed},
+			{action: "record", err: unavailable, state: BreakerClosed},
+			{action: "record", err: unavailable, state: BreakerOpen, cooldown: time.Minute},
+		}},
+		{"调用方取消的请求不计为失败", 1, []breakerStep{
+			{action: "record", err: canceled, state: BreakerClosed},
+			{action: "record", err: context.DeadlineExceeded, state: BreakerClosed},
+			{action: "record", err: unavailable, state: BreakerOpen},
+			{action: "expire", state: BreakerOpen},
+			{action: "allow", state: BreakerHalfOpen},
+			// 探测请求被取消时不改变状态，下一个请求继续探测
+			{action: "record", err: canceled, state: BreakerHalfOpen},
+			{action: "allow", state: BreakerHalfOpen},
+		}},
+	}
+
+	const endpoint = "/api/ratio"
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := &circuitBreakerGroup{
+				exchange:  "test",
+				threshold: tt.threshold,
+				cooldown:  time.Minute,
+				breakers:  make(map[string]*circuitBreaker),
+			}
+			b := group.breaker(endpoint)
+
+			for i, step := range tt.steps {
+				switch step.action {
+				case "allow":
+					err := group.allow("test", endpoint)
+					if rejected := errors.Is(err, ErrCircuitOpen); rejected != step.rejected {
+						t.Fatalf("第%d步allow = %v, 期望拒绝: %v", i+1, err, step.rejected)
+					}
+				case "record":
+					group.record(endpoint, step.err)
+				case "expire":
+					b.mu.Lock()
+					b.nextProbe = time.Now()
+					b.mu.Unlock()
+				}
+
+				b.mu.Lock()
+				state, cooldown := b.state, b.nextProbe.Sub(b.openedAt)
+				b.mu.Unlock()
+				if state != step.state {
+					t.Fatalf("第%d步%s后状态 = %s, 期望 %s", i+1, step.action, state, step.state)
+				}
+				if step.cooldown != 0 && cooldown != step.cooldown {
+					t.Errorf("第%d步冷却时间 = %v, 期望 %v", i+1, cooldown, step.cooldown)
+				}
+			}
+		})
+	}
+}
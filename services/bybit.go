@@ -0,0 +1,394 @@
+package services
+
+import (
+	"CurrencyMonitor/config"
+	"CurrencyMonitor/database"
+	"CurrencyMonitor/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// bybitEnvelope Bybit API通用响应结构
+type bybitEnvelope struct {
+	RetCode int             `json:"retCode"`
+	RetMsg  string          `json:"retMsg"`
+	Result  json.RawMessage `json:"result"`
+}
+
+// bybitErrorKinds Bybit错误码对应的错误类型
+var bybitErrorKinds = map[int]error{
+	10006: ErrRateLimited, // 请求频率过高
+	10016: ErrUnavailable, // 服务内部错误
+}
+
+// BybitAccountRatioResponse Bybit多空比API响应结构（result字段）
+type BybitAccountRatioResponse struct {
+	List []struct {
+		Symbol    string `json:"symbol"`
+		BuyRatio  string `json:"buyRatio"`
+		SellRatio string `json:"sellRatio"`
+		Timestamp string `json:"timestamp"`
+	} `json:"list"`
+}
+
+// BybitKlineResponse Bybit K线API响应结构（result字段）
+type BybitKlineResponse struct {
+	Symbol string     `json:"symbol"`
+	List   [][]string `json:"list"` // [["startTime", "open", "high", "low", "close", "volume", "turnover"], ...]
+}
+
+// BybitInstrumentsResponse Bybit合约信息API响应结构（result字段）
+type BybitInstrumentsResponse struct {
+	List []struct {
+		Symbol       string `json:"symbol"`
+		ContractType string `json:"contractType"`
+		Status       string `json:"status"`
+		BaseCoin     string `json:"baseCoin"`
+		QuoteCoin    string `json:"quoteCoin"`
+	} `json:"list"`
+	NextPageCursor string `json:"nextPageCursor"`
+}
+
+// BybitTickersResponse Bybit行情API响应结构（result字段）
+type BybitTickersResponse struct {
+	List []struct {
+		Symbol            string `json:"symbol"`
+		OpenInterestValue string `json:"openInterestValue"`
+	} `json:"list"`
+}
+
+// bybitPeriods 通用时间粒度到Bybit period参数的映射
+var bybitPeriods = map[string]string{
+	"5m":  "5min",
+	"15m": "15min",
+	"30m": "30min",
+	"1h":  "1h",
+	"4h":  "4h",
+	"1d":  "1d",
+}
+
+// bybitKlineIntervals 通用时间粒度到Bybit K线interval参数的映射
+var bybitKlineIntervals = map[string]string{
+	"5m":  "5",
+	"15m": "15",
+	"30m": "30",
+	"1h":  "60",
+	"2h":  "120",
+	"4h":  "240",
+	"1d":  "D",
+}
+
+// bybitRateLimits Bybit限流规则：每个IP每5秒最多600次请求
+var bybitRateLimits = rateLimits{
+	total: quota{limit: 600, interval: 5 * time.Second},
+}
+
+// BybitService Bybit服务
+type BybitService struct {
+	baseURL string
+	http    *exchangeClient
+}
+
+func init() {
+	RegisterExchange("bybit", func(cfg config.ExchangeConfig) ExchangeService { return NewBybitService(cfg) })
+}
+
+// NewBybitService 使用交易所配置创建Bybit服务
+func NewBybitService(cfg config.ExchangeConfig) *BybitService {
+	return &BybitService{
+		baseURL: cfg.BaseURL,
+		http:    newExchangeClient("Bybit", cfg, rateLimiter("bybit", bybitRateLimits), circuitBreakers("bybit", cfg)),
+	}
+}
+
+// Name 交易所名称
+func (b *BybitService) Name() string {
+	return "bybit"
+}
+
+// GetLongShortRatio 获取多空比数据
+func (b *BybitService) GetLongShortRatio(ctx context.Context, symbol string) (*LongShortRatioData, error) {
+	ratios, err := b.GetLongShortRatioHistory(ctx, symbol, "5m", 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(ratios) == 0 {
+		return nil, fmt.Errorf("没有获取到多空比数据")
+	}
+	return ratios[len(ratios)-1], nil
+}
+
+// GetLongShortRatioHistory 获取多空比历史数据（按时间升序返回）
+func (b *BybitService) GetLongShortRatioHistory(ctx context.Context, symbol, period string, limit int) ([]*LongShortRatioData, error) {
+	return b.ratioHistory(ctx, symbol, period, limit, time.Time{}, time.Time{})
+}
+
+// GetLongShortRatioRange 获取时间范围内的全市场账户多空比，单次最多返回500条
+func (b *BybitService) GetLongShortRatioRange(ctx context.Context, symbol, period string, start, end time.Time) ([]*LongShortRatioData, error) {
+	return b.ratioHistory(ctx, symbol, period, 500, start, end)
+}
+
+// RatioLookback Bybit多空比补齐的最长回溯时间
+func (b *BybitService) RatioLookback(period string) time.Duration {
+	return 30 * 24 * time.Hour
+}
+
+// ratioHistory 获取多空比历史数据，start和end不为零时只查询该时间范围
+func (b *BybitService) ratioHistory(ctx context.Context, symbol, period string, limit int, start, end time.Time) ([]*LongShortRatioData, error) {
+	bybitPeriod, ok := bybitPeriods[period]
+	if !ok {
+		return nil, fmt.Errorf("Bybit不支持的时间粒度: %s", period)
+	}
+
+	url := fmt.Sprintf("%s/v5/market/account-ratio?category=linear&symbol=%s&period=%s&limit=%d",
+		b.baseURL, symbol, bybitPeriod, limit)
+	if !start.IsZero() {
+		url += fmt.Sprintf("&startTime=%d&endTime=%d", start.UnixMilli(), end.UnixMilli())
+	}
+
+	// 创建日志记录
+	apiLog := &models.APILog{
+		Exchange: "bybit",
+		Symbol:   symbol,
+		Period:   period,
+		Limit:    limit,
+		URL:      url,
+	}
+
+	var response BybitAccountRatioResponse
+	if err := b.fetchJSON(ctx, apiLog, &response); err != nil {
+		return nil, err
+	}
+
+	// Bybit按时间倒序返回，这里转换为升序与其他交易所保持一致
+	var results []*LongShortRatioData
+	for i := len(response.List) - 1; i >= 0; i-- {
+		item := response.List[i]
+
+		buyRatio, err := strconv.ParseFloat(item.BuyRatio, 64)
+		if err != nil {
+			continue
+		}
+		sellRatio, err := strconv.ParseFloat(item.SellRatio, 64)
+		if err != nil || sellRatio == 0 {
+			continue
+		}
+		timestamp, err := strconv.ParseInt(item.Timestamp, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		results = append(results, &LongShortRatioData{
+			Exchange:  "bybit",
+			Symbol:    symbol,
+			Metric:    MetricGlobalAccount,
+			Period:    period,
+			Ratio:     buyRatio / sellRatio,
+			Timestamp: time.Unix(timestamp/1000, 0),
+		})
+	}
+
+	// 记录成功的日志
+	apiLog.Success = true
+	apiLog.DataCount = len(results)
+	b.saveLog(ctx, apiLog)
+
+	return results, nil
+}
+
+// GetKlines 获取K线数据（按时间升序返回）
+func (b *BybitService) GetKlines(ctx context.Context, symbol, period string, limit int) ([]*KlineData, error) {
+	interval, ok := bybitKlineIntervals[period]
+	if !ok {
+		return nil, fmt.Errorf("Bybit不支持的时间粒度: %s", period)
+	}
+
+	url := fmt.Sprintf("%s/v5/market/kline?category=linear&symbol=%s&interval=%s&limit=%d",
+		b.baseURL, symbol, interval, limit)
+
+	// 创建日志记录
+	apiLog := &models.APILog{
+		Exchange: "bybit",
+		Symbol:   symbol,
+		Period:   period,
+		Limit:    limit,
+		URL:      url,
+	}
+
+	var response BybitKlineResponse
+	if err := b.fetchJSON(ctx, apiLog, &response); err != nil {
+		return nil, err
+	}
+
+	// Bybit按时间倒序返回
+	var results []*KlineData
+	for i := len(response.List) - 1; i >= 0; i-- {
+		row := response.List[i]
+		if len(row) < 6 {
+			continue
+		}
+
+		values := make([]float64, 6)
+		valid := true
+		for j := 0; j < 6; j++ {
+			v, err := strconv.ParseFloat(row[j], 64)
+			if err != nil {
+				valid = false
+				break
+			}
+			values[j] = v
+		}
+		if !valid {
+			continue
+		}
+
+		results = append(results, &KlineData{
+			Exchange: "bybit",
+			Symbol:   symbol,
+			Period:   period,
+			OpenTime: time.Unix(int64(values[0])/1000, 0),
+			Open:     values[1],
+			High:     values[2],
+			Low:      values[3],
+			Close:    values[4],
+			Volume:   values[5],
+		})
+	}
+
+	// 记录成功的日志
+	apiLog.Success = true
+	apiLog.DataCount = len(results)
+	b.saveLog(ctx, apiLog)
+
+	return results, nil
+}
+
+// GetSymbols 获取USDT本位永续合约列表，持仓价值取自行情接口
+func (b *BybitService) GetSymbols(ctx context.Context) ([]*SymbolInfo, error) {
+	var results []*SymbolInfo
+	cursor := ""
+	for {
+		url := fmt.Sprintf("%s/v5/market/instruments-info?category=linear&limit=1000", b.baseURL)
+		if cursor != "" {
+			url += "&cursor=" + cursor
+		}
+
+		// 创建日志记录
+		apiLog := &models.APILog{
+			Exchange: "bybit",
+			Limit:    1000,
+			URL:      url,
+		}
+
+		var response BybitInstrumentsResponse
+		if err := b.fetchJSON(ctx, apiLog, &response); err != nil {
+			return nil, err
+		}
+
+		for _, item := range response.List {
+			if item.ContractType != "LinearPerpetual" || item.QuoteCoin != "USDT" || item.Status != "Trading" {
+				continue
+			}
+			results = append(results, &SymbolInfo{
+				Exchange:       "bybit",
+				Symbol:         normalizeSymbol(item.BaseCoin, item.QuoteCoin),
+				ExchangeSymbol: item.Symbol,
+				BaseAsset:      item.BaseCoin,
+				QuoteAsset:     item.QuoteCoin,
+			})
+		}
+
+		// 记录成功的日志
+		apiLog.Success = true
+		apiLog.DataCount = len(response.List)
+		b.saveLog(ctx, apiLog)
+
+		if response.NextPageCursor == "" {
+			break
+		}
+		cursor = response.NextPageCursor
+	}
+
+	url := fmt.Sprintf("%s/v5/market/tickers?category=linear", b.baseURL)
+	apiLog := &models.APILog{
+		Exchange: "bybit",
+		URL:      url,
+	}
+
+	var tickers BybitTickersResponse
+	if err := b.fetchJSON(ctx, apiLog, &tickers); err != nil {
+		// 持仓价值只用于排序，获取失败时仍返回合约列表
+		return results, nil
+	}
+
+	openInterest := make(map[string]float64, len(tickers.List))
+	for _, ticker := range tickers.List {
+		if value, err := strconv.ParseFloat(ticker.OpenInterestValue, 64); err == nil {
+			openInterest[ticker.Symbol] = value
+		}
+	}
+	for _, item := range results {
+		item.OpenInterestUSD = openInterest[item.ExchangeSymbol]
+	}
+
+	apiLog.Success = true
+	apiLog.DataCount = len(tickers.List)
+	b.saveLog(ctx, apiLog)
+
+	return results, nil
+}
+
+// fetchJSON 请求apiLog.URL并将响应中的result字段解析到out，失败时记录API日志
+func (b *BybitService) fetchJSON(ctx context.Context, apiLog *models.APILog, out interface{}) error {
+	startTime := time.Now()
+	resp, err := b.http.Get(ctx, apiLog.URL)
+	apiLog.ResponseTime = time.Since(startTime).Milliseconds()
+	if resp != nil {
+		apiLog.StatusCode = resp.StatusCode
+	}
+
+	if err == nil {
+		err = b.decode(resp.Body, out)
+	} else if resp != nil && resp.StatusCode == http.StatusForbidden {
+		// Bybit对超出频率限制的IP返回403
+		err = withCodeKind(err, ErrRateLimited)
+	}
+	if err != nil {
+		markFailed(apiLog, err)
+		b.saveLog(ctx, apiLog)
+		return err
+	}
+
+	return nil
+}
+
+// decode 检查Bybit响应的业务错误码并将result字段解析到out
+func (b *BybitService) decode(body []byte, out interface{}) error {
+	var response bybitEnvelope
+	if err := json.Unmarshal(body, &response); err != nil {
+		return decodeError("Bybit", err)
+	}
+
+	if response.RetCode != 0 {
+		return codeError("Bybit", bybitErrorKinds[response.RetCode], response.RetCode, response.RetMsg)
+	}
+
+	if err := json.Unmarshal(response.Result, out); err != nil {
+		return decodeError("Bybit", err)
+	}
+	return nil
+}
+
+// saveLog 保存API日志，请求已取消时不再写入
+func (b *BybitService) saveLog(ctx context.Context, apiLog *models.APILog) {
+	if db := database.GetDB(); db != nil {
+		repo := models.NewAPILogRepository(db)
+		if err := repo.Create(ctx, apiLog); err != nil && ctx.Err() == nil {
+			fmt.Printf("保存Bybit API日志失败: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const bybitAccountRatioFixture = `{
+	"retCode": 0,
+	"retMsg": "OK",
+	"result": {
+		"list": [
+			{"symbol": "BTCUSDT", "buyRatio": "0.6", "sellRatio": "0.4", "timestamp": "1700000600000"},
+			{"symbol": "BTCUSDT", "buyRatio": "0.5", "sellRatio": "0.5", "timestamp": "1700000300000"},
+			{"symbol": "BTCUSDT", "buyRatio": "bad", "sellRatio": "0.5", "timestamp": "1700000200000"},
+			{"symbol": "BTCUSDT", "buyRatio": "0.4", "sellRatio": "0.6", "timestamp": "1700000000000"}
+		]
+	}
+}`
+
+func TestBybitRatioHistory(t *testing.T) {
+	db := useTestDB(t)
+	server, requests := newFixtureServer(t, map[string]string{
+		"/v5/market/account-ratio": bybitAccountRatioFixture,
+	})
+	bybit := NewBybitService(testExchangeConfig(server.URL))
+
+	data, err := bybit.GetLongShortRatioHistory(context.Background(), "BTCUSDT", "5m", 4)
+	if err != nil {
+		t.Fatalf("获取多空比失败: %v", err)
+	}
+
+	req := <-requests
+	query := req.URL.Query()
+	if query.Get("category") != "linear" || query.Get("symbol") != "BTCUSDT" || query.Get("period") != "5min" || query.Get("limit") != "4" {
+		t.Errorf("请求参数错误: %s", req.URL.RawQuery)
+	}
+
+	// 无法解析的行被跳过，结果按时间升序
+	want := []struct {
+		ratio float64
+		ts    int64
+	}{
+		{0.4 / 0.6, 1700000000},
+		{1, 1700000300},
+		{1.5, 1700000600},
+	}
+	if len(data) != len(want) {
+		t.Fatalf("数据条数 = %d, 期望 %d", len(data), len(want))
+	}
+	for i, w := range want {
+		item := data[i]
+		if item.Exchange != "bybit" || item.Symbol != "BTCUSDT" || item.Metric != MetricGlobalAccount || item.Period != "5m" {
+			t.Errorf("第%d条数据字段错误: %+v", i, item)
+		}
+		if diff := item.Ratio - w.ratio; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("第%d条多空比 = %v, 期望 %v", i, item.Ratio, w.ratio)
+		}
+		if !item.Timestamp.Equal(time.Unix(w.ts, 0)) {
+			t.Errorf("第%d条时间 = %v, 期望 %v", i, item.Timestamp, time.Unix(w.ts, 0))
+		}
+	}
+
+	apiLog := lastAPILog(t, db)
+	if !apiLog.Success || apiLog.Exchange != "bybit" || apiLog.Symbol != "BTCUSDT" || apiLog.Period != "5m" ||
+		apiLog.StatusCode != http.StatusOK || apiLog.DataCount != 3 {
+		t.Errorf("API日志错误: %+v", apiLog)
+	}
+}
+
+func TestBybitPeriods(t *testing.T) {
+	server, requests := newFixtureServer(t, map[string]string{
+		"/v5/market/account-ratio": `{"retCode": 0, "retMsg": "OK", "result": {"list": []}}`,
+	})
+	bybit := NewBybitService(testExchangeConfig(server.URL))
+
+	for period, want := range map[string]string{
+		"5m": "5min", "15m": "15min", "30m": "30min", "1h": "1h", "4h": "4h", "1d": "1d",
+	} {
+		if _, err := bybit.GetLongShortRatioHistory(context.Background(), "BTCUSDT", period, 1); err != nil {
+			t.Errorf("%s: 获取多空比失败: %v", period, err)
+			continue
+		}
+		if got := (<-requests).URL.Query().Get("period"); got != want {
+			t.Errorf("%s: period参数 = %s, 期望 %s", period, got, want)
+		}
+	}
+
+	// 不支持的时间粒度不发送请求
+	if _, err := bybit.GetLongShortRatioHistory(context.Background(), "BTCUSDT", "2h", 1); err == nil {
+		t.Error("2h应返回不支持的时间粒度错误")
+	}
+	select {
+	case req := <-requests:
+		t.Errorf("不支持的时间粒度不应发送请求: %s", req.URL)
+	default:
+	}
+}
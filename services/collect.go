@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// collectWorkers 每个交易所同时执行的采集任务数，请求速度仍由交易所的限流器控制
+const collectWorkers = 4
+
+// CollectError 单个采集项的错误
+type CollectError struct {
+	Exchange string
+	Symbol   string
+	Item     string // 采集项，如指标和时间粒度
+	Err      error
+}
+
+func (e *CollectError) Error() string {
+	return fmt.Sprintf("%s %s %s: %v", e.Exchange, e.Symbol, e.Item, e.Err)
+}
+
+func (e *CollectError) Unwrap() error {
+	return e.Err
+}
+
+// CollectErrors 部分采集项失败时返回的错误，成功采集的数据仍会返回
+type CollectErrors []*CollectError
+
+func (e CollectErrors) Error() string {
+	const maxShown = 3
+
+	msgs := make([]string, 0, maxShown)
+	for i, err := range e {
+		if i == maxShown {
+			msgs = append(msgs, "...")
+			break
+		}
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d个采集项失败: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// collectTask 一个交易所的一个采集项
+type collectTask[T any] struct {
+	exchange string
+	symbol   string
+	item     string
+	fetch    func() ([]T, error)
+}
+
+// fanOut 按交易所分组并发执行采集任务，每个交易所最多collectWorkers个任务同时执行，交易所之间互不等待。
+// ctx结束后尚未开始的任务记为失败。数据按任务顺序返回，有任务失败时同时返回CollectErrors
+func fanOut[T any](ctx context.Context, tasks []collectTask[T]) ([]T, error) {
+	results := make([][]T, len(tasks))
+	errs := make([]error, len(tasks))
+
+	byExchange := make(map[string][]int)
+	for i, task := range tasks {
+		byExchange[task.exchange] = append(byExchange[task.exchange], i)
+	}
+
+	var wg sync.WaitGroup
+	for _, indexes := range byExchange {
+		queue := make(chan int, len(indexes))
+		for _, i := range indexes {
+			queue <- i
+		}
+		close(queue)
+
+		workers := min(collectWorkers, len(indexes))
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range queue {
+					if err := ctx.Err(); err != nil {
+						errs[i] = err
+						continue
+					}
+					results[i], errs[i] = tasks[i].fetch()
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	var data []T
+	var failed CollectErrors
+	for i, task := range tasks {
+		if errs[i] != nil {
+			failed = append(failed, &CollectError{Exchange: task.exchange, Symbol: task.symbol, Item: task.item, Err: errs[i]})
+			continue
+		}
+		data = append(data, results[i]...)
+	}
+	if len(failed) > 0 {
+		return data, failed
+	}
+	return data, nil
+}
+
+// single 将返回单条数据的请求转换为采集任务的请求
+func single[T any](fetch func() (T, error)) func() ([]T, error) {
+	return func() ([]T, error) {
+		item, err := fetch()
+		if err != nil {
+			return nil, err
+		}
+		return []T{item}, nil
+	}
+}
+
+// mergeCollectErrors 合并多次fanOut返回的错误，都成功时返回nil
+func mergeCollectErrors(errs ...error) error {
+	var merged CollectErrors
+	for _, err := range errs {
+		var failed CollectErrors
+		if errors.As(err, &failed) {
+			merged = append(merged, failed...)
+		} else if err != nil {
+			merged = append(merged, &CollectError{Err: err})
+		}
+	}
+	if len(merged) > 0 {
+		return merged
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// stubExchange 按交易对返回固定多空比的交易所，failing中的交易对返回错误
+type stubExchange struct {
+	ExchangeService
+
+	name    string
+	failing map[string]bool
+}
+
+func (s *stubExchange) Name() string {
+	return s.name
+}
+
+func (s *stubExchange) GetLongShortRatio(ctx context.Context, symbol string) (*LongShortRatioData, error) {
+	if s.failing[symbol] {
+		return nil, newExchangeError(s.name, ErrBadSymbol, errors.New("交易对不存在"))
+	}
+	return &LongShortRatioData{Exchange: s.name, Symbol: symbol, Metric: MetricGlobalAccount, Period: "5m", Ratio: 1, Timestamp: time.Unix(1700000000, 0)}, nil
+}
+
+func TestGetDataByExchange(t *testing.T) {
+	collector := NewDataCollectionService([]ExchangeService{
+		&stubExchange{name: "binance"},
+		&stubExchange{name: "okx", failing: map[string]bool{"ETHUSDT": true}},
+	}, []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"})
+
+	data, err := collector.GetDataByExchange(context.Background(), "okx")
+
+	// 失败的交易对通过CollectErrors返回，其余交易对的数据按顺序返回
+	var failed CollectErrors
+	if !errors.As(err, &failed) || len(failed) != 1 {
+		t.Fatalf("期望一个采集项失败，实际: %v", err)
+	}
+	if failed[0].Exchange != "okx" || failed[0].Symbol != "ETHUSDT" || failed[0].Item != MetricGlobalAccount || !errors.Is(failed[0], ErrBadSymbol) {
+		t.Errorf("采集错误字段错误: %+v", failed[0])
+	}
+	if len(data) != 2 || data[0].Symbol != "BTCUSDT" || data[1].Symbol != "SOLUSDT" || data[0].Exchange != "okx" {
+		t.Errorf("返回数据错误: %v", data)
+	}
+
+	data, err = collector.GetDataByExchange(context.Background(), "binance")
+	if err != nil || len(data) != 3 {
+		t.Errorf("GetDataByExchange(binance) = %d条, %v", len(data), err)
+	}
+
+	if _, err := collector.GetDataByExchange(context.Background(), "gate"); err == nil {
+		t.Error("未启用的交易所应返回错误")
+	}
+}
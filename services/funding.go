@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// FundingRateData 已结算资金费率数据通用结构
+type FundingRateData struct {
+	Exchange    string    `json:"exchange"`
+	Symbol      string    `json:"symbol"`
+	Rate        float64   `json:"rate"`
+	FundingTime time.Time `json:"funding_time"`
+}
+
+// PredictedFundingData 预测资金费率数据通用结构
+type PredictedFundingData struct {
+	Exchange        string    `json:"exchange"`
+	Symbol          string    `json:"symbol"`
+	Rate            float64   `json:"rate"`              // 下一次结算的预测费率
+	NextFundingTime time.Time `json:"next_funding_time"` // 下一次结算时间
+	Timestamp       time.Time `json:"timestamp"`         // 数据时间戳
+}
+
+// FundingService 支持资金费率数据的交易所服务
+type FundingService interface {
+	GetFundingRateHistory(ctx context.Context, symbol string, limit int) ([]*FundingRateData, error)
+	GetPredictedFunding(ctx context.Context, symbol string) (*PredictedFundingData, error)
+}
+
+// CollectFunding 并发收集所有支持资金费率的交易所的最近结算费率和预测费率，两类数据的失败项合并返回
+func (d *DataCollectionService) CollectFunding(ctx context.Context) ([]*FundingRateData, []*PredictedFundingData, error) {
+	var historyTasks []collectTask[*FundingRateData]
+	var predictedTasks []collectTask[*PredictedFundingData]
+	for _, exchange := range d.exchanges {
+		fundingService, ok := exchange.(FundingService)
+		if !ok {
+			continue
+		}
+
+		for _, symbol := range d.Symbols() {
+			symbol := symbol
+			historyTasks = append(historyTasks, collectTask[*FundingRateData]{
+				exchange: exchange.Name(),
+				symbol:   symbol,
+				item:     "资金费率",
+				// 每8小时结算一次，取最近3条足以覆盖收集间隔
+				fetch: func() ([]*FundingRateData, error) {
+					return fundingService.GetFundingRateHistory(ctx, symbol, 3)
+				},
+			})
+			predictedTasks = append(predictedTasks, collectTask[*PredictedFundingData]{
+				exchange: exchange.Name(),
+				symbol:   symbol,
+				item:     "预测资金费率",
+				fetch: single(func() (*PredictedFundingData, error) {
+					return fundingService.GetPredictedFunding(ctx, symbol)
+				}),
+			})
+		}
+	}
+
+	var (
+		wg                       sync.WaitGroup
+		history                  []*FundingRateData
+		predicted                []*PredictedFundingData
+		historyErr, predictedErr error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		history, historyErr = fanOut(ctx, historyTasks)
+	}()
+	go func() {
+		defer wg.Done()
+		predicted, predictedErr = fanOut(ctx, predictedTasks)
+	}()
+	wg.Wait()
+
+	return history, predicted, mergeCollectErrors(historyErr, predictedErr)
+}
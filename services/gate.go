@@ -0,0 +1,384 @@
+package services
+
+import (
+	"CurrencyMonitor/config"
+	"CurrencyMonitor/database"
+	"CurrencyMonitor/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GateContractStatResponse Gate.io合约统计API响应结构
+type GateContractStatResponse struct {
+	Time          int64   `json:"time"`
+	LsrTaker      float64 `json:"lsr_taker"`
+	LsrAccount    float64 `json:"lsr_account"`
+	TopLsrAccount float64 `json:"top_lsr_account"`
+	TopLsrSize    float64 `json:"top_lsr_size"`
+}
+
+// value 获取指定指标的比值
+func (r GateContractStatResponse) value(metric string) float64 {
+	switch metric {
+	case MetricTopAccount:
+		return r.TopLsrAccount
+	case MetricTopPosition:
+		return r.TopLsrSize
+	case MetricTakerVolume:
+		return r.LsrTaker
+	default:
+		return r.LsrAccount
+	}
+}
+
+// GateCandlestickResponse Gate.io K线API响应结构
+type GateCandlestickResponse struct {
+	T int64   `json:"t"`
+	O string  `json:"o"`
+	H string  `json:"h"`
+	L string  `json:"l"`
+	C string  `json:"c"`
+	V float64 `json:"v"`
+}
+
+// GateContractResponse Gate.io合约信息API响应结构
+type GateContractResponse struct {
+	Name             string `json:"name"` // BTC_USDT
+	QuantoMultiplier string `json:"quanto_multiplier"`
+	MarkPrice        string `json:"mark_price"`
+	PositionSize     int64  `json:"position_size"` // 总持仓张数
+	InDelisting      bool   `json:"in_delisting"`
+}
+
+// GateErrorResponse Gate.io错误响应结构
+type GateErrorResponse struct {
+	Label   string `json:"label"`
+	Message string `json:"message"`
+}
+
+// gateErrorKinds Gate.io错误标签对应的错误类型
+var gateErrorKinds = map[string]error{
+	"TOO_MANY_REQUESTS":     ErrRateLimited,
+	"SERVER_ERROR":          ErrUnavailable,
+	"CONTRACT_NOT_FOUND":    ErrBadSymbol,
+	"INVALID_CONTRACT":      ErrBadSymbol,
+	"INVALID_CURRENCY_PAIR": ErrBadSymbol,
+}
+
+// gatePeriods Gate.io支持的时间粒度
+var gatePeriods = map[string]bool{
+	"5m":  true,
+	"15m": true,
+	"30m": true,
+	"1h":  true,
+	"4h":  true,
+	"1d":  true,
+}
+
+// gateRateLimits Gate.io限流规则：公共接口每个IP每10秒最多200次请求
+var gateRateLimits = rateLimits{
+	total: quota{limit: 200, interval: 10 * time.Second},
+}
+
+// GateService Gate.io服务
+type GateService struct {
+	baseURL string
+	http    *exchangeClient
+}
+
+func init() {
+	RegisterExchange("gate", func(cfg config.ExchangeConfig) ExchangeService { return NewGateService(cfg) })
+}
+
+// NewGateService 使用交易所配置创建Gate.io服务
+func NewGateService(cfg config.ExchangeConfig) *GateService {
+	return &GateService{
+		baseURL: cfg.BaseURL,
+		http:    newExchangeClient("Gate.io", cfg, rateLimiter("gate", gateRateLimits), circuitBreakers("gate", cfg)),
+	}
+}
+
+// Name 交易所名称
+func (g *GateService) Name() string {
+	return "gate"
+}
+
+// GetLongShortRatio 获取多空比数据
+func (g *GateService) GetLongShortRatio(ctx context.Context, symbol string) (*LongShortRatioData, error) {
+	ratios, err := g.GetLongShortRatioHistory(ctx, symbol, "5m", 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(ratios) == 0 {
+		return nil, fmt.Errorf("没有获取到多空比数据")
+	}
+	return ratios[len(ratios)-1], nil
+}
+
+// GetLongShortRatioHistory 获取多空比历史数据（按时间升序返回）
+func (g *GateService) GetLongShortRatioHistory(ctx context.Context, symbol, period string, limit int) ([]*LongShortRatioData, error) {
+	return g.GetMetricHistory(ctx, symbol, MetricGlobalAccount, period, limit)
+}
+
+// SupportedMetrics 获取支持的指标类型
+func (g *GateService) SupportedMetrics() []string {
+	// 合约统计接口同时返回全部指标
+	return Metrics
+}
+
+// GetMetricHistory 获取指定指标的历史数据（按时间升序返回）
+func (g *GateService) GetMetricHistory(ctx context.Context, symbol, metric, period string, limit int) ([]*LongShortRatioData, error) {
+	return g.metricHistory(ctx, symbol, metric, period, limit, time.Time{})
+}
+
+// GetLongShortRatioRange 获取时间范围内的全市场账户多空比。接口只支持起始时间，
+// 从start开始最多返回100条，超出end的数据不返回
+func (g *GateService) GetLongShortRatioRange(ctx context.Context, symbol, period string, start, end time.Time) ([]*LongShortRatioData, error) {
+	data, err := g.metricHistory(ctx, symbol, MetricGlobalAccount, period, 100, start)
+	if err != nil {
+		return nil, err
+	}
+	results := data[:0]
+	for _, item := range data {
+		if !item.Timestamp.After(end) {
+			results = append(results, item)
+		}
+	}
+	return results, nil
+}
+
+// RatioLookback Gate.io多空比补齐的最长回溯时间
+func (g *GateService) RatioLookback(period string) time.Duration {
+	return 30 * 24 * time.Hour
+}
+
+// metricHistory 获取指定指标的历史数据，from不为零时从该时间开始查询
+func (g *GateService) metricHistory(ctx context.Context, symbol, metric, period string, limit int, from time.Time) ([]*LongShortRatioData, error) {
+	if !IsValidMetric(metric) {
+		return nil, fmt.Errorf("Gate.io不支持指标: %s", metric)
+	}
+	if !gatePeriods[period] {
+		return nil, fmt.Errorf("Gate.io不支持的时间粒度: %s", period)
+	}
+
+	contract, err := g.convertSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/api/v4/futures/usdt/contract_stats?contract=%s&interval=%s&limit=%d",
+		g.baseURL, contract, period, limit)
+	if !from.IsZero() {
+		url += fmt.Sprintf("&from=%d", from.Unix())
+	}
+
+	// 创建日志记录
+	apiLog := &models.APILog{
+		Exchange: "gate",
+		Symbol:   symbol,
+		Period:   period,
+		Limit:    limit,
+		URL:      url,
+	}
+
+	var stats []GateContractStatResponse
+	if err := g.fetchJSON(ctx, apiLog, &stats); err != nil {
+		return nil, err
+	}
+
+	var results []*LongShortRatioData
+	for _, stat := range stats {
+		results = append(results, &LongShortRatioData{
+			Exchange:  "gate",
+			Symbol:    symbol,
+			Metric:    metric,
+			Period:    period,
+			Ratio:     stat.value(metric),
+			Timestamp: time.Unix(stat.Time, 0),
+		})
+	}
+
+	// 记录成功的日志
+	apiLog.Success = true
+	apiLog.DataCount = len(results)
+	g.saveLog(ctx, apiLog)
+
+	return results, nil
+}
+
+// GetKlines 获取K线数据（按时间升序返回）
+func (g *GateService) GetKlines(ctx context.Context, symbol, period string, limit int) ([]*KlineData, error) {
+	if !gatePeriods[period] {
+		return nil, fmt.Errorf("Gate.io不支持的时间粒度: %s", period)
+	}
+
+	contract, err := g.convertSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/api/v4/futures/usdt/candlesticks?contract=%s&interval=%s&limit=%d",
+		g.baseURL, contract, period, limit)
+
+	// 创建日志记录
+	apiLog := &models.APILog{
+		Exchange: "gate",
+		Symbol:   symbol,
+		Period:   period,
+		Limit:    limit,
+		URL:      url,
+	}
+
+	var candles []GateCandlestickResponse
+	if err := g.fetchJSON(ctx, apiLog, &candles); err != nil {
+		return nil, err
+	}
+
+	var results []*KlineData
+	for _, candle := range candles {
+		values := make([]float64, 4)
+		valid := true
+		for j, raw := range []string{candle.O, candle.H, candle.L, candle.C} {
+			v, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				valid = false
+				break
+			}
+			values[j] = v
+		}
+		if !valid {
+			continue
+		}
+
+		results = append(results, &KlineData{
+			Exchange: "gate",
+			Symbol:   symbol,
+			Period:   period,
+			OpenTime: time.Unix(candle.T, 0),
+			Open:     values[0],
+			High:     values[1],
+			Low:      values[2],
+			Close:    values[3],
+			Volume:   candle.V,
+		})
+	}
+
+	// 记录成功的日志
+	apiLog.Success = true
+	apiLog.DataCount = len(results)
+	g.saveLog(ctx, apiLog)
+
+	return results, nil
+}
+
+// GetSymbols 获取USDT本位永续合约列表，持仓价值由持仓张数、合约乘数和标记价格计算
+func (g *GateService) GetSymbols(ctx context.Context) ([]*SymbolInfo, error) {
+	url := fmt.Sprintf("%s/api/v4/futures/usdt/contracts", g.baseURL)
+
+	// 创建日志记录
+	apiLog := &models.APILog{
+		Exchange: "gate",
+		URL:      url,
+	}
+
+	var contracts []GateContractResponse
+	if err := g.fetchJSON(ctx, apiLog, &contracts); err != nil {
+		return nil, err
+	}
+
+	var results []*SymbolInfo
+	for _, item := range contracts {
+		if item.InDelisting {
+			continue
+		}
+		parts := strings.SplitN(item.Name, "_", 2)
+		if len(parts) != 2 || parts[1] != "USDT" {
+			continue
+		}
+
+		info := &SymbolInfo{
+			Exchange:       "gate",
+			Symbol:         normalizeSymbol(parts[0], parts[1]),
+			ExchangeSymbol: item.Name,
+			BaseAsset:      parts[0],
+			QuoteAsset:     parts[1],
+		}
+		multiplier, err1 := strconv.ParseFloat(item.QuantoMultiplier, 64)
+		price, err2 := strconv.ParseFloat(item.MarkPrice, 64)
+		if err1 == nil && err2 == nil {
+			info.OpenInterestUSD = float64(item.PositionSize) * multiplier * price
+		}
+		results = append(results, info)
+	}
+
+	// 记录成功的日志
+	apiLog.Success = true
+	apiLog.DataCount = len(results)
+	g.saveLog(ctx, apiLog)
+
+	return results, nil
+}
+
+// fetchJSON 请求apiLog.URL并解析JSON响应，失败时记录API日志
+func (g *GateService) fetchJSON(ctx context.Context, apiLog *models.APILog, out interface{}) error {
+	startTime := time.Now()
+	resp, err := g.http.Get(ctx, apiLog.URL)
+	apiLog.ResponseTime = time.Since(startTime).Milliseconds()
+	if resp != nil {
+		apiLog.StatusCode = resp.StatusCode
+	}
+
+	if err == nil {
+		if jsonErr := json.Unmarshal(resp.Body, out); jsonErr != nil {
+			err = decodeError("Gate.io", jsonErr)
+		}
+	} else {
+		err = g.classify(resp, err)
+	}
+	if err != nil {
+		markFailed(apiLog, err)
+		g.saveLog(ctx, apiLog)
+		return err
+	}
+
+	return nil
+}
+
+// classify 根据HTTP错误响应中的Gate.io错误标签修正错误类型
+func (g *GateService) classify(resp *exchangeResponse, err error) error {
+	if resp == nil || resp.StatusCode == http.StatusOK {
+		return err
+	}
+
+	var response GateErrorResponse
+	if json.Unmarshal(resp.Body, &response) != nil || response.Label == "" {
+		return err
+	}
+	return withCodeKind(err, gateErrorKinds[response.Label])
+}
+
+// convertSymbol 转换交易对格式，Gate.io使用下划线分隔的合约名称，如BTC_USDT。
+// 只支持USDT本位合约，其他计价货币的交易对返回ErrBadSymbol
+func (g *GateService) convertSymbol(symbol string) (string, error) {
+	base, ok := strings.CutSuffix(symbol, "_USDT")
+	if !ok {
+		base, ok = strings.CutSuffix(symbol, "USDT")
+	}
+	if !ok || base == "" || strings.Contains(base, "_") {
+		return "", newExchangeError("Gate.io", ErrBadSymbol, fmt.Errorf("只支持USDT本位合约: %s", symbol))
+	}
+	return base + "_USDT", nil
+}
+
+// saveLog 保存API日志，请求已取消时不再写入
+func (g *GateService) saveLog(ctx context.Context, apiLog *models.APILog) {
+	if db := database.GetDB(); db != nil {
+		repo := models.NewAPILogRepository(db)
+		if err := repo.Create(ctx, apiLog); err != nil && ctx.Err() == nil {
+			fmt.Printf("保存Gate.io API日志失败: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// gateContractStatsFixture Gate.io合约统计接口按时间升序返回
+const gateContractStatsFixture = `[
+	{"time": 1700000000, "lsr_taker": 1.1, "lsr_account": 1.2, "top_lsr_account": 1.3, "top_lsr_size": 1.4},
+	{"time": 1700000300, "lsr_taker": 2.1, "lsr_account": 2.2, "top_lsr_account": 2.3, "top_lsr_size": 2.4},
+	{"time": 1700000600, "lsr_taker": 3.1, "lsr_account": 3.2, "top_lsr_account": 3.3, "top_lsr_size": 3.4}
+]`
+
+func TestGateConvertSymbol(t *testing.T) {
+	gate := NewGateService(testExchangeConfig(""))
+
+	for symbol, want := range map[string]string{
+		"BTCUSDT":      "BTC_USDT",
+		"BTC_USDT":     "BTC_USDT",
+		"1000PEPEUSDT": "1000PEPE_USDT",
+	} {
+		got, err := gate.convertSymbol(symbol)
+		if err != nil || got != want {
+			t.Errorf("convertSymbol(%s) = %q, %v, 期望 %q", symbol, got, err, want)
+		}
+	}
+
+	for _, symbol := range []string{"BTCUSDC", "BTC_USD", "BTC", "USDT", "_USDT"} {
+		if got, err := gate.convertSymbol(symbol); !errors.Is(err, ErrBadSymbol) {
+			t.Errorf("convertSymbol(%s) = %q, %v, 期望ErrBadSymbol", symbol, got, err)
+		}
+	}
+}
+
+func TestGateMetricHistory(t *testing.T) {
+	db := useTestDB(t)
+	server, requests := newFixtureServer(t, map[string]string{
+		"/api/v4/futures/usdt/contract_stats": gateContractStatsFixture,
+	})
+	gate := NewGateService(testExchangeConfig(server.URL))
+
+	want := map[string][]float64{
+		MetricGlobalAccount: {1.2, 2.2, 3.2},
+		MetricTopAccount:    {1.3, 2.3, 3.3},
+		MetricTopPosition:   {1.4, 2.4, 3.4},
+		MetricTakerVolume:   {1.1, 2.1, 3.1},
+	}
+	for metric, ratios := range want {
+		data, err := gate.GetMetricHistory(context.Background(), "BTCUSDT", metric, "5m", 3)
+		if err != nil {
+			t.Fatalf("%s: 获取数据失败: %v", metric, err)
+		}
+
+		query := (<-requests).URL.Query()
+		if query.Get("contract") != "BTC_USDT" || query.Get("interval") != "5m" || query.Get("limit") != "3" {
+			t.Errorf("%s: 请求参数错误: %v", metric, query)
+		}
+
+		if len(data) != len(ratios) {
+			t.Fatalf("%s: 数据条数 = %d, 期望 %d", metric, len(data), len(ratios))
+		}
+		for i, ratio := range ratios {
+			item := data[i]
+			if item.Exchange != "gate" || item.Symbol != "BTCUSDT" || item.Metric != metric || item.Period != "5m" {
+				t.Errorf("%s: 第%d条数据字段错误: %+v", metric, i, item)
+			}
+			if item.Ratio != ratio {
+				t.Errorf("%s: 第%d条比值 = %v, 期望 %v", metric, i, item.Ratio, ratio)
+			}
+			if wantTime := time.Unix(1700000000+int64(i)*300, 0); !item.Timestamp.Equal(wantTime) {
+				t.Errorf("%s: 第%d条时间 = %v, 期望 %v", metric, i, item.Timestamp, wantTime)
+			}
+		}
+	}
+
+	// 最新数据取升序结果的最后一条
+	latest, err := gate.GetLongShortRatio(context.Background(), "BTCUSDT")
+	if err != nil {
+		t.Fatalf("获取最新多空比失败: %v", err)
+	}
+	<-requests
+	if latest.Ratio != 3.2 {
+		t.Errorf("最新多空比 = %v, 期望 3.2", latest.Ratio)
+	}
+
+	logs := apiLogs(t, db)
+	if len(logs) != len(want)+1 {
+		t.Fatalf("API日志条数 = %d, 期望 %d", len(logs), len(want)+1)
+	}
+	apiLog := logs[0]
+	if !apiLog.Success || apiLog.Exchange != "gate" || apiLog.Symbol != "BTCUSDT" || apiLog.Period != "5m" ||
+		apiLog.Limit != 3 || apiLog.StatusCode != http.StatusOK || apiLog.DataCount != 3 {
+		t.Errorf("API日志错误: %+v", apiLog)
+	}
+}
+
+func TestGateRatioRange(t *testing.T) {
+	useTestDB(t)
+	server, requests := newFixtureServer(t, map[string]string{
+		"/api/v4/futures/usdt/contract_stats": gateContractStatsFixture,
+	})
+	gate := NewGateService(testExchangeConfig(server.URL))
+
+	start, end := time.Unix(1700000000, 0), time.Unix(1700000300, 0)
+	data, err := gate.GetLongShortRatioRange(context.Background(), "BTC_USDT", "5m", start, end)
+	if err != nil {
+		t.Fatalf("获取多空比失败: %v", err)
+	}
+	if from := (<-requests).URL.Query().Get("from"); from != "1700000000" {
+		t.Errorf("from参数 = %s, 期望 1700000000", from)
+	}
+
+	// 接口只支持起始时间，超出end的数据被过滤
+	if len(data) != 2 || !data[1].Timestamp.Equal(end) {
+		t.Errorf("返回数据错误: %v", data)
+	}
+}
+
+func TestGateUnsupportedSymbol(t *testing.T) {
+	server, requests := newFixtureServer(t, map[string]string{
+		"/api/v4/futures/usdt/contract_stats": gateContractStatsFixture,
+	})
+	gate := NewGateService(testExchangeConfig(server.URL))
+
+	if _, err := gate.GetLongShortRatio(context.Background(), "BTCUSDC"); !errors.Is(err, ErrBadSymbol) {
+		t.Errorf("期望ErrBadSymbol，实际: %v", err)
+	}
+	if _, err := gate.GetKlines(context.Background(), "BTCUSDC", "5m", 1); !errors.Is(err, ErrBadSymbol) {
+		t.Errorf("期望ErrBadSymbol，实际: %v", err)
+	}
+	select {
+	case req := <-requests:
+		t.Errorf("不支持的交易对不应发送请求: %s", req.URL)
+	default:
+	}
+}
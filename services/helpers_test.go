@@ -0,0 +1,177 @@
+package services
+
+import (
+	"CurrencyMonitor/config"
+	"CurrencyMonitor/database"
+	"CurrencyMonitor/internal/testdb"
+	"CurrencyMonitor/models"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// testExchangeConfig 指向测试服务器的交易所配置，不重试也不熔断
+func testExchangeConfig(baseURL string) config.ExchangeConfig {
+	return config.ExchangeConfig{
+		BaseURL:     baseURL,
+		Timeout:     5 * time.Second,
+		MaxAttempts: 1,
+	}
+}
+
+// newFixtureServer 启动按路径返回固定响应的交易所替身，收到的请求通过requests返回
+func newFixtureServer(t *testing.T, fixtures map[string]string) (*httptest.Server, <-chan *http.Request) {
+	t.Helper()
+
+	requests := make(chan *http.Request, 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case requests <- r:
+		default:
+		}
+		body, ok := fixtures[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+// newStatusServer 启动对所有请求返回指定状态码和响应的交易所替身
+func newStatusServer(t *testing.T, status int, body string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// useTestDB 使用执行过全部迁移的内存SQLite数据库替换database.DB，测试结束后恢复
+func useTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db := testdb.Migrated(t)
+
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = previous })
+	return db
+}
+
+// apiLogs 按写入顺序获取测试数据库中的API日志
+func apiLogs(t *testing.T, db *gorm.DB) []models.APILog {
+	t.Helper()
+
+	var logs []models.APILog
+	if err := db.Order("id ASC").Find(&logs).Error; err != nil {
+		t.Fatalf("读取API日志失败: %v", err)
+	}
+	return logs
+}
+
+// lastAPILog 获取最后写入的一条API日志
+func lastAPILog(t *testing.T, db *gorm.DB) models.APILog {
+	t.Helper()
+
+	logs := apiLogs(t, db)
+	if len(logs) == 0 {
+		t.Fatal("没有写入API日志")
+	}
+	return logs[len(logs)-1]
+}
+
+// errorFixture 交易所返回的错误响应及期望的错误分类
+type errorFixture struct {
+	name      string
+	status    int
+	body      string
+	kind      error  // 期望的错误类型，nil表示不归类
+	errorType string // 期望写入API日志的错误类型
+}
+
+// TestExchangeErrors 各交易所的错误响应按错误码分类，并写入失败的API日志
+func TestExchangeErrors(t *testing.T) {
+	exchanges := []struct {
+		name     string
+		newFn    func(cfg config.ExchangeConfig) ExchangeService
+		fixtures []errorFixture
+	}{
+		{"binance", func(cfg config.ExchangeConfig) ExchangeService { return NewBinanceService(cfg) }, []errorFixture{
+			{"交易对无效", http.StatusBadRequest, `{"code": -1121, "msg": "Invalid symbol."}`, ErrBadSymbol, "bad_symbol"},
+			{"请求权重超限", http.StatusTooManyRequests, `{"code": -1003, "msg": "Too many requests."}`, ErrRateLimited, "rate_limited"},
+			{"服务不可用", http.StatusServiceUnavailable, `service unavailable`, ErrUnavailable, "unavailable"},
+			{"未分类错误码", http.StatusBadRequest, `{"code": -1102, "msg": "Mandatory parameter 'period' was not sent."}`, nil, ""},
+			{"响应格式错误", http.StatusOK, `{"data": []}`, ErrDecode, "decode"},
+		}},
+		{"okx", func(cfg config.ExchangeConfig) ExchangeService { return NewOKXService(cfg) }, []errorFixture{
+			{"产品不存在", http.StatusOK, `{"code": "51001", "msg": "Instrument ID does not exist", "data": []}`, ErrBadSymbol, "bad_symbol"},
+			{"请求频率过高", http.StatusTooManyRequests, `{"code": "50011", "msg": "Too Many Requests"}`, ErrRateLimited, "rate_limited"},
+			{"系统繁忙", http.StatusServiceUnavailable, `{"code": "50013", "msg": "Systems are busy"}`, ErrUnavailable, "unavailable"},
+			{"未分类错误码", http.StatusOK, `{"code": "51000", "msg": "Parameter period error", "data": []}`, nil, ""},
+			{"响应格式错误", http.StatusOK, `{"code": "0", "data": {}}`, ErrDecode, "decode"},
+		}},
+		{"bybit", func(cfg config.ExchangeConfig) ExchangeService { return NewBybitService(cfg) }, []errorFixture{
+			{"请求频率过高", http.StatusOK, `{"retCode": 10006, "retMsg": "Too many visits"}`, ErrRateLimited, "rate_limited"},
+			{"服务内部错误", http.StatusOK, `{"retCode": 10016, "retMsg": "Internal error"}`, ErrUnavailable, "unavailable"},
+			{"未分类错误码", http.StatusOK, `{"retCode": 10001, "retMsg": "params error"}`, nil, ""},
+			{"IP被限制", http.StatusForbidden, `access denied`, ErrRateLimited, "rate_limited"},
+			{"响应格式错误", http.StatusOK, `<html>`, ErrDecode, "decode"},
+		}},
+		{"bitget", func(cfg config.ExchangeConfig) ExchangeService { return NewBitgetService(cfg) }, []errorFixture{
+			{"交易对不存在", http.StatusBadRequest, `{"code": "40034", "msg": "Parameter does not exist"}`, ErrBadSymbol, "bad_symbol"},
+			{"合约已下架", http.StatusOK, `{"code": "40309", "msg": "The contract has been removed"}`, ErrBadSymbol, "bad_symbol"},
+			{"请求频率过高", http.StatusTooManyRequests, `{"code": "429", "msg": "Too Many Requests"}`, ErrRateLimited, "rate_limited"},
+			{"服务不可用", http.StatusServiceUnavailable, `service unavailable`, ErrUnavailable, "unavailable"},
+			{"未分类错误码", http.StatusBadRequest, `{"code": "40017", "msg": "Parameter verification failed"}`, nil, ""},
+			{"响应格式错误", http.StatusOK, `{"code": "00000", "data": {}}`, ErrDecode, "decode"},
+		}},
+		{"gate", func(cfg config.ExchangeConfig) ExchangeService { return NewGateService(cfg) }, []errorFixture{
+			{"合约不存在", http.StatusBadRequest, `{"label": "CONTRACT_NOT_FOUND", "message": "contract not found"}`, ErrBadSymbol, "bad_symbol"},
+			{"请求频率过高", http.StatusTooManyRequests, `{"label": "TOO_MANY_REQUESTS", "message": "too many requests"}`, ErrRateLimited, "rate_limited"},
+			{"服务错误", http.StatusInternalServerError, `{"label": "SERVER_ERROR", "message": "internal error"}`, ErrUnavailable, "unavailable"},
+			{"未分类错误标签", http.StatusBadRequest, `{"label": "INVALID_PARAM_VALUE", "message": "invalid interval"}`, nil, ""},
+			{"响应格式错误", http.StatusOK, `{"time": 1}`, ErrDecode, "decode"},
+		}},
+	}
+
+	for _, exchange := range exchanges {
+		for _, tt := range exchange.fixtures {
+			t.Run(exchange.name+"/"+tt.name, func(t *testing.T) {
+				db := useTestDB(t)
+				server := newStatusServer(t, tt.status, tt.body)
+				service := exchange.newFn(testExchangeConfig(server.URL))
+
+				_, err := service.GetLongShortRatio(context.Background(), "BTCUSDT")
+				if err == nil {
+					t.Fatal("期望返回错误")
+				}
+				if tt.kind != nil && !errors.Is(err, tt.kind) {
+					t.Errorf("错误类型错误: %v", err)
+				}
+				if got := ErrorType(err); got != tt.errorType {
+					t.Errorf("ErrorType = %q, 期望 %q", got, tt.errorType)
+				}
+
+				apiLog := lastAPILog(t, db)
+				if apiLog.Success || apiLog.Exchange != exchange.name || apiLog.StatusCode != tt.status ||
+					apiLog.ErrorType != tt.errorType || apiLog.ErrorMsg == "" {
+					t.Errorf("API日志错误: %+v", apiLog)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"CurrencyMonitor/config"
+	"CurrencyMonitor/models"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// 交易所请求的错误类型，通过errors.Is判断
+var (
+	ErrRateLimited = errors.New("请求被限流")
+	ErrUnavailable = errors.New("服务不可用")
+	ErrBadSymbol   = errors.New("交易对不存在")
+	ErrDecode      = errors.New("解析响应失败")
+	ErrCircuitOpen = errors.New("熔断中")
+)
+
+// errorTypes 错误类型在API日志中的名称
+var errorTypes = []struct {
+	kind error
+	name string
+}{
+	{ErrRateLimited, "rate_limited"},
+	{ErrUnavailable, "unavailable"},
+	{ErrBadSymbol, "bad_symbol"},
+	{ErrDecode, "decode"},
+	{ErrCircuitOpen, "circuit_open"},
+}
+
+const (
+	maxRetryBackoff = 10 * time.Second // 指数退避的最长等待时间
+	maxRetryAfter   = 30 * time.Second // Retry-After的最长等待时间，超过时按该时长等待
+)
+
+// ExchangeError 交易所请求错误
+type ExchangeError struct {
+	Exchange   string        // 交易所名称
+	Kind       error         // 错误类型，为ErrRateLimited等之一，无法分类时为nil
+	StatusCode int           // HTTP状态码，没有收到响应时为0
+	RetryAfter time.Duration // 交易所要求的等待时间
+	Err        error
+}
+
+func (e *ExchangeError) Error() string {
+	msg := e.Exchange + " API"
+	if e.Kind != nil {
+		msg += e.Kind.Error()
+	} else {
+		msg += "请求失败"
+	}
+	if e.StatusCode != 0 {
+		msg += fmt.Sprintf("(HTTP %d)", e.StatusCode)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap 同时返回错误类型和原始错误
+func (e *ExchangeError) Unwrap() []error {
+	var errs []error
+	if e.Kind != nil {
+		errs = append(errs, e.Kind)
+	}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+// ErrorType 获取错误类型在API日志中的名称，无法分类的错误返回空字符串
+func ErrorType(err error) string {
+	for _, t := range errorTypes {
+		if errors.Is(err, t.kind) {
+			return t.name
+		}
+	}
+	return ""
+}
+
+// IsRetryable 判断错误是否为限流或服务不可用等可以稍后重试的错误
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrUnavailable)
+}
+
+// newExchangeError 创建交易所请求错误
+func newExchangeError(exchange string, kind error, err error) *ExchangeError {
+	return &ExchangeError{Exchange: exchange, Kind: kind, Err: err}
+}
+
+// exchangeResponse 交易所HTTP响应
+type exchangeResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// exchangeClient 各交易所共用的HTTP请求层，按错误类型重试，每次请求前等待限流器放行。
+// 接口熔断时请求直接失败，不等待超时
+type exchangeClient struct {
+	exchange    string
+	client      *http.Client
+	limiter     *RateLimiter
+	breakers    *circuitBreakerGroup
+	maxAttempts int
+	backoff     time.Duration
+	sleep       func(ctx context.Context, d time.Duration) error // 重试前等待，测试中替换以避免真实等待
+}
+
+// newExchangeClient 使用交易所配置创建HTTP请求层，limiter为nil时不限流，breakers为nil时不熔断
+func newExchangeClient(exchange string, cfg config.ExchangeConfig, limiter *RateLimiter, breakers *circuitBreakerGroup) *exchangeClient {
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+	return &exchangeClient{
+		exchange:    exchange,
+		client:      &http.Client{Timeout: cfg.Timeout},
+		limiter:     limiter,
+		breakers:    breakers,
+		maxAttempts: maxAttempts,
+		backoff:     cfg.RetryBackoff,
+		sleep:       sleepContext,
+	}
+}
+
+// Get 发送GET请求并读取响应，接口熔断时直接返回ErrCircuitOpen。
+// 收到HTTP响应时即使返回错误resp也不为nil，便于记录状态码
+func (c *exchangeClient) Get(ctx context.Context, rawURL string) (*exchangeResponse, error) {
+	if c.breakers == nil {
+		return c.get(ctx, rawURL)
+	}
+
+	endpoint := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		endpoint = u.Path
+	}
+	if err := c.breakers.allow(c.exchange, endpoint); err != nil {
+		return nil, err
+	}
+	resp, err := c.get(ctx, rawURL)
+	c.breakers.record(endpoint, err)
+	return resp, err
+}
+
+// get 发送GET请求。交易所限流、5xx和超时按指数退避加随机抖动重试，交易所返回Retry-After时至少等待该时长，
+// 最多等待maxRetryAfter。等待期间ctx取消时返回ctx的错误
+func (c *exchangeClient) get(ctx context.Context, url string) (*exchangeResponse, error) {
+	for attempt := 1; ; attempt++ {
+		if c.limiter != nil {
+			if err := c.limiter.Wait(ctx, url); err != nil {
+				return nil, newExchangeError(c.exchange, nil, err)
+			}
+		}
+
+		resp, err := c.do(ctx, url)
+		if resp != nil && c.limiter != nil {
+			c.limiter.Observe(resp.Header)
+		}
+		if err == nil || attempt >= c.maxAttempts || !IsRetryable(err) || ctx.Err() != nil {
+			return resp, err
+		}
+
+		wait := c.retryDelay(attempt)
+		var exchangeErr *ExchangeError
+		if errors.As(err, &exchangeErr) && exchangeErr.RetryAfter > wait {
+			wait = min(exchangeErr.RetryAfter, maxRetryAfter)
+		}
+
+		if err := c.sleep(ctx, wait); err != nil {
+			return resp, newExchangeError(c.exchange, nil, err)
+		}
+	}
+}
+
+// sleepContext 等待d，ctx取消时提前返回ctx的错误
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// do 发送一次请求，按HTTP状态码和网络错误分类
+func (c *exchangeClient) do(ctx context.Context, url string) (*exchangeResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, newExchangeError(c.exchange, nil, err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		// 调用方取消的请求不算交易所不可用
+		if ctx.Err() != nil {
+			return nil, newExchangeError(c.exchange, nil, ctx.Err())
+		}
+		return nil, newExchangeError(c.exchange, ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	result := &exchangeResponse{StatusCode: resp.StatusCode, Header: resp.Header, Body: body}
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return result, &ExchangeError{Exchange: c.exchange, Kind: ErrUnavailable, StatusCode: resp.StatusCode, Err: err}
+		}
+		return result, &ExchangeError{Exchange: c.exchange, StatusCode: resp.StatusCode, Err: fmt.Errorf("读取响应失败: %w", err)}
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		return result, nil
+	}
+
+	exchangeErr := &ExchangeError{
+		Exchange:   c.exchange,
+		StatusCode: resp.StatusCode,
+		Err:        fmt.Errorf("%s", truncateBody(body)),
+	}
+	switch {
+	// Binance超出限制后继续请求会返回418并封禁IP
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusTeapot:
+		exchangeErr.Kind = ErrRateLimited
+		exchangeErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+	case resp.StatusCode >= http.StatusInternalServerError:
+		exchangeErr.Kind = ErrUnavailable
+		exchangeErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+	// 公共行情接口的参数只有交易对和时间粒度，时间粒度在调用前已经校验，400和404基本都是交易对不存在或已下架
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusNotFound:
+		exchangeErr.Kind = ErrBadSymbol
+	}
+	return result, exchangeErr
+}
+
+// retryDelay 第attempt次请求失败后的等待时间：指数增长，取[d/2, d)内的随机值避免多个请求同时重试
+func (c *exchangeClient) retryDelay(attempt int) time.Duration {
+	d := c.backoff << (attempt - 1)
+	if d <= 0 || d > maxRetryBackoff {
+		d = maxRetryBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// parseRetryAfter 解析Retry-After响应头，支持秒数和HTTP日期两种格式
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// truncateBody 截取响应内容用于错误信息
+func truncateBody(body []byte) string {
+	const maxLen = 200
+	if len(body) > maxLen {
+		return string(body[:maxLen]) + "..."
+	}
+	return string(body)
+}
+
+// codeError 创建交易所返回业务错误码时的错误，kind为错误码对应的错误类型
+func codeError(exchange string, kind error, code interface{}, msg string) error {
+	return newExchangeError(exchange, kind, fmt.Errorf("错误码%v: %s", code, msg))
+}
+
+// withCodeKind 按HTTP错误响应中的业务错误码修正错误类型。kind为nil表示错误码无法分类，
+// 此时不再沿用按400/404推断的交易对不存在
+func withCodeKind(err error, kind error) error {
+	var exchangeErr *ExchangeError
+	if !errors.As(err, &exchangeErr) {
+		return err
+	}
+	if kind != nil {
+		exchangeErr.Kind = kind
+	} else if exchangeErr.Kind == ErrBadSymbol {
+		exchangeErr.Kind = nil
+	}
+	return err
+}
+
+// decodeError 创建解析响应失败的错误
+func decodeError(exchange string, err error) error {
+	return newExchangeError(exchange, ErrDecode, err)
+}
+
+// markFailed 记录请求失败的错误信息和错误类型
+func markFailed(apiLog *models.APILog, err error) {
+	apiLog.Success = false
+	apiLog.ErrorMsg = err.Error()
+	apiLog.ErrorType = ErrorType(err)
+}
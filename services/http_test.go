@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// sequenceResponse 测试服务器依次返回的响应
+type sequenceResponse struct {
+	status     int
+	retryAfter string
+}
+
+// newSequenceServer 启动按顺序返回responses的交易所替身，之后的请求重复最后一个响应，返回收到的请求数
+func newSequenceServer(t *testing.T, responses []sequenceResponse) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var count atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(count.Add(1))
+		resp := responses[min(n, len(responses))-1]
+		if resp.retryAfter != "" {
+			w.Header().Set("Retry-After", resp.retryAfter)
+		}
+		w.WriteHeader(resp.status)
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &count
+}
+
+func TestExchangeClientRetry(t *testing.T) {
+	backoff := 100 * time.Millisecond
+	tests := []struct {
+		name      string
+		responses []sequenceResponse
+		waits     []time.Duration // 每次重试前的等待时间，为0表示按退避时间等待
+	}{
+		{"503后重试一次成功", []sequenceResponse{{status: http.StatusServiceUnavailable}, {status: http.StatusOK}}, []time.Duration{0}},
+		{"429按Retry-After等待", []sequenceResponse{{status: http.StatusTooManyRequests, retryAfter: "1"}, {status: http.StatusOK}}, []time.Duration{time.Second}},
+		{"Retry-After超过上限时按上限等待", []sequenceResponse{{status: http.StatusTooManyRequests, retryAfter: "120"}, {status: http.StatusOK}}, []time.Duration{maxRetryAfter}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newSequenceServer(t, tt.responses)
+			cfg := testExchangeConfig(server.URL)
+			cfg.MaxAttempts = 3
+			cfg.RetryBackoff = backoff
+			client := newExchangeClient("test", cfg, nil, nil)
+			var waits []time.Duration
+			client.sleep = func(ctx context.Context, d time.Duration) error {
+				waits = append(waits, d)
+				return nil
+			}
+
+			resp, err := client.get(context.Background(), server.URL+"/api")
+			if err != nil || resp.StatusCode != http.StatusOK {
+				t.Fatalf("get = %v, %v, 期望成功", resp, err)
+			}
+			if n := int(requests.Load()); n != len(tt.responses) {
+				t.Errorf("请求次数 = %d, 期望 %d", n, len(tt.responses))
+			}
+			if len(waits) != len(tt.waits) {
+				t.Fatalf("等待次数 = %d, 期望 %d", len(waits), len(tt.waits))
+			}
+			for i, want := range tt.waits {
+				if want == 0 {
+					// 第一次重试的退避时间在[backoff/2, backoff]之间
+					if waits[i] < backoff/2 || waits[i] > backoff {
+						t.Errorf("第%d次等待 %v, 期望在%v和%v之间", i+1, waits[i], backoff/2, backoff)
+					}
+				} else if waits[i] != want {
+					t.Errorf("第%d次等待 %v, 期望 %v", i+1, waits[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestExchangeClientCancelDuringBackoff(t *testing.T) {
+	server, requests := newSequenceServer(t, []sequenceResponse{{status: http.StatusServiceUnavailable}})
+	cfg := testExchangeConfig(server.URL)
+	cfg.MaxAttempts = 3
+	cfg.RetryBackoff = 10 * time.Second
+	client := newExchangeClient("test", cfg, nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	resp, err := client.get(ctx, server.URL+"/api")
+	if !errors.Is(err, context.Canceled) || errors.Is(err, ErrUnavailable) {
+		t.Errorf("错误 = %v, 期望context.Canceled", err)
+	}
+	if resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("应返回最后一次收到的响应: %+v", resp)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("请求次数 = %d, 期望取消后不再重试", n)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("取消后等待了%v才返回", elapsed)
+	}
+}
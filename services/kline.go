@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// KlineData K线数据通用结构
+type KlineData struct {
+	Exchange string    `json:"exchange"`
+	Symbol   string    `json:"symbol"`
+	Period   string    `json:"period"`
+	OpenTime time.Time `json:"open_time"`
+	Open     float64   `json:"open"`
+	High     float64   `json:"high"`
+	Low      float64   `json:"low"`
+	Close    float64   `json:"close"`
+	Volume   float64   `json:"volume"` // 成交量（以币计，Gate.io为合约张数）
+}
+
+// KlineService 支持K线数据的交易所服务
+type KlineService interface {
+	GetKlines(ctx context.Context, symbol, period string, limit int) ([]*KlineData, error)
+}
+
+// periodDurations 时间粒度对应的时长
+var periodDurations = map[string]time.Duration{
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"30m": 30 * time.Minute,
+	"1h":  time.Hour,
+	"2h":  2 * time.Hour,
+	"4h":  4 * time.Hour,
+	"1d":  24 * time.Hour,
+}
+
+// PeriodDuration 获取时间粒度对应的时长
+func PeriodDuration(period string) (time.Duration, bool) {
+	d, ok := periodDurations[period]
+	return d, ok
+}
+
+// CollectKlines 并发收集所有支持K线的交易所最近的K线
+func (d *DataCollectionService) CollectKlines(ctx context.Context, period string, limit int) ([]*KlineData, error) {
+	var tasks []collectTask[*KlineData]
+	for _, exchange := range d.exchanges {
+		klineService, ok := exchange.(KlineService)
+		if !ok {
+			continue
+		}
+
+		for _, symbol := range d.Symbols() {
+			symbol := symbol
+			tasks = append(tasks, collectTask[*KlineData]{
+				exchange: exchange.Name(),
+				symbol:   symbol,
+				item:     "K线 " + period,
+				fetch: func() ([]*KlineData, error) {
+					return klineService.GetKlines(ctx, symbol, period, limit)
+				},
+			})
+		}
+	}
+
+	return fanOut(ctx, tasks)
+}
+
+// parseNumber 解析JSON中以字符串或数字表示的数值
+func parseNumber(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case string:
+		return strconv.ParseFloat(n, 64)
+	default:
+		return 0, fmt.Errorf("无法解析数值: %v", v)
+	}
+}
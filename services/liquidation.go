@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"time"
+)
+
+// 强平方向（被强平的仓位方向）
+const (
+	LiquidationSideLong  = "long"
+	LiquidationSideShort = "short"
+)
+
+// LiquidationData 强平事件数据通用结构
+type LiquidationData struct {
+	Exchange  string    `json:"exchange"`
+	Symbol    string    `json:"symbol"`
+	Side      string    `json:"side"`     // 被强平的仓位方向 (long, short)
+	Price     float64   `json:"price"`    // 成交价格
+	Quantity  float64   `json:"quantity"` // 数量（以币计）
+	Notional  float64   `json:"notional"` // 名义价值（USDT）
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// LiquidationService 通过REST接口提供强平事件的交易所服务
+type LiquidationService interface {
+	GetLiquidations(ctx context.Context, symbol string, limit int) ([]*LiquidationData, error)
+}
+
+// CollectLiquidations 通过REST接口并发收集所有交易所的最近强平事件
+func (d *DataCollectionService) CollectLiquidations(ctx context.Context) ([]*LiquidationData, error) {
+	var tasks []collectTask[*LiquidationData]
+	for _, exchange := range d.exchanges {
+		liqService, ok := exchange.(LiquidationService)
+		if !ok {
+			continue
+		}
+
+		for _, symbol := range d.Symbols() {
+			symbol := symbol
+			tasks = append(tasks, collectTask[*LiquidationData]{
+				exchange: exchange.Name(),
+				symbol:   symbol,
+				item:     "强平",
+				fetch: func() ([]*LiquidationData, error) {
+					return liqService.GetLiquidations(ctx, symbol, 100)
+				},
+			})
+		}
+	}
+
+	return fanOut(ctx, tasks)
+}
@@ -1,240 +1,910 @@
 package services
 
 import (
+	"CurrencyMonitor/config"
 	"CurrencyMonitor/database"
 	"CurrencyMonitor/models"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/gorilla/websocket"
 )
 
-// OKXLongShortRatioResponse OKX多空比API响应结构
-type OKXLongShortRatioResponse struct {
-	Code string     `json:"code"`
-	Msg  string     `json:"msg"`
-	Data [][]string `json:"data"` // OKX返回二维数组格式: [["timestamp", "ratio"], ...]
+// okxEnvelope OKX API通用响应结构
+type okxEnvelope struct {
+	Code string          `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+// okxErrorKinds OKX错误码对应的错误类型
+var okxErrorKinds = map[string]error{
+	"50001": ErrUnavailable, // 服务暂时不可用
+	"50004": ErrUnavailable, // 接口请求超时
+	"50011": ErrRateLimited, // 请求频率过高
+	"50013": ErrUnavailable, // 系统繁忙
+	"51001": ErrBadSymbol,   // 产品ID不存在
+}
+
+// OKXOpenInterestResponse OKX持仓量API响应结构
+type OKXOpenInterestResponse struct {
+	InstID string `json:"instId"`
+	Oi     string `json:"oi"`
+	OiCcy  string `json:"oiCcy"`
+	OiUsd  string `json:"oiUsd"`
+	Ts     string `json:"ts"`
+}
+
+// OKXFundingRateResponse OKX当前资金费率API响应结构
+type OKXFundingRateResponse struct {
+	InstID          string `json:"instId"`
+	FundingRate     string `json:"fundingRate"`
+	NextFundingRate string `json:"nextFundingRate"`
+	FundingTime     string `json:"fundingTime"`
+	NextFundingTime string `json:"nextFundingTime"`
+	Ts              string `json:"ts"`
+}
+
+// OKXFundingRateHistoryResponse OKX资金费率历史API响应结构
+type OKXFundingRateHistoryResponse struct {
+	InstID       string `json:"instId"`
+	FundingRate  string `json:"fundingRate"`
+	RealizedRate string `json:"realizedRate"`
+	FundingTime  string `json:"fundingTime"`
+}
+
+// OKXLiquidationResponse OKX强平订单API响应结构
+type OKXLiquidationResponse struct {
+	InstID  string `json:"instId"`
+	Uly     string `json:"uly"`
+	Details []struct {
+		Side    string `json:"side"`
+		PosSide string `json:"posSide"`
+		BkPx    string `json:"bkPx"`
+		Sz      string `json:"sz"`
+		Ts      string `json:"ts"`
+	} `json:"details"`
+}
+
+// OKXInstrumentResponse OKX合约信息API响应结构
+type OKXInstrumentResponse struct {
+	InstID string `json:"instId"`
+	CtVal  string `json:"ctVal"`
+}
+
+// OKXSwapInstrumentResponse OKX永续合约列表API响应结构
+type OKXSwapInstrumentResponse struct {
+	InstID    string `json:"instId"` // BTC-USDT-SWAP
+	Uly       string `json:"uly"`    // BTC-USDT
+	CtVal     string `json:"ctVal"`
+	SettleCcy string `json:"settleCcy"`
+	State     string `json:"state"`
+}
+
+// OKXStreamMessage OKX推送消息结构
+type OKXStreamMessage struct {
+	Event string `json:"event"` // 订阅确认或错误事件
+	Arg   struct {
+		Channel string `json:"channel"`
+		InstID  string `json:"instId"`
+	} `json:"arg"`
+	Data json.RawMessage `json:"data"`
+}
+
+// okxRateLimits OKX限流规则：按接口限制每个IP的请求频率，没有单独列出的公共接口为每2秒20次
+var okxRateLimits = rateLimits{
+	endpoints: []endpointQuota{
+		{prefix: "/api/v5/rubik/stat/", quota: quota{limit: 5, interval: 2 * time.Second}},
+		{prefix: "/api/v5/public/funding-rate-history", quota: quota{limit: 10, interval: 2 * time.Second}},
+		{prefix: "/api/v5/public/liquidation-orders", quota: quota{limit: 40, interval: 2 * time.Second}},
+		{prefix: "/api/v5/market/candles", quota: quota{limit: 40, interval: 2 * time.Second}},
+	},
+	defaultQuota: quota{limit: 20, interval: 2 * time.Second},
 }
 
 // OKXService OKX服务
 type OKXService struct {
-	baseURL     string
-	client      *http.Client
-	lastRequest time.Time
+	baseURL string
+	wsURL   string
+	http    *exchangeClient
+
+	ctValMu sync.Mutex
+	ctVals  map[string]float64 // instId -> 合约面值（以币计）
 }
 
-// NewOKXService 创建新的OKX服务
-func NewOKXService() *OKXService {
+func init() {
+	RegisterExchange("okx", func(cfg config.ExchangeConfig) ExchangeService { return NewOKXService(cfg) })
+}
+
+// NewOKXService 使用交易所配置创建OKX服务
+func NewOKXService(cfg config.ExchangeConfig) *OKXService {
 	return &OKXService{
-		baseURL: "https://www.okx.com",
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		baseURL: cfg.BaseURL,
+		wsURL:   cfg.WSURL,
+		http:    newExchangeClient("OKX", cfg, rateLimiter("okx", okxRateLimits), circuitBreakers("okx", cfg)),
+		ctVals:  make(map[string]float64),
 	}
 }
 
+// Name 交易所名称
+func (o *OKXService) Name() string {
+	return "okx"
+}
+
 // GetLongShortRatio 获取多空比数据
-func (o *OKXService) GetLongShortRatio(symbol string) (*LongShortRatioData, error) {
-	return o.GetLongShortRatioWithPeriod(symbol, "5m", 1)
+func (o *OKXService) GetLongShortRatio(ctx context.Context, symbol string) (*LongShortRatioData, error) {
+	return o.GetLongShortRatioWithPeriod(ctx, symbol, "5m", 1)
+}
+
+// GetLongShortRatioWithPeriod 获取指定周期的最新多空比数据
+func (o *OKXService) GetLongShortRatioWithPeriod(ctx context.Context, symbol, period string, limit int) (*LongShortRatioData, error) {
+	ratios, err := o.GetLongShortRatioHistory(ctx, symbol, period, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(ratios) == 0 {
+		return nil, fmt.Errorf("没有获取到多空比数据")
+	}
+	return ratios[len(ratios)-1], nil
 }
 
-// GetLongShortRatioWithPeriod 获取指定周期的多空比数据
-func (o *OKXService) GetLongShortRatioWithPeriod(symbol, period string, limit int) (*LongShortRatioData, error) {
-	// 限流：每次请求间隔至少1秒
-	if time.Since(o.lastRequest) < time.Second {
-		time.Sleep(time.Second - time.Since(o.lastRequest))
+// GetLongShortRatioHistory 获取多空比历史数据
+func (o *OKXService) GetLongShortRatioHistory(ctx context.Context, symbol, period string, limit int) ([]*LongShortRatioData, error) {
+	return o.ratioHistory(ctx, symbol, period, limit, time.Time{}, time.Time{})
+}
+
+// GetLongShortRatioRange 获取时间范围内的全市场账户多空比
+func (o *OKXService) GetLongShortRatioRange(ctx context.Context, symbol, period string, start, end time.Time) ([]*LongShortRatioData, error) {
+	return o.ratioHistory(ctx, symbol, period, 0, start, end)
+}
+
+// RatioLookback OKX按时间粒度限制可查询的历史范围：5分钟2天，小时30天，日180天
+func (o *OKXService) RatioLookback(period string) time.Duration {
+	d, _ := PeriodDuration(period)
+	switch {
+	case d < time.Hour:
+		return 2 * 24 * time.Hour
+	case d < 24*time.Hour:
+		return 30 * 24 * time.Hour
+	default:
+		return 180 * 24 * time.Hour
 	}
-	o.lastRequest = time.Now()
+}
 
+// ratioHistory 获取多空比历史数据，limit为0时不限制数量，start和end不为零时只查询该时间范围
+func (o *OKXService) ratioHistory(ctx context.Context, symbol, period string, limit int, start, end time.Time) ([]*LongShortRatioData, error) {
 	// OKX API使用不同的交易对格式，需要转换
 	instId := o.convertSymbol(symbol)
-	url := fmt.Sprintf("%s/api/v5/rubik/stat/contracts/long-short-account-ratio?ccy=%s&period=%s&limit=%d",
-		o.baseURL, instId, period, limit)
+	// OKX API不支持limit参数，我们获取全部数据然后截取
+	url := fmt.Sprintf("%s/api/v5/rubik/stat/contracts/long-short-account-ratio?ccy=%s&period=%s",
+		o.baseURL, instId, o.convertPeriod(period))
+	if !start.IsZero() {
+		url += fmt.Sprintf("&begin=%d&end=%d", start.UnixMilli(), end.UnixMilli())
+	}
 
-	resp, err := o.client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("请求OKX API失败: %w", err)
+	// 创建日志记录
+	apiLog := &models.APILog{
+		Exchange: "okx",
+		Symbol:   symbol,
+		Period:   period,
+		Limit:    limit,
+		URL:      url,
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("OKX API返回错误状态码: %d", resp.StatusCode)
+	// OKX返回的数据格式: [["timestamp", "ratio"], ...]，按时间倒序
+	var rows [][]string
+	if err := o.fetchJSON(ctx, apiLog, &rows); err != nil {
+		return nil, err
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
+	var results []*LongShortRatioData
+	for _, data := range rows {
+		if len(data) < 2 {
+			continue
+		}
+
+		ratioValue, err := strconv.ParseFloat(data[1], 64)
+		if err != nil {
+			continue
+		}
+
+		timestamp, err := strconv.ParseInt(data[0], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		results = append(results, &LongShortRatioData{
+			Exchange:  "okx",
+			Symbol:    symbol,
+			Metric:    MetricGlobalAccount,
+			Period:    period,
+			Ratio:     ratioValue,
+			Timestamp: time.Unix(timestamp/1000, 0),
+		})
 	}
 
-	var response OKXLongShortRatioResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("解析JSON失败: %w", err)
+	// 按时间升序排列并保留最近的limit条
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Timestamp.Before(results[j].Timestamp)
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[len(results)-limit:]
 	}
 
-	if response.Code != "0" {
-		return nil, fmt.Errorf("OKX API返回错误: %s", response.Msg)
+	// 记录成功的日志
+	apiLog.Success = true
+	apiLog.DataCount = len(results)
+	o.saveLog(ctx, apiLog)
+
+	return results, nil
+}
+
+// GetOpenInterest 获取最新持仓量
+func (o *OKXService) GetOpenInterest(ctx context.Context, symbol string) (*OpenInterestData, error) {
+	url := fmt.Sprintf("%s/api/v5/public/open-interest?instType=SWAP&instId=%s",
+		o.baseURL, o.swapInstID(symbol))
+
+	// 创建日志记录
+	apiLog := &models.APILog{
+		Exchange: "okx",
+		Symbol:   symbol,
+		Limit:    1,
+		URL:      url,
 	}
 
-	if len(response.Data) == 0 {
-		return nil, fmt.Errorf("没有获取到多空比数据")
+	var items []OKXOpenInterestResponse
+	if err := o.fetchJSON(ctx, apiLog, &items); err != nil {
+		return nil, err
 	}
 
-	// OKX返回的数据格式: [["timestamp", "ratio"], ...]
-	// 取最新的一条数据（第一个元素）
-	data := response.Data[0]
-	if len(data) < 2 {
-		return nil, fmt.Errorf("OKX数据格式错误")
+	if len(items) == 0 {
+		apiLog.Success = false
+		apiLog.ErrorMsg = "没有获取到持仓量数据"
+		o.saveLog(ctx, apiLog)
+		return nil, fmt.Errorf("没有获取到持仓量数据")
 	}
 
-	ratioValue, err := strconv.ParseFloat(data[1], 64)
+	data, err := o.parseOpenInterest(&items[0], symbol)
 	if err != nil {
-		return nil, fmt.Errorf("解析多空比数值失败: %w", err)
+		return nil, err
 	}
 
-	timestamp, err := strconv.ParseInt(data[0], 10, 64)
+	// 记录成功的日志
+	apiLog.Success = true
+	apiLog.DataCount = 1
+	o.saveLog(ctx, apiLog)
+
+	return data, nil
+}
+
+// parseOpenInterest 解析持仓量数据
+func (o *OKXService) parseOpenInterest(item *OKXOpenInterestResponse, symbol string) (*OpenInterestData, error) {
+	openInterest, err := strconv.ParseFloat(item.OiCcy, 64)
+	if err != nil {
+		return nil, fmt.Errorf("解析持仓量失败: %w", err)
+	}
+	notional, err := strconv.ParseFloat(item.OiUsd, 64)
+	if err != nil {
+		return nil, fmt.Errorf("解析持仓价值失败: %w", err)
+	}
+	timestamp, err := strconv.ParseInt(item.Ts, 10, 64)
 	if err != nil {
 		return nil, fmt.Errorf("解析时间戳失败: %w", err)
 	}
 
-	return &LongShortRatioData{
-		Exchange:  "okx",
-		Symbol:    symbol,
-		Ratio:     ratioValue,
-		Timestamp: time.Unix(timestamp/1000, 0),
+	return &OpenInterestData{
+		Exchange:     "okx",
+		Symbol:       symbol,
+		OpenInterest: openInterest,
+		Notional:     notional,
+		Timestamp:    time.Unix(timestamp/1000, 0),
 	}, nil
 }
 
-// GetLongShortRatioHistory 获取多空比历史数据
-func (o *OKXService) GetLongShortRatioHistory(symbol, period string, limit int) ([]*LongShortRatioData, error) {
-	startTime := time.Now()
+// GetOpenInterestHistory 获取持仓量历史数据（按时间升序返回）
+func (o *OKXService) GetOpenInterestHistory(ctx context.Context, symbol, period string, limit int) ([]*OpenInterestData, error) {
+	url := fmt.Sprintf("%s/api/v5/rubik/stat/contracts/open-interest-history?instId=%s&period=%s&limit=%d",
+		o.baseURL, o.swapInstID(symbol), o.convertPeriod(period), limit)
 
-	// 限流：每次请求间隔至少1秒
-	if time.Since(o.lastRequest) < time.Second {
-		time.Sleep(time.Second - time.Since(o.lastRequest))
+	// 创建日志记录
+	apiLog := &models.APILog{
+		Exchange: "okx",
+		Symbol:   symbol,
+		Period:   period,
+		Limit:    limit,
+		URL:      url,
 	}
-	o.lastRequest = time.Now()
 
-	// OKX API使用不同的交易对格式，需要转换
-	instId := o.convertSymbol(symbol)
-	// OKX API不支持limit参数，我们获取全部数据然后截取
-	url := fmt.Sprintf("%s/api/v5/rubik/stat/contracts/long-short-account-ratio?ccy=%s&period=%s",
-		o.baseURL, instId, period)
+	// OKX返回的数据格式: [["timestamp", "oi", "oiCcy", "oiUsd"], ...]，按时间倒序
+	var rows [][]string
+	if err := o.fetchJSON(ctx, apiLog, &rows); err != nil {
+		return nil, err
+	}
+
+	var results []*OpenInterestData
+	for i := len(rows) - 1; i >= 0; i-- {
+		row := rows[i]
+		if len(row) < 4 {
+			continue
+		}
+
+		timestamp, err := strconv.ParseInt(row[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		openInterest, err := strconv.ParseFloat(row[2], 64)
+		if err != nil {
+			continue
+		}
+		notional, err := strconv.ParseFloat(row[3], 64)
+		if err != nil {
+			continue
+		}
+
+		results = append(results, &OpenInterestData{
+			Exchange:     "okx",
+			Symbol:       symbol,
+			OpenInterest: openInterest,
+			Notional:     notional,
+			Timestamp:    time.Unix(timestamp/1000, 0),
+		})
+	}
+
+	// 记录成功的日志
+	apiLog.Success = true
+	apiLog.DataCount = len(results)
+	o.saveLog(ctx, apiLog)
+
+	return results, nil
+}
+
+// GetFundingRateHistory 获取已结算资金费率历史（按时间升序返回）
+func (o *OKXService) GetFundingRateHistory(ctx context.Context, symbol string, limit int) ([]*FundingRateData, error) {
+	url := fmt.Sprintf("%s/api/v5/public/funding-rate-history?instId=%s&limit=%d",
+		o.baseURL, o.swapInstID(symbol), limit)
 
 	// 创建日志记录
 	apiLog := &models.APILog{
 		Exchange: "okx",
 		Symbol:   symbol,
-		Period:   period,
+		Period:   "8h",
 		Limit:    limit,
 		URL:      url,
 	}
 
-	resp, err := o.client.Get(url)
-	responseTime := time.Since(startTime).Milliseconds()
+	var items []OKXFundingRateHistoryResponse
+	if err := o.fetchJSON(ctx, apiLog, &items); err != nil {
+		return nil, err
+	}
 
-	// 更新日志记录
-	apiLog.ResponseTime = responseTime
+	// OKX按时间倒序返回
+	var results []*FundingRateData
+	for i := len(items) - 1; i >= 0; i-- {
+		item := items[i]
 
-	if err != nil {
-		apiLog.Success = false
-		apiLog.ErrorMsg = err.Error()
-		apiLog.StatusCode = 0
-		o.saveLog(apiLog)
-		return nil, fmt.Errorf("请求OKX API失败: %w", err)
+		rate, err := strconv.ParseFloat(item.RealizedRate, 64)
+		if err != nil {
+			continue
+		}
+		fundingTime, err := strconv.ParseInt(item.FundingTime, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		results = append(results, &FundingRateData{
+			Exchange:    "okx",
+			Symbol:      symbol,
+			Rate:        rate,
+			FundingTime: time.Unix(fundingTime/1000, 0),
+		})
+	}
+
+	// 记录成功的日志
+	apiLog.Success = true
+	apiLog.DataCount = len(results)
+	o.saveLog(ctx, apiLog)
+
+	return results, nil
+}
+
+// GetPredictedFunding 获取预测资金费率和下一次结算时间
+func (o *OKXService) GetPredictedFunding(ctx context.Context, symbol string) (*PredictedFundingData, error) {
+	url := fmt.Sprintf("%s/api/v5/public/funding-rate?instId=%s", o.baseURL, o.swapInstID(symbol))
+
+	// 创建日志记录
+	apiLog := &models.APILog{
+		Exchange: "okx",
+		Symbol:   symbol,
+		Limit:    1,
+		URL:      url,
 	}
-	defer resp.Body.Close()
 
-	apiLog.StatusCode = resp.StatusCode
-	if resp.StatusCode != http.StatusOK {
+	var items []OKXFundingRateResponse
+	if err := o.fetchJSON(ctx, apiLog, &items); err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
 		apiLog.Success = false
-		apiLog.ErrorMsg = fmt.Sprintf("HTTP状态码: %d", resp.StatusCode)
-		o.saveLog(apiLog)
-		return nil, fmt.Errorf("OKX API返回错误状态码: %d", resp.StatusCode)
+		apiLog.ErrorMsg = "没有获取到资金费率数据"
+		o.saveLog(ctx, apiLog)
+		return nil, fmt.Errorf("没有获取到资金费率数据")
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	data, err := o.parsePredictedFunding(&items[0], symbol)
 	if err != nil {
-		apiLog.Success = false
-		apiLog.ErrorMsg = err.Error()
-		o.saveLog(apiLog)
-		return nil, fmt.Errorf("读取响应失败: %w", err)
+		return nil, err
 	}
 
-	var response OKXLongShortRatioResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		apiLog.Success = false
-		apiLog.ErrorMsg = err.Error()
-		o.saveLog(apiLog)
-		return nil, fmt.Errorf("解析JSON失败: %w", err)
+	// 记录成功的日志
+	apiLog.Success = true
+	apiLog.DataCount = 1
+	o.saveLog(ctx, apiLog)
+
+	return data, nil
+}
+
+// parsePredictedFunding 解析预测资金费率，OKX的fundingRate为本期（fundingTime结算）的预测费率
+func (o *OKXService) parsePredictedFunding(item *OKXFundingRateResponse, symbol string) (*PredictedFundingData, error) {
+	rate, err := strconv.ParseFloat(item.FundingRate, 64)
+	if err != nil {
+		return nil, fmt.Errorf("解析资金费率失败: %w", err)
+	}
+	fundingTime, err := strconv.ParseInt(item.FundingTime, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("解析结算时间失败: %w", err)
+	}
+	timestamp, err := strconv.ParseInt(item.Ts, 10, 64)
+	if err != nil {
+		timestamp = time.Now().Unix() * 1000
 	}
 
-	if response.Code != "0" {
-		apiLog.Success = false
-		apiLog.ErrorMsg = response.Msg
-		o.saveLog(apiLog)
-		return nil, fmt.Errorf("OKX API返回错误: %s", response.Msg)
+	return &PredictedFundingData{
+		Exchange:        "okx",
+		Symbol:          symbol,
+		Rate:            rate,
+		NextFundingTime: time.Unix(fundingTime/1000, 0),
+		Timestamp:       time.Unix(timestamp/1000, 0),
+	}, nil
+}
+
+// GetLiquidations 获取最近的强平订单
+func (o *OKXService) GetLiquidations(ctx context.Context, symbol string, limit int) ([]*LiquidationData, error) {
+	instId := o.swapInstID(symbol)
+	ctVal, err := o.contractValue(ctx, instId)
+	if err != nil {
+		return nil, err
 	}
 
-	var results []*LongShortRatioData
-	count := 0
-	for _, data := range response.Data {
-		if count >= limit {
-			break // 限制返回的数据点数量
-		}
+	url := fmt.Sprintf("%s/api/v5/public/liquidation-orders?instType=SWAP&uly=%s-USDT&state=filled&limit=%d",
+		o.baseURL, o.convertSymbol(symbol), limit)
 
-		if len(data) < 2 {
+	// 创建日志记录
+	apiLog := &models.APILog{
+		Exchange: "okx",
+		Symbol:   symbol,
+		Limit:    limit,
+		URL:      url,
+	}
+
+	var items []OKXLiquidationResponse
+	if err := o.fetchJSON(ctx, apiLog, &items); err != nil {
+		return nil, err
+	}
+
+	var results []*LiquidationData
+	for _, item := range items {
+		if item.InstID != "" && item.InstID != instId {
 			continue
 		}
 
-		ratioValue, err := strconv.ParseFloat(data[1], 64)
+		results = append(results, o.parseLiquidations(&item, symbol, ctVal)...)
+	}
+
+	// 记录成功的日志
+	apiLog.Success = true
+	apiLog.DataCount = len(results)
+	o.saveLog(ctx, apiLog)
+
+	return results, nil
+}
+
+// parseLiquidations 解析强平订单明细，sz为合约张数
+func (o *OKXService) parseLiquidations(item *OKXLiquidationResponse, symbol string, ctVal float64) []*LiquidationData {
+	var results []*LiquidationData
+	for _, detail := range item.Details {
+		price, err := strconv.ParseFloat(detail.BkPx, 64)
 		if err != nil {
 			continue
 		}
-
-		timestamp, err := strconv.ParseInt(data[0], 10, 64)
+		contracts, err := strconv.ParseFloat(detail.Sz, 64)
+		if err != nil {
+			continue
+		}
+		timestamp, err := strconv.ParseInt(detail.Ts, 10, 64)
 		if err != nil {
 			continue
 		}
 
-		results = append(results, &LongShortRatioData{
+		// posSide为被强平的仓位方向，单向持仓模式下通过成交方向推断
+		side := detail.PosSide
+		if side != LiquidationSideLong && side != LiquidationSideShort {
+			side = LiquidationSideLong
+			if detail.Side == "buy" {
+				side = LiquidationSideShort
+			}
+		}
+
+		quantity := contracts * ctVal
+		results = append(results, &LiquidationData{
 			Exchange:  "okx",
 			Symbol:    symbol,
-			Ratio:     ratioValue,
+			Side:      side,
+			Price:     price,
+			Quantity:  quantity,
+			Notional:  price * quantity,
 			Timestamp: time.Unix(timestamp/1000, 0),
 		})
-		count++
 	}
+	return results
+}
+
+// NewStream 创建订阅持仓量、资金费率和强平推送的流客户端
+func (o *OKXService) NewStream(symbols []string, handler func(*StreamEvent)) *StreamClient {
+	instSymbols := make(map[string]string, len(symbols))
+	var args []map[string]string
+	for _, symbol := range symbols {
+		instId := o.swapInstID(symbol)
+		instSymbols[instId] = symbol
+		args = append(args,
+			map[string]string{"channel": "open-interest", "instId": instId},
+			map[string]string{"channel": "funding-rate", "instId": instId},
+		)
+	}
+	args = append(args, map[string]string{"channel": "liquidation-orders", "instType": "SWAP"})
+
+	return NewStreamClient(StreamOptions{
+		Name: "okx",
+		URL:  o.wsURL,
+		// 强平推送按张数计量，订阅前缓存合约面值，读取推送时不再请求接口
+		Prepare: func(ctx context.Context) error {
+			for instId := range instSymbols {
+				if _, err := o.contractValue(ctx, instId); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Subscribe: func(conn *websocket.Conn) error {
+			return conn.WriteJSON(map[string]interface{}{
+				"op":   "subscribe",
+				"args": args,
+			})
+		},
+		// OKX要求30秒内有数据往来，否则断开连接
+		Heartbeat: func(conn *websocket.Conn) error {
+			return conn.WriteMessage(websocket.TextMessage, []byte("ping"))
+		},
+		HeartbeatInterval: 20 * time.Second,
+		OnMessage: func(message []byte) {
+			if string(message) == "pong" {
+				return
+			}
+
+			var push OKXStreamMessage
+			if err := json.Unmarshal(message, &push); err != nil || push.Event != "" {
+				return
+			}
+
+			switch push.Arg.Channel {
+			case "open-interest":
+				var items []OKXOpenInterestResponse
+				if err := json.Unmarshal(push.Data, &items); err != nil {
+					return
+				}
+				for i := range items {
+					symbol, ok := instSymbols[items[i].InstID]
+					if !ok {
+						continue
+					}
+					if data, err := o.parseOpenInterest(&items[i], symbol); err == nil {
+						handler(&StreamEvent{OpenInterest: data})
+					}
+				}
+			case "funding-rate":
+				var items []OKXFundingRateResponse
+				if err := json.Unmarshal(push.Data, &items); err != nil {
+					return
+				}
+				for i := range items {
+					symbol, ok := instSymbols[items[i].InstID]
+					if !ok {
+						continue
+					}
+					if data, err := o.parsePredictedFunding(&items[i], symbol); err == nil {
+						handler(&StreamEvent{PredictedFunding: data})
+					}
+				}
+			case "liquidation-orders":
+				var items []OKXLiquidationResponse
+				if err := json.Unmarshal(push.Data, &items); err != nil {
+					return
+				}
+				for i := range items {
+					symbol, ok := instSymbols[items[i].InstID]
+					if !ok {
+						continue
+					}
+					ctVal, ok := o.cachedContractValue(items[i].InstID)
+					if !ok {
+						continue
+					}
+					for _, data := range o.parseLiquidations(&items[i], symbol, ctVal) {
+						handler(&StreamEvent{Liquidation: data})
+					}
+				}
+			}
+		},
+	})
+}
+
+// GetSymbols 获取USDT本位永续合约列表，持仓价值取自批量持仓量接口
+func (o *OKXService) GetSymbols(ctx context.Context) ([]*SymbolInfo, error) {
+	url := fmt.Sprintf("%s/api/v5/public/instruments?instType=SWAP", o.baseURL)
+
+	// 创建日志记录
+	apiLog := &models.APILog{
+		Exchange: "okx",
+		URL:      url,
+	}
+
+	var instruments []OKXSwapInstrumentResponse
+	if err := o.fetchJSON(ctx, apiLog, &instruments); err != nil {
+		return nil, err
+	}
+
+	var results []*SymbolInfo
+	o.ctValMu.Lock()
+	for _, item := range instruments {
+		parts := strings.SplitN(item.Uly, "-", 2)
+		if item.SettleCcy != "USDT" || item.State != "live" || len(parts) != 2 {
+			continue
+		}
+
+		// 顺便缓存合约面值，避免强平数据换算时逐个查询
+		if ctVal, err := strconv.ParseFloat(item.CtVal, 64); err == nil {
+			o.ctVals[item.InstID] = ctVal
+		}
+
+		results = append(results, &SymbolInfo{
+			Exchange:       "okx",
+			Symbol:         normalizeSymbol(parts[0], parts[1]),
+			ExchangeSymbol: item.InstID,
+			BaseAsset:      parts[0],
+			QuoteAsset:     parts[1],
+		})
+	}
+	o.ctValMu.Unlock()
 
 	// 记录成功的日志
 	apiLog.Success = true
 	apiLog.DataCount = len(results)
-	o.saveLog(apiLog)
+	o.saveLog(ctx, apiLog)
+
+	url = fmt.Sprintf("%s/api/v5/public/open-interest?instType=SWAP", o.baseURL)
+	apiLog = &models.APILog{
+		Exchange: "okx",
+		URL:      url,
+	}
+
+	var items []OKXOpenInterestResponse
+	if err := o.fetchJSON(ctx, apiLog, &items); err != nil {
+		// 持仓价值只用于排序，获取失败时仍返回合约列表
+		return results, nil
+	}
+
+	openInterest := make(map[string]float64, len(items))
+	for _, item := range items {
+		if value, err := strconv.ParseFloat(item.OiUsd, 64); err == nil {
+			openInterest[item.InstID] = value
+		}
+	}
+	for _, item := range results {
+		item.OpenInterestUSD = openInterest[item.ExchangeSymbol]
+	}
+
+	apiLog.Success = true
+	apiLog.DataCount = len(items)
+	o.saveLog(ctx, apiLog)
 
 	return results, nil
 }
 
-// GetMultipleSymbolsLongShortRatio 获取多个交易对的多空比数据
-func (o *OKXService) GetMultipleSymbolsLongShortRatio(symbols []string) ([]*LongShortRatioData, error) {
-	var results []*LongShortRatioData
+// cachedContractValue 从缓存中获取合约面值
+func (o *OKXService) cachedContractValue(instId string) (float64, bool) {
+	o.ctValMu.Lock()
+	defer o.ctValMu.Unlock()
+	ctVal, ok := o.ctVals[instId]
+	return ctVal, ok
+}
 
-	for _, symbol := range symbols {
-		data, err := o.GetLongShortRatio(symbol)
-		if err != nil {
-			// 记录错误但继续处理其他交易对
-			fmt.Printf("获取%s多空比失败: %v\n", symbol, err)
+// contractValue 获取合约面值，结果会被缓存
+func (o *OKXService) contractValue(ctx context.Context, instId string) (float64, error) {
+	if ctVal, ok := o.cachedContractValue(instId); ok {
+		return ctVal, nil
+	}
+
+	url := fmt.Sprintf("%s/api/v5/public/instruments?instType=SWAP&instId=%s", o.baseURL, instId)
+	apiLog := &models.APILog{
+		Exchange: "okx",
+		Symbol:   instId,
+		Limit:    1,
+		URL:      url,
+	}
+
+	var items []OKXInstrumentResponse
+	if err := o.fetchJSON(ctx, apiLog, &items); err != nil {
+		return 0, err
+	}
+	if len(items) == 0 {
+		return 0, fmt.Errorf("没有获取到合约信息: %s", instId)
+	}
+
+	ctVal, err := strconv.ParseFloat(items[0].CtVal, 64)
+	if err != nil {
+		return 0, fmt.Errorf("解析合约面值失败: %w", err)
+	}
+
+	apiLog.Success = true
+	apiLog.DataCount = 1
+	o.saveLog(ctx, apiLog)
+
+	o.ctValMu.Lock()
+	o.ctVals[instId] = ctVal
+	o.ctValMu.Unlock()
+
+	return ctVal, nil
+}
+
+// GetKlines 获取K线数据（按时间升序返回）
+func (o *OKXService) GetKlines(ctx context.Context, symbol, period string, limit int) ([]*KlineData, error) {
+	url := fmt.Sprintf("%s/api/v5/market/candles?instId=%s&bar=%s&limit=%d",
+		o.baseURL, o.swapInstID(symbol), o.convertPeriod(period), limit)
+
+	// 创建日志记录
+	apiLog := &models.APILog{
+		Exchange: "okx",
+		Symbol:   symbol,
+		Period:   period,
+		Limit:    limit,
+		URL:      url,
+	}
+
+	// OKX返回的数据格式: [["ts", "o", "h", "l", "c", "vol", "volCcy", ...], ...]，按时间倒序
+	var rows [][]string
+	if err := o.fetchJSON(ctx, apiLog, &rows); err != nil {
+		return nil, err
+	}
+
+	var results []*KlineData
+	for i := len(rows) - 1; i >= 0; i-- {
+		row := rows[i]
+		if len(row) < 7 {
+			continue
+		}
+
+		values := make([]float64, 7)
+		valid := true
+		for j := 0; j < 7; j++ {
+			v, err := strconv.ParseFloat(row[j], 64)
+			if err != nil {
+				valid = false
+				break
+			}
+			values[j] = v
+		}
+		if !valid {
 			continue
 		}
-		results = append(results, data)
 
-		// 避免请求过于频繁
-		time.Sleep(100 * time.Millisecond)
+		results = append(results, &KlineData{
+			Exchange: "okx",
+			Symbol:   symbol,
+			Period:   period,
+			OpenTime: time.Unix(int64(values[0])/1000, 0),
+			Open:     values[1],
+			High:     values[2],
+			Low:      values[3],
+			Close:    values[4],
+			Volume:   values[6],
+		})
 	}
 
+	// 记录成功的日志
+	apiLog.Success = true
+	apiLog.DataCount = len(results)
+	o.saveLog(ctx, apiLog)
+
 	return results, nil
 }
 
+// fetchJSON 请求apiLog.URL并将响应中的data字段解析到out，失败时记录API日志
+func (o *OKXService) fetchJSON(ctx context.Context, apiLog *models.APILog, out interface{}) error {
+	startTime := time.Now()
+	resp, err := o.http.Get(ctx, apiLog.URL)
+	apiLog.ResponseTime = time.Since(startTime).Milliseconds()
+	if resp != nil {
+		apiLog.StatusCode = resp.StatusCode
+	}
+
+	if err == nil {
+		err = o.decode(resp.Body, out)
+	} else {
+		err = o.classify(resp, err)
+	}
+	if err != nil {
+		markFailed(apiLog, err)
+		o.saveLog(ctx, apiLog)
+		return err
+	}
+
+	return nil
+}
+
+// decode 检查OKX响应的业务错误码并将data字段解析到out
+func (o *OKXService) decode(body []byte, out interface{}) error {
+	var response okxEnvelope
+	if err := json.Unmarshal(body, &response); err != nil {
+		return decodeError("OKX", err)
+	}
+
+	if response.Code != "0" {
+		return codeError("OKX", okxErrorKinds[response.Code], response.Code, response.Msg)
+	}
+
+	if err := json.Unmarshal(response.Data, out); err != nil {
+		return decodeError("OKX", err)
+	}
+	return nil
+}
+
+// classify 根据HTTP错误响应中的OKX错误码修正错误类型
+func (o *OKXService) classify(resp *exchangeResponse, err error) error {
+	if resp == nil || resp.StatusCode == http.StatusOK {
+		return err
+	}
+
+	var response okxEnvelope
+	if json.Unmarshal(resp.Body, &response) != nil || response.Code == "" {
+		return err
+	}
+	return withCodeKind(err, okxErrorKinds[response.Code])
+}
+
+// swapInstID 获取U本位永续合约的instId，如BTC-USDT-SWAP
+func (o *OKXService) swapInstID(symbol string) string {
+	return o.convertSymbol(symbol) + "-USDT-SWAP"
+}
+
+// convertPeriod 转换时间粒度格式，OKX小时及以上粒度使用大写单位
+func (o *OKXService) convertPeriod(period string) string {
+	switch period {
+	case "1h", "2h", "4h", "6h", "12h":
+		return strings.ToUpper(period)
+	case "1d":
+		return "1D"
+	default:
+		return period
+	}
+}
+
 // convertSymbol 转换交易对格式
 func (o *OKXService) convertSymbol(symbol string) string {
 	// 将BTC转换为BTC，ETH转换为ETH等
@@ -253,11 +923,11 @@ func (o *OKXService) convertSymbol(symbol string) string {
 	}
 }
 
-// saveLog 保存API日志
-func (o *OKXService) saveLog(apiLog *models.APILog) {
+// saveLog 保存API日志，请求已取消时不再写入
+func (o *OKXService) saveLog(ctx context.Context, apiLog *models.APILog) {
 	if db := database.GetDB(); db != nil {
 		repo := models.NewAPILogRepository(db)
-		if err := repo.Create(apiLog); err != nil {
+		if err := repo.Create(ctx, apiLog); err != nil && ctx.Err() == nil {
 			fmt.Printf("保存OKX API日志失败: %v\n", err)
 		}
 	}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// okxAccountRatioFixture OKX多空比接口按时间倒序返回，包含一条无法解析的数据
+const okxAccountRatioFixture = `{
+	"code": "0",
+	"msg": "",
+	"data": [
+		["1700000600000", "1.5"],
+		["1700000300000", "1.22"],
+		["1700000200000", ""],
+		["1700000000000", "1"]
+	]
+}`
+
+func TestOKXRatioHistory(t *testing.T) {
+	db := useTestDB(t)
+	server, requests := newFixtureServer(t, map[string]string{
+		"/api/v5/rubik/stat/contracts/long-short-account-ratio": okxAccountRatioFixture,
+	})
+	okx := NewOKXService(testExchangeConfig(server.URL))
+
+	data, err := okx.GetLongShortRatioHistory(context.Background(), "BTCUSDT", "5m", 2)
+	if err != nil {
+		t.Fatalf("获取多空比失败: %v", err)
+	}
+
+	query := (<-requests).URL.Query()
+	if query.Get("ccy") != "BTC" || query.Get("period") != "5m" {
+		t.Errorf("请求参数错误: %v", query)
+	}
+
+	// 按时间升序排列后保留最近的2条
+	if len(data) != 2 {
+		t.Fatalf("数据条数 = %d, 期望 2", len(data))
+	}
+	for i, want := range []struct {
+		ratio float64
+		ts    int64
+	}{
+		{1.22, 1700000300},
+		{1.5, 1700000600},
+	} {
+		item := data[i]
+		if item.Exchange != "okx" || item.Symbol != "BTCUSDT" || item.Metric != MetricGlobalAccount || item.Period != "5m" {
+			t.Errorf("第%d条数据字段错误: %+v", i, item)
+		}
+		if item.Ratio != want.ratio || !item.Timestamp.Equal(time.Unix(want.ts, 0)) {
+			t.Errorf("第%d条数据 = %v %v, 期望 %v %v", i, item.Ratio, item.Timestamp, want.ratio, time.Unix(want.ts, 0))
+		}
+	}
+
+	apiLog := lastAPILog(t, db)
+	if !apiLog.Success || apiLog.Exchange != "okx" || apiLog.Limit != 2 || apiLog.StatusCode != http.StatusOK || apiLog.DataCount != 2 {
+		t.Errorf("API日志错误: %+v", apiLog)
+	}
+}
+
+func TestOKXRatioRange(t *testing.T) {
+	useTestDB(t)
+	server, requests := newFixtureServer(t, map[string]string{
+		"/api/v5/rubik/stat/contracts/long-short-account-ratio": okxAccountRatioFixture,
+	})
+	okx := NewOKXService(testExchangeConfig(server.URL))
+
+	start, end := time.Unix(1700000000, 0), time.Unix(1700000600, 0)
+	data, err := okx.GetLongShortRatioRange(context.Background(), "BTCUSDT", "5m", start, end)
+	if err != nil {
+		t.Fatalf("获取多空比失败: %v", err)
+	}
+
+	query := (<-requests).URL.Query()
+	if query.Get("begin") != "1700000000000" || query.Get("end") != "1700000600000" {
+		t.Errorf("请求参数错误: %v", query)
+	}
+
+	// 范围查询不限制条数，同样按时间升序返回
+	if len(data) != 3 || !data[0].Timestamp.Equal(start) || !data[2].Timestamp.Equal(end) {
+		t.Errorf("返回数据错误: %v", data)
+	}
+}
+
+func TestOKXLatestRatio(t *testing.T) {
+	db := useTestDB(t)
+	server, requests := newFixtureServer(t, map[string]string{
+		"/api/v5/rubik/stat/contracts/long-short-account-ratio": okxAccountRatioFixture,
+	})
+	okx := NewOKXService(testExchangeConfig(server.URL))
+
+	// 最新数据取升序结果的最后一条
+	latest, err := okx.GetLongShortRatio(context.Background(), "ETHUSDT")
+	if err != nil {
+		t.Fatalf("获取最新多空比失败: %v", err)
+	}
+	<-requests
+	if latest.Ratio != 1.5 || !latest.Timestamp.Equal(time.Unix(1700000600, 0)) {
+		t.Errorf("最新多空比 = %v %v, 期望 1.5 %v", latest.Ratio, latest.Timestamp, time.Unix(1700000600, 0))
+	}
+
+	apiLog := lastAPILog(t, db)
+	if !apiLog.Success || apiLog.Symbol != "ETHUSDT" || apiLog.Period != "5m" || apiLog.Limit != 1 || apiLog.DataCount != 1 {
+		t.Errorf("API日志错误: %+v", apiLog)
+	}
+}
+
+func TestOKXStreamLiquidationUsesCachedContractValue(t *testing.T) {
+	useTestDB(t)
+	server, requests := newFixtureServer(t, map[string]string{
+		"/api/v5/public/instruments": `{"code": "0", "msg": "", "data": [{"instId": "BTC-USDT-SWAP", "ctVal": "0.01"}]}`,
+	})
+	var ws *wsTestServer
+	ws = newWSTestServer(t, func(n int, conn *websocket.Conn) {
+		conn.WriteMessage(websocket.TextMessage, []byte(`{
+			"arg": {"channel": "liquidation-orders", "instType": "SWAP"},
+			"data": [{"instId": "BTC-USDT-SWAP", "details": [{"side": "sell", "posSide": "long", "bkPx": "100", "sz": "3", "ts": "1700000000000"}]}]
+		}`))
+		<-ws.done
+	})
+	cfg := testExchangeConfig(server.URL)
+	cfg.WSURL = ws.wsURL()
+	okx := NewOKXService(cfg)
+
+	events := make(chan *StreamEvent, 4)
+	runStream(t, okx.NewStream([]string{"BTCUSDT"}, func(event *StreamEvent) { events <- event }))
+
+	// 收到订阅请求时已查询过合约面值
+	receive(t, ws.subscribed, "订阅请求")
+	select {
+	case r := <-requests:
+		if r.URL.Query().Get("instId") != "BTC-USDT-SWAP" {
+			t.Errorf("合约信息请求参数错误: %v", r.URL.Query())
+		}
+	default:
+		t.Fatal("订阅前没有查询合约面值")
+	}
+
+	// 张数按合约面值换算为币数量
+	event := receive(t, events, "强平推送")
+	if event.Liquidation == nil || event.Liquidation.Side != LiquidationSideLong || event.Liquidation.Quantity != 0.03 || event.Liquidation.Notional != 3 {
+		t.Errorf("强平数据错误: %+v", event.Liquidation)
+	}
+}
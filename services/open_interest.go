@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"time"
+)
+
+// OpenInterestData 持仓量数据通用结构
+type OpenInterestData struct {
+	Exchange     string    `json:"exchange"`
+	Symbol       string    `json:"symbol"`
+	OpenInterest float64   `json:"open_interest"` // 持仓量（以币计）
+	Notional     float64   `json:"notional"`      // 持仓名义价值（USDT）
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// OpenInterestService 支持持仓量数据的交易所服务
+type OpenInterestService interface {
+	GetOpenInterest(ctx context.Context, symbol string) (*OpenInterestData, error)
+	GetOpenInterestHistory(ctx context.Context, symbol, period string, limit int) ([]*OpenInterestData, error)
+}
+
+// CollectOpenInterest 并发收集所有支持持仓量的交易所的最新持仓量
+func (d *DataCollectionService) CollectOpenInterest(ctx context.Context) ([]*OpenInterestData, error) {
+	var tasks []collectTask[*OpenInterestData]
+	for _, exchange := range d.exchanges {
+		oiService, ok := exchange.(OpenInterestService)
+		if !ok {
+			continue
+		}
+
+		for _, symbol := range d.Symbols() {
+			symbol := symbol
+			tasks = append(tasks, collectTask[*OpenInterestData]{
+				exchange: exchange.Name(),
+				symbol:   symbol,
+				item:     "持仓量",
+				fetch: single(func() (*OpenInterestData, error) {
+					return oiService.GetOpenInterest(ctx, symbol)
+				}),
+			})
+		}
+	}
+
+	return fanOut(ctx, tasks)
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// quota 限流额度：每interval最多limit个权重
+type quota struct {
+	limit    int
+	interval time.Duration
+}
+
+// endpointQuota 接口限流规则，按路径前缀匹配，匹配的各个接口分别计算额度
+type endpointQuota struct {
+	prefix string
+	quota  quota                      // 接口自身的额度，为零表示只受交易所总额度限制
+	weight func(query url.Values) int // 计入交易所总额度的请求权重，为nil时为1
+}
+
+// rateLimits 交易所限流规则
+type rateLimits struct {
+	total        quota           // 交易所总的请求权重额度，为零表示不限制
+	usedHeader   string          // 响应中返回已用权重的响应头，为空表示交易所不返回
+	endpoints    []endpointQuota // 第一条匹配的规则生效
+	defaultQuota quota           // 没有匹配规则的接口各自的额度
+}
+
+// tokenBucket 令牌桶，令牌可以预支为负数，之后的请求按欠额排队等待
+type tokenBucket struct {
+	mu       sync.Mutex
+	capacity float64
+	rate     float64 // 每秒补充的令牌数
+	tokens   float64
+	last     time.Time
+}
+
+// newTokenBucket 创建装满令牌的令牌桶
+func newTokenBucket(q quota) *tokenBucket {
+	return &tokenBucket{
+		capacity: float64(q.limit),
+		rate:     float64(q.limit) / q.interval.Seconds(),
+		tokens:   float64(q.limit),
+		last:     time.Now(),
+	}
+}
+
+// refill 按经过的时间补充令牌，调用方持有锁
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+// reserve 取出n个令牌，返回令牌补足前需要等待的时间
+func (b *tokenBucket) reserve(n float64) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel 归还没有用到的令牌
+func (b *tokenBucket) cancel(n float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	b.tokens = math.Min(b.capacity, b.tokens+n)
+}
+
+// sync 按交易所返回的已用权重校正剩余令牌，只向减少的方向校正
+func (b *tokenBucket) sync(used float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	if remaining := b.capacity - used; remaining < b.tokens {
+		b.tokens = remaining
+	}
+}
+
+// available 当前可用的令牌数
+func (b *tokenBucket) available() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	return b.tokens
+}
+
+// RateLimiter 交易所限流器，按交易所总权重和各接口额度限流，可以并发使用
+type RateLimiter struct {
+	exchange string
+	limits   rateLimits
+	total    *tokenBucket
+
+	mu        sync.Mutex
+	endpoints map[string]*tokenBucket // 接口路径 -> 令牌桶
+	waited    time.Duration           // 累计等待时间
+	throttled int64                   // 需要等待的请求数
+}
+
+// rateLimiters 按交易所名称共用的限流器，重新加载配置重建交易所服务后额度不会重置
+var rateLimiters = struct {
+	sync.Mutex
+	byExchange map[string]*RateLimiter
+}{
+	byExchange: make(map[string]*RateLimiter),
+}
+
+// rateLimiter 获取交易所的限流器，不存在时按规则创建
+func rateLimiter(exchange string, limits rateLimits) *RateLimiter {
+	rateLimiters.Lock()
+	defer rateLimiters.Unlock()
+
+	if limiter, ok := rateLimiters.byExchange[exchange]; ok {
+		return limiter
+	}
+	limiter := newRateLimiter(exchange, limits)
+	rateLimiters.byExchange[exchange] = limiter
+	return limiter
+}
+
+// newRateLimiter 按规则创建限流器
+func newRateLimiter(exchange string, limits rateLimits) *RateLimiter {
+	limiter := &RateLimiter{
+		exchange:  exchange,
+		limits:    limits,
+		endpoints: make(map[string]*tokenBucket),
+	}
+	if limits.total.limit > 0 {
+		limiter.total = newTokenBucket(limits.total)
+	}
+	return limiter
+}
+
+// RateLimitStatus 获取所有交易所限流器的状态
+func RateLimitStatus() []map[string]interface{} {
+	rateLimiters.Lock()
+	limiters := make([]*RateLimiter, 0, len(rateLimiters.byExchange))
+	for _, limiter := range rateLimiters.byExchange {
+		limiters = append(limiters, limiter)
+	}
+	rateLimiters.Unlock()
+
+	sort.Slice(limiters, func(i, j int) bool { return limiters[i].exchange < limiters[j].exchange })
+	results := make([]map[string]interface{}, 0, len(limiters))
+	for _, limiter := range limiters {
+		results = append(results, limiter.Status())
+	}
+	return results
+}
+
+// Wait 等待rawURL对应接口和交易所总额度都有可用令牌，ctx取消时归还令牌并返回错误
+func (l *RateLimiter) Wait(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	endpoint, weight := l.match(u)
+	var wait time.Duration
+	if endpoint != nil {
+		wait = endpoint.reserve(1)
+	}
+	if l.total != nil && weight > 0 {
+		if d := l.total.reserve(float64(weight)); d > wait {
+			wait = d
+		}
+	}
+	if wait <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	l.waited += wait
+	l.throttled++
+	l.mu.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		if endpoint != nil {
+			endpoint.cancel(1)
+		}
+		if l.total != nil && weight > 0 {
+			l.total.cancel(float64(weight))
+		}
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Observe 根据响应头校正交易所总额度的剩余令牌
+func (l *RateLimiter) Observe(header http.Header) {
+	if l.total == nil || l.limits.usedHeader == "" || header == nil {
+		return
+	}
+	used, err := strconv.Atoi(header.Get(l.limits.usedHeader))
+	if err != nil {
+		return
+	}
+	l.total.sync(float64(used))
+}
+
+// match 获取请求对应的接口令牌桶和请求权重
+func (l *RateLimiter) match(u *url.URL) (*tokenBucket, int) {
+	q, weight := l.limits.defaultQuota, 1
+	for _, rule := range l.limits.endpoints {
+		if !strings.HasPrefix(u.Path, rule.prefix) {
+			continue
+		}
+		q = rule.quota
+		if rule.weight != nil {
+			weight = rule.weight(u.Query())
+		}
+		break
+	}
+	if q.limit <= 0 {
+		return nil, weight
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	bucket, ok := l.endpoints[u.Path]
+	if !ok {
+		bucket = newTokenBucket(q)
+		l.endpoints[u.Path] = bucket
+	}
+	return bucket, weight
+}
+
+// Status 获取限流器状态
+func (l *RateLimiter) Status() map[string]interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	status := map[string]interface{}{
+		"exchange":  l.exchange,
+		"throttled": l.throttled,
+		"waited_ms": l.waited.Milliseconds(),
+	}
+	if l.total != nil {
+		status["weight_limit"] = l.limits.total.limit
+		status["weight_available"] = int(l.total.available())
+	}
+	endpoints := make(map[string]int, len(l.endpoints))
+	for key, bucket := range l.endpoints {
+		endpoints[key] = int(bucket.available())
+	}
+	status["endpoints"] = endpoints
+	return status
+}
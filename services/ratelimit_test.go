@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketRefill(t *testing.T) {
+	// 每200毫秒2个令牌，即每100毫秒补充1个
+	bucket := newTokenBucket(quota{limit: 2, interval: 200 * time.Millisecond})
+
+	for i := 0; i < 2; i++ {
+		if wait := bucket.reserve(1); wait != 0 {
+			t.Fatalf("第%d个令牌需要等待%v, 期望立即取得", i+1, wait)
+		}
+	}
+	// 令牌用完后预支，等待时间按欠额计算
+	if wait := bucket.reserve(1); wait < 90*time.Millisecond || wait > 100*time.Millisecond {
+		t.Errorf("令牌用完后等待%v, 期望约100ms", wait)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	// 补充的令牌不超过容量
+	if available := bucket.available(); available != 2 {
+		t.Errorf("可用令牌 = %v, 期望补满为2", available)
+	}
+}
+
+func TestRateLimiterWaits(t *testing.T) {
+	limiter := newRateLimiter("test", rateLimits{
+		defaultQuota: quota{limit: 1, interval: 100 * time.Millisecond},
+	})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(context.Background(), "https://example.com/api/ratio"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 第2、3个请求各等待约100ms
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Errorf("3个请求用时%v, 期望至少200ms", elapsed)
+	}
+	if status := limiter.Status(); status["throttled"] != int64(2) {
+		t.Errorf("限流状态错误: %v", status)
+	}
+
+	// 不同接口分别计算额度
+	start = time.Now()
+	if err := limiter.Wait(context.Background(), "https://example.com/api/klines"); err != nil || time.Since(start) > 50*time.Millisecond {
+		t.Errorf("其他接口不应等待: %v, %v", time.Since(start), err)
+	}
+}
+
+func TestRateLimiterEndpointWeights(t *testing.T) {
+	limits := binanceRateLimits
+	limits.total = quota{limit: 10, interval: time.Hour}
+	limiter := newRateLimiter("binance", limits)
+
+	for _, tt := range []struct {
+		url       string
+		available int // 请求后交易所总额度剩余的权重
+	}{
+		{"https://fapi.binance.com/futures/data/globalLongShortAccountRatio?symbol=BTCUSDT", 10},
+		{"https://fapi.binance.com/fapi/v1/klines?symbol=BTCUSDT&limit=1000", 5},
+		{"https://fapi.binance.com/fapi/v1/klines?symbol=BTCUSDT&limit=200", 3},
+		{"https://fapi.binance.com/fapi/v1/openInterest?symbol=BTCUSDT", 2},
+	} {
+		if err := limiter.Wait(context.Background(), tt.url); err != nil {
+			t.Fatal(err)
+		}
+		if available := int(limiter.total.available()); available != tt.available {
+			t.Errorf("请求%s后剩余权重 = %d, 期望 %d", tt.url, available, tt.available)
+		}
+	}
+
+	// 只有单独设置了额度的接口有自己的令牌桶
+	endpoints := limiter.Status()["endpoints"].(map[string]int)
+	if len(endpoints) != 1 || endpoints["/futures/data/globalLongShortAccountRatio"] != 999 {
+		t.Errorf("接口额度错误: %v", endpoints)
+	}
+}
+
+func TestRateLimiterWaitCancelled(t *testing.T) {
+	limiter := newRateLimiter("test", rateLimits{
+		defaultQuota: quota{limit: 1, interval: time.Hour},
+	})
+	url := "https://example.com/api/ratio"
+	if err := limiter.Wait(context.Background(), url); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := limiter.Wait(ctx, url); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("错误 = %v, 期望context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("ctx取消后等待了%v才返回", elapsed)
+	}
+
+	// 取消的请求归还预支的令牌，之后的请求不会多等一个周期
+	if available := limiter.Status()["endpoints"].(map[string]int)["/api/ratio"]; available != 0 {
+		t.Errorf("取消后剩余令牌 = %d, 期望0", available)
+	}
+}
+
+func TestRateLimiterObserveUsedWeight(t *testing.T) {
+	limiter := newRateLimiter("binance", rateLimits{
+		total:      quota{limit: 100, interval: 10 * time.Second},
+		usedHeader: "X-MBX-USED-WEIGHT-1M",
+	})
+
+	// 交易所返回的已用权重小于本地记录时不校正
+	limiter.Observe(http.Header{"X-Mbx-Used-Weight-1m": []string{"0"}})
+	if available := int(limiter.total.available()); available != 100 {
+		t.Errorf("剩余权重 = %d, 期望100", available)
+	}
+
+	// 其他进程用完了额度，之后的请求需要等待补充令牌（每100ms一个）
+	limiter.Observe(http.Header{"X-Mbx-Used-Weight-1m": []string{"100"}})
+	start := time.Now()
+	if err := limiter.Wait(context.Background(), "https://fapi.binance.com/fapi/v1/openInterest"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("额度用完后等待%v, 期望约100ms", elapsed)
+	}
+}
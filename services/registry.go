@@ -0,0 +1,106 @@
+package services
+
+import (
+	"CurrencyMonitor/config"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ExchangeFactory 交易所服务构造函数
+type ExchangeFactory func(cfg config.ExchangeConfig) ExchangeService
+
+// exchangeRegistry 交易所注册表
+var exchangeRegistry = struct {
+	sync.RWMutex
+	factories map[string]ExchangeFactory
+}{
+	factories: make(map[string]ExchangeFactory),
+}
+
+// RegisterExchange 注册交易所适配器，通常在适配器文件的init中调用
+func RegisterExchange(name string, factory ExchangeFactory) {
+	exchangeRegistry.Lock()
+	defer exchangeRegistry.Unlock()
+
+	if factory == nil {
+		panic("services: 交易所构造函数不能为空: " + name)
+	}
+	if _, exists := exchangeRegistry.factories[name]; exists {
+		panic("services: 交易所重复注册: " + name)
+	}
+	exchangeRegistry.factories[name] = factory
+}
+
+// ExchangeNames 获取已注册的交易所名称（按名称排序）
+func ExchangeNames() []string {
+	exchangeRegistry.RLock()
+	defer exchangeRegistry.RUnlock()
+
+	names := make([]string, 0, len(exchangeRegistry.factories))
+	for name := range exchangeRegistry.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewExchange 根据名称和配置创建交易所服务
+func NewExchange(name string, cfg config.ExchangeConfig) (ExchangeService, error) {
+	exchangeRegistry.RLock()
+	factory, ok := exchangeRegistry.factories[name]
+	exchangeRegistry.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("不支持的交易所: %s", name)
+	}
+	return factory(cfg), nil
+}
+
+// NewExchanges 根据配置创建所有启用的交易所服务，配置中出现未注册的交易所时返回错误
+func NewExchanges(cfgs map[string]config.ExchangeConfig) ([]ExchangeService, error) {
+	exchangeRegistry.RLock()
+	for name := range cfgs {
+		if _, ok := exchangeRegistry.factories[name]; !ok {
+			exchangeRegistry.RUnlock()
+			return nil, fmt.Errorf("不支持的交易所: %s", name)
+		}
+	}
+	exchangeRegistry.RUnlock()
+
+	var exchanges []ExchangeService
+	for _, name := range ExchangeNames() {
+		cfg, ok := cfgs[name]
+		if !ok {
+			return nil, fmt.Errorf("缺少交易所配置: %s", name)
+		}
+		if cfg.Disabled {
+			continue
+		}
+
+		exchange, err := NewExchange(name, cfg)
+		if err != nil {
+			return nil, err
+		}
+		exchanges = append(exchanges, exchange)
+	}
+	return exchanges, nil
+}
+
+// SelectExchanges 从交易所服务中按名称选择，名称不存在时返回错误
+func SelectExchanges(exchanges []ExchangeService, names []string) ([]ExchangeService, error) {
+	byName := make(map[string]ExchangeService, len(exchanges))
+	for _, exchange := range exchanges {
+		byName[exchange.Name()] = exchange
+	}
+
+	selected := make([]ExchangeService, 0, len(names))
+	for _, name := range names {
+		exchange, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("交易所%s不存在或未启用", name)
+		}
+		selected = append(selected, exchange)
+	}
+	return selected, nil
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// StreamEvent 推送流事件，每个事件只包含一种数据
+type StreamEvent struct {
+	Kline            *KlineData
+	OpenInterest     *OpenInterestData
+	PredictedFunding *PredictedFundingData
+	Liquidation      *LiquidationData
+}
+
+// StreamingService 支持WebSocket推送的交易所服务
+type StreamingService interface {
+	Name() string
+	// NewStream 创建订阅symbols相关推送的流客户端，事件通过handler回调
+	NewStream(symbols []string, handler func(*StreamEvent)) *StreamClient
+}
+
+// StreamOptions 流客户端配置
+type StreamOptions struct {
+	Name              string                           // 名称，用于日志
+	URL               string                           // WebSocket地址
+	Prepare           func(ctx context.Context) error  // 建立连接前准备订阅需要的数据，每次重连都会调用，ctx在stop关闭或超时后取消
+	Subscribe         func(conn *websocket.Conn) error // 连接建立后发送订阅请求，每次重连都会调用
+	Heartbeat         func(conn *websocket.Conn) error // 发送心跳，为空时发送WebSocket ping帧
+	HeartbeatInterval time.Duration                    // 心跳间隔
+	OnMessage         func(message []byte)             // 处理收到的消息
+}
+
+// StreamClient 维持WebSocket长连接的流客户端，断线后自动重连并重新订阅
+type StreamClient struct {
+	opts StreamOptions
+
+	mu         sync.Mutex
+	connected  bool
+	reconnects int
+	lastError  string
+	lastEvent  time.Time
+}
+
+// 重连退避参数
+const (
+	streamMinBackoff = time.Second
+	streamMaxBackoff = time.Minute
+)
+
+// streamPrepareTimeout 连接前准备数据的超时时间
+const streamPrepareTimeout = 30 * time.Second
+
+// NewStreamClient 创建新的流客户端
+func NewStreamClient(opts StreamOptions) *StreamClient {
+	if opts.HeartbeatInterval <= 0 {
+		opts.HeartbeatInterval = 20 * time.Second
+	}
+	return &StreamClient{opts: opts}
+}
+
+// Name 流名称
+func (c *StreamClient) Name() string {
+	return c.opts.Name
+}
+
+// Run 运行流客户端直到stop关闭，连接断开时按指数退避重连
+func (c *StreamClient) Run(stop <-chan struct{}) {
+	backoff := streamMinBackoff
+
+	for {
+		start := time.Now()
+		err := c.runOnce(stop)
+
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		c.mu.Lock()
+		c.connected = false
+		c.reconnects++
+		if err != nil {
+			c.lastError = err.Error()
+		}
+		c.mu.Unlock()
+
+		// 连接维持了较长时间说明不是持续性故障，从最短退避重新开始，本次等待后不翻倍
+		stable := time.Since(start) > streamMaxBackoff
+		if stable {
+			backoff = streamMinBackoff
+		}
+
+		log.Printf("%s推送断开: %v，%v后重连", c.opts.Name, err, backoff)
+		select {
+		case <-stop:
+			return
+		case <-time.After(backoff):
+		}
+
+		if !stable {
+			backoff *= 2
+			if backoff > streamMaxBackoff {
+				backoff = streamMaxBackoff
+			}
+		}
+	}
+}
+
+// runOnce 建立一次连接并持续读取，直到连接断开或stop关闭
+func (c *StreamClient) runOnce(stop <-chan struct{}) error {
+	if c.opts.Prepare != nil {
+		if err := c.prepare(stop); err != nil {
+			return fmt.Errorf("准备订阅失败: %w", err)
+		}
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(c.opts.URL, nil)
+	if err != nil {
+		return fmt.Errorf("连接失败: %w", err)
+	}
+	defer conn.Close()
+
+	// 超过两个心跳周期没有任何消息（包括pong）视为连接失效
+	readTimeout := 2 * c.opts.HeartbeatInterval
+	conn.SetReadDeadline(time.Now().Add(readTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(readTimeout))
+	})
+
+	// 写操作统一加锁，心跳和订阅可能并发写入
+	var writeMu sync.Mutex
+	lockedConn := func(fn func(*websocket.Conn) error) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return fn(conn)
+	}
+
+	if c.opts.Subscribe != nil {
+		if err := lockedConn(c.opts.Subscribe); err != nil {
+			return fmt.Errorf("订阅失败: %w", err)
+		}
+	}
+
+	c.mu.Lock()
+	c.connected = true
+	c.mu.Unlock()
+	log.Printf("%s推送已连接", c.opts.Name)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(c.opts.HeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				conn.Close()
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+				heartbeat := c.opts.Heartbeat
+				if heartbeat == nil {
+					heartbeat = func(conn *websocket.Conn) error {
+						return conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(5*time.Second))
+					}
+				}
+				if err := lockedConn(heartbeat); err != nil {
+					conn.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return fmt.Errorf("读取失败: %w", err)
+		}
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+
+		c.mu.Lock()
+		c.lastEvent = time.Now()
+		c.mu.Unlock()
+
+		if c.opts.OnMessage != nil {
+			c.opts.OnMessage(message)
+		}
+	}
+}
+
+// prepare 执行Prepare，stop关闭时取消
+func (c *StreamClient) prepare(stop <-chan struct{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), streamPrepareTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return c.opts.Prepare(ctx)
+}
+
+// GetStatus 获取流客户端状态
+func (c *StreamClient) GetStatus() map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := map[string]interface{}{
+		"name":       c.opts.Name,
+		"connected":  c.connected,
+		"reconnects": c.reconnects,
+		"last_error": c.lastError,
+	}
+	if !c.lastEvent.IsZero() {
+		status["last_event"] = c.lastEvent.Format("2006-01-02 15:04:05")
+	}
+	return status
+}
+
+// StreamingServices 获取支持WebSocket推送的交易所服务
+func (d *DataCollectionService) StreamingServices() []StreamingService {
+	var results []StreamingService
+	for _, exchange := range d.exchanges {
+		if streamer, ok := exchange.(StreamingService); ok {
+			results = append(results, streamer)
+		}
+	}
+	return results
+}
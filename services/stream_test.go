@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// streamTestTimeout 等待推送事件的超时时间，需要大于最短重连退避
+const streamTestTimeout = 5 * time.Second
+
+// wsTestServer 本地WebSocket测试服务器，每个连接收到的第一条消息视为订阅请求
+type wsTestServer struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	conns      int
+	subscribed chan string   // 每个连接收到的订阅请求
+	done       chan struct{} // 测试结束时关闭，释放阻塞的连接
+	serve      func(n int, conn *websocket.Conn)
+}
+
+// newWSTestServer 启动WebSocket测试服务器，serve处理第n个连接（从1开始）订阅之后的交互
+func newWSTestServer(t *testing.T, serve func(n int, conn *websocket.Conn)) *wsTestServer {
+	t.Helper()
+
+	s := &wsTestServer{
+		subscribed: make(chan string, 8),
+		done:       make(chan struct{}),
+		serve:      serve,
+	}
+	upgrader := websocket.Upgrader{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		s.mu.Lock()
+		s.conns++
+		n := s.conns
+		s.mu.Unlock()
+
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		s.subscribed <- string(message)
+		s.serve(n, conn)
+	}))
+	t.Cleanup(func() {
+		close(s.done)
+		s.Server.Close()
+	})
+	return s
+}
+
+// wsURL 测试服务器的WebSocket地址
+func (s *wsTestServer) wsURL() string {
+	return "ws" + strings.TrimPrefix(s.Server.URL, "http")
+}
+
+// runStream 在后台运行流客户端，测试结束时停止并等待退出
+func runStream(t *testing.T, client *StreamClient) {
+	t.Helper()
+
+	stop := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		client.Run(stop)
+		close(exited)
+	}()
+	t.Cleanup(func() {
+		close(stop)
+		select {
+		case <-exited:
+		case <-time.After(streamTestTimeout):
+			t.Error("流客户端没有在stop关闭后退出")
+		}
+	})
+}
+
+// receive 从通道读取一个值，超时时测试失败
+func receive[T any](t *testing.T, ch <-chan T, what string) T {
+	t.Helper()
+
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(streamTestTimeout):
+		t.Fatalf("等待%s超时", what)
+	}
+	var zero T
+	return zero
+}
+
+// subscribeJSON 发送固定订阅请求的订阅函数
+func subscribeJSON(conn *websocket.Conn) error {
+	return conn.WriteJSON(map[string]interface{}{"method": "SUBSCRIBE", "params": []string{"btcusdt@forceOrder"}})
+}
+
+func TestStreamClientResubscribesAfterDrop(t *testing.T) {
+	server := newWSTestServer(t, func(n int, conn *websocket.Conn) {
+		conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"conn": %d}`, n)))
+		if n == 1 {
+			// 第一个连接推送一条消息后断开
+			return
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+
+	messages := make(chan string, 8)
+	client := NewStreamClient(StreamOptions{
+		Name:      "test",
+		URL:       server.wsURL(),
+		Subscribe: subscribeJSON,
+		OnMessage: func(message []byte) { messages <- string(message) },
+	})
+	runStream(t, client)
+
+	for n := 1; n <= 2; n++ {
+		var request struct {
+			Method string   `json:"method"`
+			Params []string `json:"params"`
+		}
+		if err := json.Unmarshal([]byte(receive(t, server.subscribed, "订阅请求")), &request); err != nil {
+			t.Fatalf("第%d个连接的订阅请求无法解析: %v", n, err)
+		}
+		if request.Method != "SUBSCRIBE" || len(request.Params) != 1 || request.Params[0] != "btcusdt@forceOrder" {
+			t.Errorf("第%d个连接的订阅请求错误: %+v", n, request)
+		}
+
+		want := fmt.Sprintf(`{"conn": %d}`, n)
+		if got := receive(t, messages, "推送消息"); got != want {
+			t.Errorf("第%d个连接的消息 = %s, 期望 %s", n, got, want)
+		}
+	}
+
+	status := client.GetStatus()
+	if status["connected"] != true || status["reconnects"] != 1 || status["last_event"] == nil {
+		t.Errorf("流客户端状态错误: %v", status)
+	}
+	if lastError, _ := status["last_error"].(string); !strings.Contains(lastError, "读取失败") {
+		t.Errorf("last_error = %q, 期望包含读取失败", lastError)
+	}
+}
+
+func TestStreamClientHeartbeatTimeout(t *testing.T) {
+	var server *wsTestServer
+	server = newWSTestServer(t, func(n int, conn *websocket.Conn) {
+		if n == 1 {
+			// 不读取消息就不会回复pong，客户端应在两个心跳周期后断开
+			<-server.done
+			return
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+
+	client := NewStreamClient(StreamOptions{
+		Name:              "test",
+		URL:               server.wsURL(),
+		Subscribe:         subscribeJSON,
+		HeartbeatInterval: 50 * time.Millisecond,
+	})
+	runStream(t, client)
+
+	receive(t, server.subscribed, "订阅请求")
+	receive(t, server.subscribed, "重连后的订阅请求")
+
+	status := client.GetStatus()
+	if lastError, _ := status["last_error"].(string); !strings.Contains(lastError, "timeout") {
+		t.Errorf("last_error = %q, 期望为读取超时", lastError)
+	}
+
+	// 正常回复pong的连接在多个心跳周期后仍保持
+	time.Sleep(10 * 50 * time.Millisecond)
+	status = client.GetStatus()
+	if status["connected"] != true || status["reconnects"] != 1 {
+		t.Errorf("流客户端状态错误: %v", status)
+	}
+}
+
+func TestBinanceKlineEventClosedOnly(t *testing.T) {
+	binance := NewBinanceService(testExchangeConfig(""))
+	message := `{"e": "kline", "E": 1700000299000, "s": "BTCUSDT", "k": {
+		"t": 1700000000000, "s": "BTCUSDT", "i": "5m",
+		"o": "100", "h": "110", "l": "90", "c": "105", "v": "12.5", "x": %t
+	}}`
+
+	var open BinanceStreamEvent
+	if err := json.Unmarshal([]byte(fmt.Sprintf(message, false)), &open); err != nil {
+		t.Fatal(err)
+	}
+	if data := binance.parseKlineEvent(&open); data != nil {
+		t.Errorf("未收盘的K线不应返回: %+v", data)
+	}
+
+	var closed BinanceStreamEvent
+	if err := json.Unmarshal([]byte(fmt.Sprintf(message, true)), &closed); err != nil {
+		t.Fatal(err)
+	}
+	data := binance.parseKlineEvent(&closed)
+	if data == nil {
+		t.Fatal("已收盘的K线应返回数据")
+	}
+	if data.Symbol != "BTCUSDT" || data.Period != "5m" || !data.OpenTime.Equal(time.Unix(1700000000, 0)) ||
+		data.Open != 100 || data.High != 110 || data.Low != 90 || data.Close != 105 || data.Volume != 12.5 {
+		t.Errorf("K线数据错误: %+v", data)
+	}
+}
+
+func TestStreamClientPrepareCancelledOnStop(t *testing.T) {
+	prepared := make(chan error, 1)
+	client := NewStreamClient(StreamOptions{
+		Name: "test",
+		URL:  "ws://127.0.0.1:0",
+		Prepare: func(ctx context.Context) error {
+			<-ctx.Done()
+			prepared <- ctx.Err()
+			return ctx.Err()
+		},
+	})
+
+	stop := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		client.Run(stop)
+		close(exited)
+	}()
+	close(stop)
+
+	if err := receive(t, prepared, "准备数据结束"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Prepare的ctx错误 = %v, 期望context.Canceled", err)
+	}
+	receive(t, exited, "流客户端退出")
+}
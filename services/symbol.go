@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SymbolInfo 交易所USDT本位永续合约信息
+type SymbolInfo struct {
+	Exchange        string  `json:"exchange"`
+	Symbol          string  `json:"symbol"`          // 统一格式交易对 (BTCUSDT)
+	ExchangeSymbol  string  `json:"exchange_symbol"` // 交易所原生合约代码 (BTC-USDT-SWAP, BTC_USDT)
+	BaseAsset       string  `json:"base_asset"`
+	QuoteAsset      string  `json:"quote_asset"`
+	OpenInterestUSD float64 `json:"open_interest_usd"` // 持仓价值（USDT），交易所没有批量接口时为0
+}
+
+// SymbolService 提供合约列表的交易所服务
+type SymbolService interface {
+	GetSymbols(ctx context.Context) ([]*SymbolInfo, error)
+}
+
+// UniverseRule 跟踪交易对的选择规则
+type UniverseRule struct {
+	Symbols      []string // 始终跟踪的交易对
+	TopN         int      // 按各交易所持仓价值之和取前N个交易对，0表示不按持仓选择
+	MinExchanges int      // 至少在多少个交易所上线，0表示不限制
+}
+
+// normalizeSymbol 将基础货币和计价货币转换为统一格式交易对
+func normalizeSymbol(base, quote string) string {
+	return strings.ToUpper(base + quote)
+}
+
+// CollectSymbols 收集所有交易所的USDT本位永续合约列表
+func (d *DataCollectionService) CollectSymbols(ctx context.Context) ([]*SymbolInfo, error) {
+	var allData []*SymbolInfo
+
+	for _, exchange := range d.exchanges {
+		symbolService, ok := exchange.(SymbolService)
+		if !ok {
+			continue
+		}
+
+		data, err := symbolService.GetSymbols(ctx)
+		if err != nil {
+			fmt.Printf("收集%s合约列表失败: %v\n", exchange.Name(), err)
+			continue
+		}
+		allData = append(allData, data...)
+	}
+
+	if len(allData) == 0 {
+		return nil, fmt.Errorf("没有获取到任何合约列表")
+	}
+	return allData, nil
+}
+
+// SelectUniverse 根据规则从合约列表中选择跟踪的交易对
+func SelectUniverse(infos []*SymbolInfo, rule UniverseRule) []string {
+	openInterest := make(map[string]float64)
+	exchanges := make(map[string]int)
+	for _, info := range infos {
+		openInterest[info.Symbol] += info.OpenInterestUSD
+		exchanges[info.Symbol]++
+	}
+
+	var results []string
+	selected := make(map[string]bool)
+	for _, symbol := range rule.Symbols {
+		symbol = strings.ToUpper(symbol)
+		if !selected[symbol] {
+			selected[symbol] = true
+			results = append(results, symbol)
+		}
+	}
+
+	if rule.TopN <= 0 {
+		return results
+	}
+
+	var candidates []string
+	for symbol, count := range exchanges {
+		if selected[symbol] || count < rule.MinExchanges {
+			continue
+		}
+		candidates = append(candidates, symbol)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if openInterest[candidates[i]] != openInterest[candidates[j]] {
+			return openInterest[candidates[i]] > openInterest[candidates[j]]
+		}
+		return candidates[i] < candidates[j]
+	})
+
+	for _, symbol := range candidates {
+		if len(results) >= rule.TopN {
+			break
+		}
+		results = append(results, symbol)
+	}
+	return results
+}
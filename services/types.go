@@ -1,73 +1,231 @@
 package services
 
 import (
+	"CurrencyMonitor/models"
+	"context"
 	"fmt"
+	"sync"
 	"time"
 )
 
+// 多空比指标类型
+const (
+	MetricGlobalAccount = "global_account" // 全市场账户多空比
+	MetricTopAccount    = "top_account"    // 大户账户多空比
+	MetricTopPosition   = "top_position"   // 大户持仓多空比
+	MetricTakerVolume   = "taker_volume"   // 主动买卖量比
+)
+
+// Metrics 所有支持的指标类型
+var Metrics = []string{MetricGlobalAccount, MetricTopAccount, MetricTopPosition, MetricTakerVolume}
+
+// IsValidMetric 检查指标类型是否有效
+func IsValidMetric(metric string) bool {
+	for _, m := range Metrics {
+		if m == metric {
+			return true
+		}
+	}
+	return false
+}
+
 // LongShortRatioData 多空比数据通用结构
 type LongShortRatioData struct {
 	Exchange  string    `json:"exchange"`
 	Symbol    string    `json:"symbol"`
+	Metric    string    `json:"metric"`
+	Period    string    `json:"period"`
 	Ratio     float64   `json:"ratio"`
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// RatioModels 将多空比数据转换为数据库模型
+func RatioModels(data []*LongShortRatioData) []*models.LongShortRatio {
+	ratios := make([]*models.LongShortRatio, 0, len(data))
+	for _, item := range data {
+		ratios = append(ratios, &models.LongShortRatio{
+			Exchange:  item.Exchange,
+			Symbol:    item.Symbol,
+			Metric:    item.Metric,
+			Period:    item.Period,
+			Ratio:     item.Ratio,
+			Timestamp: item.Timestamp,
+		})
+	}
+	return ratios
+}
+
+// RatioRangeService 支持按时间范围查询全市场账户多空比历史的交易所服务，用于补齐缺失的历史数据
+type RatioRangeService interface {
+	// GetLongShortRatioRange 获取[start, end]内的数据，单次请求返回的数量受交易所分页限制
+	GetLongShortRatioRange(ctx context.Context, symbol, period string, start, end time.Time) ([]*LongShortRatioData, error)
+	// RatioLookback 指定时间粒度最多可以回溯的时长
+	RatioLookback(period string) time.Duration
+}
+
 // ExchangeService 交易所服务接口
 type ExchangeService interface {
-	GetLongShortRatio(symbol string) (*LongShortRatioData, error)
-	GetMultipleSymbolsLongShortRatio(symbols []string) ([]*LongShortRatioData, error)
+	Name() string
+	GetLongShortRatio(ctx context.Context, symbol string) (*LongShortRatioData, error)
+	GetLongShortRatioHistory(ctx context.Context, symbol, period string, limit int) ([]*LongShortRatioData, error)
+}
+
+// MetricService 支持全市场账户多空比以外指标的交易所服务
+type MetricService interface {
+	SupportedMetrics() []string
+	GetMetricHistory(ctx context.Context, symbol, metric, period string, limit int) ([]*LongShortRatioData, error)
+}
+
+// SupportedMetrics 获取交易所支持的指标类型
+func SupportedMetrics(exchange ExchangeService) []string {
+	if m, ok := exchange.(MetricService); ok {
+		return m.SupportedMetrics()
+	}
+	return []string{MetricGlobalAccount}
+}
+
+// SupportsMetric 检查交易所是否支持指定指标
+func SupportsMetric(exchange ExchangeService, metric string) bool {
+	for _, m := range SupportedMetrics(exchange) {
+		if m == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// GetMetricHistory 获取交易所指定指标的历史数据
+func GetMetricHistory(ctx context.Context, exchange ExchangeService, symbol, metric, period string, limit int) ([]*LongShortRatioData, error) {
+	if m, ok := exchange.(MetricService); ok {
+		return m.GetMetricHistory(ctx, symbol, metric, period, limit)
+	}
+	if metric != MetricGlobalAccount {
+		return nil, fmt.Errorf("%s不支持指标: %s", exchange.Name(), metric)
+	}
+	return exchange.GetLongShortRatioHistory(ctx, symbol, period, limit)
 }
 
 // DataCollectionService 数据收集服务
 type DataCollectionService struct {
-	binanceService *BinanceService
-	okxService     *OKXService
-	symbols        []string
+	exchanges []ExchangeService
+
+	mu      sync.RWMutex
+	symbols []string
 }
 
 // NewDataCollectionService 创建新的数据收集服务
-func NewDataCollectionService(symbols []string) *DataCollectionService {
+func NewDataCollectionService(exchanges []ExchangeService, symbols []string) *DataCollectionService {
 	return &DataCollectionService{
-		binanceService: NewBinanceService(),
-		okxService:     NewOKXService(),
-		symbols:        symbols,
+		exchanges: exchanges,
+		symbols:   symbols,
+	}
+}
+
+// CollectAllData 并发收集所有交易所的多空比和其他指标的最新数据，各交易所互不等待。
+// 部分交易对失败时返回成功的数据和CollectErrors
+func (d *DataCollectionService) CollectAllData(ctx context.Context) ([]*LongShortRatioData, error) {
+	var tasks []collectTask[*LongShortRatioData]
+	for _, exchange := range d.exchanges {
+		tasks = append(tasks, d.latestRatioTasks(ctx, exchange)...)
+		tasks = append(tasks, d.extraMetricTasks(ctx, exchange)...)
 	}
+
+	return fanOut(ctx, tasks)
 }
 
-// CollectAllData 收集所有交易所的多空比数据
-func (d *DataCollectionService) CollectAllData() ([]*LongShortRatioData, error) {
-	var allData []*LongShortRatioData
+// CollectRatios 并发收集所有交易所支持的各项指标在指定时间粒度下最近limit个数据点
+func (d *DataCollectionService) CollectRatios(ctx context.Context, period string, limit int) ([]*LongShortRatioData, error) {
+	var tasks []collectTask[*LongShortRatioData]
+	for _, exchange := range d.exchanges {
+		for _, metric := range SupportedMetrics(exchange) {
+			for _, symbol := range d.Symbols() {
+				exchange, metric, symbol := exchange, metric, symbol
+				tasks = append(tasks, collectTask[*LongShortRatioData]{
+					exchange: exchange.Name(),
+					symbol:   symbol,
+					item:     metric + " " + period,
+					fetch: func() ([]*LongShortRatioData, error) {
+						return GetMetricHistory(ctx, exchange, symbol, metric, period, limit)
+					},
+				})
+			}
+		}
+	}
+
+	return fanOut(ctx, tasks)
+}
 
-	// 收集Binance数据
-	binanceData, err := d.binanceService.GetMultipleSymbolsLongShortRatio(d.symbols)
-	if err != nil {
-		// 记录错误但继续
-		fmt.Printf("收集Binance数据失败: %v\n", err)
-	} else {
-		allData = append(allData, binanceData...)
+// latestRatioTasks 收集交易所各交易对最新全市场账户多空比的任务
+func (d *DataCollectionService) latestRatioTasks(ctx context.Context, exchange ExchangeService) []collectTask[*LongShortRatioData] {
+	var tasks []collectTask[*LongShortRatioData]
+	for _, symbol := range d.Symbols() {
+		symbol := symbol
+		tasks = append(tasks, collectTask[*LongShortRatioData]{
+			exchange: exchange.Name(),
+			symbol:   symbol,
+			item:     MetricGlobalAccount,
+			fetch: single(func() (*LongShortRatioData, error) {
+				return exchange.GetLongShortRatio(ctx, symbol)
+			}),
+		})
 	}
+	return tasks
+}
+
+// extraMetricTasks 收集交易所支持的其他指标最新数据的任务
+func (d *DataCollectionService) extraMetricTasks(ctx context.Context, exchange ExchangeService) []collectTask[*LongShortRatioData] {
+	var tasks []collectTask[*LongShortRatioData]
 
-	// 收集OKX数据
-	okxData, err := d.okxService.GetMultipleSymbolsLongShortRatio(d.symbols)
-	if err != nil {
-		// 记录错误但继续
-		fmt.Printf("收集OKX数据失败: %v\n", err)
-	} else {
-		allData = append(allData, okxData...)
+	for _, metric := range SupportedMetrics(exchange) {
+		if metric == MetricGlobalAccount {
+			continue
+		}
+
+		for _, symbol := range d.Symbols() {
+			metric, symbol := metric, symbol
+			tasks = append(tasks, collectTask[*LongShortRatioData]{
+				exchange: exchange.Name(),
+				symbol:   symbol,
+				item:     metric,
+				fetch: func() ([]*LongShortRatioData, error) {
+					data, err := GetMetricHistory(ctx, exchange, symbol, metric, "5m", 1)
+					if err != nil || len(data) == 0 {
+						return nil, err
+					}
+					return data[len(data)-1:], nil
+				},
+			})
+		}
 	}
 
-	return allData, nil
+	return tasks
 }
 
-// GetDataByExchange 根据交易所获取数据
-func (d *DataCollectionService) GetDataByExchange(exchange string) ([]*LongShortRatioData, error) {
-	switch exchange {
-	case "binance":
-		return d.binanceService.GetMultipleSymbolsLongShortRatio(d.symbols)
-	case "okx":
-		return d.okxService.GetMultipleSymbolsLongShortRatio(d.symbols)
-	default:
-		return nil, fmt.Errorf("不支持的交易所: %s", exchange)
+// GetDataByExchange 并发获取指定交易所各交易对的最新多空比，部分交易对失败时返回成功的数据和CollectErrors
+func (d *DataCollectionService) GetDataByExchange(ctx context.Context, exchange string) ([]*LongShortRatioData, error) {
+	for _, svc := range d.exchanges {
+		if svc.Name() == exchange {
+			return fanOut(ctx, d.latestRatioTasks(ctx, svc))
+		}
 	}
+	return nil, fmt.Errorf("不支持的交易所: %s", exchange)
+}
+
+// Symbols 获取参与收集的交易对
+func (d *DataCollectionService) Symbols() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return append([]string(nil), d.symbols...)
+}
+
+// SetSymbols 更新参与收集的交易对
+func (d *DataCollectionService) SetSymbols(symbols []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.symbols = append([]string(nil), symbols...)
+}
+
+// Exchanges 获取参与收集的交易所服务
+func (d *DataCollectionService) Exchanges() []ExchangeService {
+	return d.exchanges
 }